@@ -3,13 +3,17 @@ package main
 import (
 	"context"
 	"log"
+	"net/http"
 	"time"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
 	pgstore "github.com/randomtoy/random-chess-backend/internal/adapters/postgres"
+	"github.com/randomtoy/random-chess-backend/internal/adapters/webhook"
 	"github.com/randomtoy/random-chess-backend/internal/config"
+	"github.com/randomtoy/random-chess-backend/internal/db"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
 	"github.com/randomtoy/random-chess-backend/internal/ports"
 	transporthttp "github.com/randomtoy/random-chess-backend/internal/transport/http"
 	"github.com/randomtoy/random-chess-backend/internal/usecase"
@@ -22,6 +26,22 @@ func main() {
 	rl := memory.AlwaysAllow{}
 
 	if cfg.DatabaseURL != "" {
+		if cfg.RunMigrationsOnStart {
+			migrateCtx, migrateCancel := context.WithTimeout(context.Background(), 5*time.Minute)
+			if err := db.MigrateUp(migrateCtx, cfg.DatabaseURL); err != nil {
+				log.Fatalf("migrations: %v", err)
+			}
+			migrateCancel()
+			log.Println("migrations applied")
+		}
+		if cfg.CheckSchemaOnStart {
+			checkCtx, checkCancel := context.WithTimeout(context.Background(), 30*time.Second)
+			if err := db.CheckSchema(checkCtx, cfg.DatabaseURL); err != nil {
+				log.Fatalf("schema check: %v", err)
+			}
+			checkCancel()
+		}
+
 		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 		pool, err := pgxpool.New(ctx, cfg.DatabaseURL)
 		cancel()
@@ -37,42 +57,178 @@ func main() {
 		pingCancel()
 		log.Println("connected to database")
 
-		pg := pgstore.New(pool)
-		seedIfEmpty(pg, cfg.GameCreateBatchSize)
+		pg := pgstore.New(pool).WithClaimStrategy(cfg.ClaimStrategy)
+		if cfg.PreferInProgress {
+			pg = pg.WithPreferInProgress()
+		}
+		if !cfg.PersistHistory {
+			pg = pg.WithoutHistory()
+		}
+		if cfg.ClaimExclusiveWindow > 0 {
+			pg = pg.WithExclusiveWindow(cfg.ClaimExclusiveWindow)
+		}
+		if cfg.DBAcquireTimeout > 0 {
+			pg = pg.WithAcquireTimeout(cfg.DBAcquireTimeout)
+		}
+		seedPool(pg, cfg.MinWaitingOnStart, cfg.GameCreateBatchSize)
 		store = pg
+		if cfg.RNGSeed != nil {
+			log.Println("RNG_SEED is ignored by the postgres store")
+		}
 	} else {
-		store = memory.New(cfg.GameCreateBatchSize)
+		mem := memory.New(cfg.GameCreateBatchSize)
+		if cfg.RNGSeed != nil {
+			mem = memory.NewSeeded(cfg.GameCreateBatchSize, *cfg.RNGSeed)
+		}
+		mem = mem.WithClaimStrategy(cfg.ClaimStrategy)
+		if cfg.PreferInProgress {
+			mem = mem.WithPreferInProgress()
+		}
+		if !cfg.PersistHistory {
+			mem = mem.WithoutHistory()
+		}
+		if cfg.ClaimExclusiveWindow > 0 {
+			mem = mem.WithExclusiveWindow(cfg.ClaimExclusiveWindow)
+		}
+		store = mem
+	}
+
+	blocklist := usecase.NewBlocklist(store, cfg.BlockedClientIDs)
+	if err := blocklist.Refresh(context.Background()); err != nil {
+		log.Printf("blocklist load failed: %v", err)
+	}
+	go blocklist.Run(context.Background(), cfg.BlocklistRefresh)
+
+	registry := metrics.NewRegistry()
+	// Pool stats describe the database, so they go on the admin-only
+	// registry rather than the public /metrics.
+	adminRegistry := metrics.NewRegistry()
+	if p, ok := store.(ports.PoolStatsProvider); ok {
+		registerPoolStats(adminRegistry, p)
+	}
+	poolMetrics := usecase.NewPoolMetrics(registry)
+	if cfg.TargetWaitingPool > 0 {
+		refiller := usecase.NewPoolRefiller(store, cfg.TargetWaitingPool, cfg.MaxWaitingGames, poolMetrics)
+		go refiller.Run(context.Background(), cfg.PoolRefillInterval)
+	}
+	if cfg.AuditInterval > 0 {
+		mismatches := registry.Counter("audit_inconsistent_games_total", "Sampled games whose columns disagree with their FEN.")
+		auditor := usecase.NewAuditor(store, cfg.AuditSampleSize, mismatches)
+		go auditor.Run(context.Background(), cfg.AuditInterval)
+	}
+	if cfg.GameRetention > 0 {
+		go usecase.NewPurger(store, cfg.GameRetention).Run(context.Background(), cfg.PurgeInterval)
+	}
+
+	var notifier ports.GameCompletionNotifier
+	if cfg.WebhookURL != "" {
+		sender := webhook.New(cfg.WebhookURL, webhook.Options{
+			Secret:  cfg.WebhookSecret,
+			Dropped: registry.Counter("webhook_dropped_total", "Game-completion webhooks dropped because the queue was full."),
+		})
+		go sender.Run(context.Background())
+		notifier = sender
+	}
+	if cfg.HouseMoves {
+		go usecase.NewHouseMover(store, cfg.HouseMoveStall, notifier).Run(context.Background(), cfg.HouseMoveInterval)
 	}
 
 	h := transporthttp.NewHandlers(
 		usecase.NewAssigner(store, rl),
-		usecase.NewNextGame(store, rl, cfg.GameCreateBatchSize),
-		usecase.NewGameGetter(store, rl),
-		usecase.NewMoveSubmitter(store, rl),
+		usecase.NewNextGame(store, rl, cfg.GameCreateBatchSize, usecase.NextGameOptions{
+			SingleActiveClaim:   cfg.SingleActiveClaim,
+			Blocklist:           blocklist,
+			MaxClaimsPerIP:      cfg.MaxGamesPerIP,
+			ClaimCounter:        memory.NewClaimCounter(cfg.MaxGamesPerIPWindow),
+			IPv4PrefixLen:       cfg.IPv4KeyPrefixLen,
+			IPv6PrefixLen:       cfg.IPv6KeyPrefixLen,
+			PoolMetrics:         poolMetrics,
+			MaxConcurrentClaims: cfg.MaxConcurrentClaims,
+			ClaimOverflow:       usecase.ClaimOverflowPolicy(cfg.ClaimOverflow),
+			ClaimQueueTimeout:   cfg.ClaimQueueTimeout,
+		}),
+		usecase.NewGameGetter(store, rl, usecase.GameGetterOptions{
+			HistoryLimit:    cfg.HistoryLimit,
+			DegradeHistory:  cfg.DegradeHistory,
+			HistoryDisabled: !cfg.PersistHistory,
+		}),
+		usecase.NewMoveSubmitter(store, rl, usecase.MoveSubmitterOptions{
+			Cooldown:             cfg.MoveCooldown,
+			Blocklist:            blocklist,
+			DeadLetter:           cfg.DeadLetterMoves,
+			RecordUserAgent:      cfg.RecordUserAgent,
+			BlunderThresholdCP:   cfg.BlunderThresholdCP,
+			RejectMovesOnWaiting: cfg.RejectMovesOnWaiting,
+			Notifier:             notifier,
+			DraftTTL:             cfg.DraftTTL,
+		}),
+		usecase.NewAdmin(store, blocklist),
 	)
 
-	e := transporthttp.New(h)
+	if cfg.CursorSecret == "" {
+		log.Println("CURSOR_SECRET not set; pagination cursors will not survive restarts")
+	}
+	e := transporthttp.New(h, transporthttp.Options{
+		AdminToken:           cfg.AdminToken,
+		AdminSignedRequests:  cfg.AdminSignedRequests,
+		AdminSignatureSkew:   cfg.AdminSignatureSkew,
+		MoveMaxBody:          cfg.MoveMaxBody,
+		AdminMaxBody:         cfg.AdminMaxBody,
+		PlaceholderClientIDs: cfg.PlaceholderClientIDs,
+		CursorSecret:         []byte(cfg.CursorSecret),
+		Metrics:              registry,
+		AdminMetrics:         adminRegistry,
+		RouteInFlight:        cfg.RouteInFlight,
+		BasePath:             cfg.BasePath,
+		FinishedMaxAge:       cfg.FinishedMaxAge,
+		RobotsTxt:            cfg.RobotsTxt,
+		ReadTimeout:          cfg.ReadTimeout,
+		WriteTimeout:         cfg.WriteTimeout,
+		RetryAfter:           cfg.RetryAfter,
+		RetryJitter:          cfg.RetryJitter,
+		ReadOnly:             cfg.ReadOnly,
+		StrictMoveInput:      cfg.StrictMoveInput,
+	})
+	if cfg.EnablePprof {
+		go func() {
+			log.Printf("pprof listening on %s", cfg.PprofAddr)
+			if err := http.ListenAndServe(cfg.PprofAddr, transporthttp.NewPprofHandler()); err != nil {
+				log.Printf("pprof listener: %v", err)
+			}
+		}()
+	}
+
+	if cfg.ReadOnly {
+		log.Println("READ_ONLY set; claims and moves are rejected")
+	}
 	log.Printf("starting on :%s", cfg.Port)
 	log.Fatal(e.Start(":" + cfg.Port))
 }
 
-// seedIfEmpty creates a batch of waiting games if the DB has no active games.
-func seedIfEmpty(store ports.GameStore, batchSize int) {
+// seedPool tops the waiting pool up to minWaiting games, or creates a batch
+// if the DB has no active games when minWaiting is zero.
+func seedPool(store ports.GameStore, minWaiting, batchSize int) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
 	defer cancel()
 
-	has, err := store.HasActiveGames(ctx)
+	created, err := usecase.SeedPool(ctx, store, minWaiting, batchSize)
 	if err != nil {
-		log.Printf("seed check failed: %v", err)
+		log.Printf("seed failed after %d games: %v", created, err)
 		return
 	}
-	if has {
-		return
+	if created > 0 {
+		log.Printf("seeded %d waiting games", created)
 	}
+}
 
-	if err := store.CreateWaitingBatch(ctx, batchSize); err != nil {
-		log.Printf("seed batch failed: %v", err)
-		return
-	}
-	log.Printf("seeded %d waiting games", batchSize)
+// registerPoolStats exposes connection pool utilization: current connection
+// counts as gauges, cumulative acquire totals as counters.
+func registerPoolStats(reg *metrics.Registry, p ports.PoolStatsProvider) {
+	reg.GaugeFunc("db_pool_total_conns", "Open database connections.", func() int64 { return int64(p.Stats().TotalConns) })
+	reg.GaugeFunc("db_pool_idle_conns", "Idle database connections.", func() int64 { return int64(p.Stats().IdleConns) })
+	reg.GaugeFunc("db_pool_in_use_conns", "Database connections in use.", func() int64 { return int64(p.Stats().InUseConns) })
+	reg.CounterFunc("db_pool_acquire_count", "Total successful connection acquires.", func() int64 { return p.Stats().AcquireCount })
+	reg.CounterFunc("db_pool_acquire_duration_ms", "Total time spent acquiring connections, in milliseconds.", func() int64 {
+		return p.Stats().AcquireDuration.Milliseconds()
+	})
 }
@@ -0,0 +1,67 @@
+package memory
+
+import (
+	"sync"
+	"time"
+)
+
+// ClaimCounter is an in-process ports.ClaimCounter using fixed windows.
+// Counts are per process, so each replica enforces its own cap.
+type ClaimCounter struct {
+	mu        sync.Mutex
+	window    time.Duration
+	now       func() time.Time
+	counts    map[string]windowCount
+	lastPrune time.Time
+}
+
+type windowCount struct {
+	start time.Time
+	n     int
+}
+
+// NewClaimCounter creates a ClaimCounter whose counts reset every window.
+func NewClaimCounter(window time.Duration) *ClaimCounter {
+	return &ClaimCounter{window: window, now: time.Now, counts: make(map[string]windowCount)}
+}
+
+func (c *ClaimCounter) Reserve(key string, limit int) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	c.prune(now)
+	wc, ok := c.counts[key]
+	if !ok || now.Sub(wc.start) >= c.window {
+		wc = windowCount{start: now}
+	}
+	if wc.n >= limit {
+		return false
+	}
+	wc.n++
+	c.counts[key] = wc
+	return true
+}
+
+func (c *ClaimCounter) Release(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	wc, ok := c.counts[key]
+	if !ok || c.now().Sub(wc.start) >= c.window || wc.n == 0 {
+		return
+	}
+	wc.n--
+	c.counts[key] = wc
+}
+
+// prune drops expired windows, at most once per window. Callers hold mu.
+func (c *ClaimCounter) prune(now time.Time) {
+	if now.Sub(c.lastPrune) < c.window {
+		return
+	}
+	for k, wc := range c.counts {
+		if now.Sub(wc.start) >= c.window {
+			delete(c.counts, k)
+		}
+	}
+	c.lastPrune = now
+}
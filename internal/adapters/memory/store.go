@@ -1,7 +1,13 @@
 package memory
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
+	"log"
+	"maps"
+	"math/rand/v2"
+	"slices"
 	"sync"
 	"time"
 
@@ -13,36 +19,164 @@ import (
 
 // Store is a thread-safe in-memory GameStore.
 type Store struct {
-	mu sync.Mutex
+	// mu guards state. Transactional views share state with their parent and
+	// use noLock, since InTx already holds the parent's mutex.
+	mu sync.Locker
+	*state
 
+	claimStrategy    ports.ClaimStrategy
+	preferInProgress bool
+	// skipHistory drops move records; see WithoutHistory.
+	skipHistory bool
+	// exclusiveWindow is how long a claim keeps the game to its claimant;
+	// see WithExclusiveWindow.
+	exclusiveWindow time.Duration
+}
+
+type state struct {
 	games map[uuid.UUID]*game.Game
 
-	// assigned: gameID -> set of clientIDs that have been assigned
-	assigned map[uuid.UUID]map[uuid.UUID]struct{}
+	// assigned: gameID -> clientIDs that have been assigned, with claim time
+	assigned map[uuid.UUID]map[uuid.UUID]time.Time
 
 	// moved: gameID -> set of clientIDs that have already made their move
 	moved map[uuid.UUID]map[uuid.UUID]struct{}
 
+	// exclusive: gameID -> the latest claim's exclusive window. While it
+	// holds, nobody else can claim the game, so one entry per game suffices.
+	exclusive map[uuid.UUID]exclusiveHold
+
 	// history: gameID -> ordered move history
 	history map[uuid.UUID][]game.MoveHistoryItem
+
+	// events: gameID -> append-only event log
+	events map[uuid.UUID][]ports.GameEvent
+
+	// lastMove: clientID -> time of the client's most recent accepted move
+	lastMove map[uuid.UUID]time.Time
+
+	// blocked: set of client IDs on the moderation block list
+	blocked map[uuid.UUID]struct{}
+
+	// failedMoves: dead-letter records in insertion order
+	failedMoves []ports.FailedMove
+
+	// rng drives the random claim strategy; newID generates game IDs.
+	rng   *rand.Rand
+	newID func() uuid.UUID
 }
 
 // New creates a Store pre-seeded with seedCount games from the initial position.
 func New(seedCount int) *Store {
+	rng := rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	return newStore(seedCount, rng, uuid.New)
+}
+
+// NewSeeded is like New, but game IDs and the random claim strategy are drawn
+// from a generator seeded with seed, so the same sequence of calls yields the
+// same games in the same order. Intended for tests and demos.
+func NewSeeded(seedCount int, seed uint64) *Store {
+	rng := rand.New(rand.NewPCG(seed, seed))
+	return newStore(seedCount, rng, func() uuid.UUID {
+		var b [16]byte
+		binary.BigEndian.PutUint64(b[:8], rng.Uint64())
+		binary.BigEndian.PutUint64(b[8:], rng.Uint64())
+		b[6] = b[6]&0x0f | 0x40 // version 4
+		b[8] = b[8]&0x3f | 0x80 // RFC 4122 variant
+		return uuid.UUID(b)
+	})
+}
+
+func newStore(seedCount int, rng *rand.Rand, newID func() uuid.UUID) *Store {
 	s := &Store{
-		games:    make(map[uuid.UUID]*game.Game, seedCount),
-		assigned: make(map[uuid.UUID]map[uuid.UUID]struct{}),
-		moved:    make(map[uuid.UUID]map[uuid.UUID]struct{}),
-		history:  make(map[uuid.UUID][]game.MoveHistoryItem),
+		mu: &sync.Mutex{},
+		state: &state{
+			games:     make(map[uuid.UUID]*game.Game, seedCount),
+			assigned:  make(map[uuid.UUID]map[uuid.UUID]time.Time),
+			moved:     make(map[uuid.UUID]map[uuid.UUID]struct{}),
+			exclusive: make(map[uuid.UUID]exclusiveHold),
+			history:   make(map[uuid.UUID][]game.MoveHistoryItem),
+			events:    make(map[uuid.UUID][]ports.GameEvent),
+			lastMove:  make(map[uuid.UUID]time.Time),
+			blocked:   make(map[uuid.UUID]struct{}),
+			rng:       rng,
+			newID:     newID,
+		},
 	}
 	now := time.Now()
 	for i := 0; i < seedCount; i++ {
-		g := game.NewGame(uuid.New(), now)
+		g := game.NewGame(s.newID(), now)
 		s.games[g.ID] = g
+		s.logEvent(g.ID, ports.GameEvent{Kind: ports.EventCreated, CreatedAt: now})
 	}
 	return s
 }
 
+// InTx emulates a transaction: it holds the store mutex for the duration of
+// fn and restores a snapshot of the state if fn returns an error.
+func (s *Store) InTx(_ context.Context, fn func(txStore ports.GameStore) error) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	snapshot := s.state.clone()
+	if err := fn(&Store{mu: noLock{}, state: s.state, claimStrategy: s.claimStrategy, preferInProgress: s.preferInProgress, skipHistory: s.skipHistory, exclusiveWindow: s.exclusiveWindow}); err != nil {
+		*s.state = *snapshot
+		return err
+	}
+	return nil
+}
+
+// WithClaimStrategy returns a view of s whose ClaimNextGame orders eligible
+// games by strategy. The view shares state with s.
+func (s *Store) WithClaimStrategy(strategy ports.ClaimStrategy) *Store {
+	c := *s
+	c.claimStrategy = strategy
+	return &c
+}
+
+// WithPreferInProgress returns a view of s whose ClaimNextGame hands out
+// games that already have moves before untouched ones, applying the claim
+// strategy within each group.
+func (s *Store) WithPreferInProgress() *Store {
+	c := *s
+	c.preferInProgress = true
+	return &c
+}
+
+// WithoutHistory returns a view of the store that applies moves to the game
+// state without recording them, so move history stays empty.
+func (s *Store) WithoutHistory() *Store {
+	c := *s
+	c.skipHistory = true
+	return &c
+}
+
+// WithExclusiveWindow returns a view of s whose claims hold the game for the
+// claimant alone for d: until it moves or d elapses, ClaimNextGame hands the
+// game to nobody else. Zero disables the window.
+func (s *Store) WithExclusiveWindow(d time.Duration) *Store {
+	c := *s
+	c.exclusiveWindow = d
+	return &c
+}
+
+// exclusiveHold is a claimant's exclusive window on a game.
+type exclusiveHold struct {
+	clientID uuid.UUID
+	until    time.Time
+}
+
+// heldByOther reports whether another client's unmoved claim still holds
+// gameID exclusively at now. Callers hold the lock.
+func (st *state) heldByOther(gameID, clientID uuid.UUID, now time.Time) bool {
+	h, ok := st.exclusive[gameID]
+	if !ok || h.clientID == clientID || !now.Before(h.until) {
+		return false
+	}
+	_, moved := st.moved[gameID][h.clientID]
+	return !moved
+}
+
 func (s *Store) GetByID(_ context.Context, id uuid.UUID) (*game.Game, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -65,9 +199,76 @@ func (s *Store) ListOngoing(_ context.Context) ([]*game.Game, error) {
 	return out, nil
 }
 
+func (s *Store) ListChangedSince(_ context.Context, since time.Time, afterID uuid.UUID, limit int) ([]*game.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []*game.Game{}
+	for _, g := range s.games {
+		if c := g.UpdatedAt.Compare(since); c > 0 || (c == 0 && bytes.Compare(g.ID[:], afterID[:]) > 0) {
+			out = append(out, g)
+		}
+	}
+	slices.SortFunc(out, func(a, b *game.Game) int {
+		if c := a.UpdatedAt.Compare(b.UpdatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *Store) ListGames(_ context.Context, filter ports.GameFilter, afterID uuid.UUID, limit int) ([]*game.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []*game.Game{}
+	for _, g := range s.games {
+		if filter.Matches(g) && bytes.Compare(g.ID[:], afterID[:]) > 0 {
+			out = append(out, g)
+		}
+	}
+	slices.SortFunc(out, func(a, b *game.Game) int { return bytes.Compare(a.ID[:], b.ID[:]) })
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *Store) SampleForAudit(_ context.Context, n int) ([]*game.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := slices.Collect(maps.Values(s.games))
+	s.rng.Shuffle(len(out), func(i, j int) { out[i], out[j] = out[j], out[i] })
+	if len(out) > n {
+		out = out[:n]
+	}
+	return out, nil
+}
+
+func (s *Store) RandomOngoing(_ context.Context) (*game.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ongoing []*game.Game
+	for _, g := range s.games {
+		if g.Status == game.StatusOngoing {
+			ongoing = append(ongoing, g)
+		}
+	}
+	if len(ongoing) == 0 {
+		return nil, ports.ErrNotFound
+	}
+	return s.randomOf(ongoing), nil
+}
+
 // SaveIfVersion overwrites the game only when the current stored StateVersion
 // equals expectedVersion, providing optimistic concurrency safety.
 func (s *Store) SaveIfVersion(_ context.Context, g *game.Game, expectedVersion int) error {
+	if g.StateVersion != expectedVersion+1 {
+		log.Printf("game %s: refusing to save state_version %d over expected %d", g.ID, g.StateVersion, expectedVersion)
+		return ports.ErrVersionNotIncremented
+	}
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	cur, ok := s.games[g.ID]
@@ -92,54 +293,81 @@ func (s *Store) HasActiveGames(_ context.Context) (bool, error) {
 	return false, nil
 }
 
-func (s *Store) CreateWaitingBatch(_ context.Context, count int) error {
+// Stats returns zeros: the memory store has no connection pool.
+func (s *Store) Stats() ports.PoolStats { return ports.PoolStats{} }
+
+func (s *Store) CountWaiting(_ context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, g := range s.games {
+		if g.Status == game.StatusWaiting {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (s *Store) TotalMoves(_ context.Context) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var n int64
+	for _, g := range s.games {
+		n += int64(g.PlyCount)
+	}
+	return n, nil
+}
+
+func (s *Store) CreateWaitingBatch(_ context.Context, count int) (int, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	now := time.Now()
+	created := 0
 	for i := 0; i < count; i++ {
-		id := uuid.New()
+		id := s.newID()
+		if _, exists := s.games[id]; exists {
+			continue
+		}
+		created++
 		g := game.NewGame(id, now)
 		// NewGame sets StatusOngoing; override to StatusWaiting.
 		waiting := *g
 		waiting.Status = game.StatusWaiting
 		s.games[id] = &waiting
+		s.logEvent(id, ports.GameEvent{Kind: ports.EventCreated, CreatedAt: now})
 	}
-	return nil
+	return created, nil
 }
 
 func (s *Store) ClaimNextGame(_ context.Context, clientID uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	var chosen *game.Game
-	for _, g := range s.games {
-		if g.Status != game.StatusWaiting && g.Status != game.StatusOngoing {
-			continue
-		}
-		if assignedSet, ok := s.assigned[g.ID]; ok {
-			if _, alreadyAssigned := assignedSet[clientID]; alreadyAssigned {
-				continue
-			}
-		}
-		chosen = g
-		break
-	}
-	if chosen == nil {
+	now := time.Now()
+	eligible := s.claimable(clientID, now)
+	if len(eligible) == 0 {
 		return nil, nil, ports.ErrNoGamesAvailable
 	}
+	chosen := s.pick(eligible)
 
 	// Claim.
 	if s.assigned[chosen.ID] == nil {
-		s.assigned[chosen.ID] = make(map[uuid.UUID]struct{})
+		s.assigned[chosen.ID] = make(map[uuid.UUID]time.Time)
 	}
-	s.assigned[chosen.ID][clientID] = struct{}{}
+	s.assigned[chosen.ID][clientID] = now
+	if s.exclusiveWindow > 0 {
+		s.exclusive[chosen.ID] = exclusiveHold{clientID: clientID, until: now.Add(s.exclusiveWindow)}
+	}
+	s.logEvent(chosen.ID, ports.GameEvent{Kind: ports.EventClaimed, Actor: &clientID, CreatedAt: now})
 
 	// Transition waiting -> ongoing.
 	if chosen.Status == game.StatusWaiting {
 		updated := *chosen
 		updated.Status = game.StatusOngoing
+		updated.UpdatedAt = now
 		s.games[chosen.ID] = &updated
 		chosen = &updated
+		s.logEvent(chosen.ID, ports.GameEvent{Kind: ports.EventStatusChange, Actor: &clientID, Detail: string(updated.Status), CreatedAt: now})
 	}
 
 	hist := s.history[chosen.ID]
@@ -149,6 +377,110 @@ func (s *Store) ClaimNextGame(_ context.Context, clientID uuid.UUID) (*game.Game
 	return chosen, hist, nil
 }
 
+func (s *Store) PeekNextGameID(_ context.Context, clientID uuid.UUID) (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	eligible := s.claimable(clientID, time.Now())
+	if len(eligible) == 0 {
+		return uuid.Nil, ports.ErrNoGamesAvailable
+	}
+	return s.pick(eligible).ID, nil
+}
+
+// claimable returns the waiting/ongoing games clientID has not claimed and
+// nobody else holds exclusively. Callers hold the lock.
+func (s *Store) claimable(clientID uuid.UUID, now time.Time) []*game.Game {
+	var eligible []*game.Game
+	for _, g := range s.games {
+		if g.Status != game.StatusWaiting && g.Status != game.StatusOngoing {
+			continue
+		}
+		if s.heldByOther(g.ID, clientID, now) {
+			continue
+		}
+		if assignedSet, ok := s.assigned[g.ID]; ok {
+			if _, alreadyAssigned := assignedSet[clientID]; alreadyAssigned {
+				continue
+			}
+		}
+		eligible = append(eligible, g)
+	}
+	return eligible
+}
+
+// pick chooses among eligible games according to the claim strategy,
+// mirroring the ORDER BY clauses of the postgres store.
+func (s *Store) pick(eligible []*game.Game) *game.Game {
+	if s.preferInProgress {
+		started := slices.DeleteFunc(slices.Clone(eligible), func(g *game.Game) bool { return g.PlyCount == 0 })
+		if len(started) > 0 {
+			eligible = started
+		}
+	}
+	switch s.claimStrategy {
+	case ports.ClaimRandom:
+		return s.randomOf(eligible)
+	case ports.ClaimMostActive:
+		return slices.MinFunc(eligible, func(a, b *game.Game) int {
+			if c := b.UpdatedAt.Compare(a.UpdatedAt); c != 0 {
+				return c
+			}
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+	default:
+		return slices.MinFunc(eligible, func(a, b *game.Game) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+	}
+}
+
+// randomClaimCandidates mirrors the postgres store's bound on how many of the
+// oldest eligible games ClaimRandom picks from.
+const randomClaimCandidates = 100
+
+// randomOf picks one of the oldest randomClaimCandidates games with the
+// store's rng. games comes from map iteration, so it is sorted first, which
+// also lets a seeded rng reproduce the choice.
+func (s *Store) randomOf(games []*game.Game) *game.Game {
+	slices.SortFunc(games, func(a, b *game.Game) int {
+		if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.ID[:], b.ID[:])
+	})
+	games = games[:min(len(games), randomClaimCandidates)]
+	return games[s.rng.IntN(len(games))]
+}
+
+func (s *Store) CountAvailableForClient(_ context.Context, clientID uuid.UUID) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.claimable(clientID, time.Now())), nil
+}
+
+// LockClient is a no-op: InTx already holds the store lock for the whole
+// transaction.
+func (s *Store) LockClient(context.Context, uuid.UUID) error { return nil }
+
+func (s *Store) FindActiveClaim(_ context.Context, clientID uuid.UUID) (uuid.UUID, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for gameID, assignedSet := range s.assigned {
+		if _, ok := assignedSet[clientID]; !ok {
+			continue
+		}
+		if _, moved := s.moved[gameID][clientID]; moved {
+			continue
+		}
+		g := s.games[gameID]
+		if g == nil || (g.Status != game.StatusWaiting && g.Status != game.StatusOngoing) {
+			continue
+		}
+		return gameID, true, nil
+	}
+	return uuid.Nil, false, nil
+}
+
 func (s *Store) GetGameWithHistory(_ context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -163,6 +495,134 @@ func (s *Store) GetGameWithHistory(_ context.Context, id uuid.UUID) (*game.Game,
 	return g, hist, nil
 }
 
+func (s *Store) GetMovesSince(_ context.Context, gameID uuid.UUID, fromPly int) ([]game.MoveHistoryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []game.MoveHistoryItem{}
+	for _, item := range s.history[gameID] {
+		if item.Ply >= fromPly {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) GetMovesPage(_ context.Context, gameID uuid.UUID, fromPly, limit int) ([]game.MoveHistoryItem, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []game.MoveHistoryItem{}
+	for _, item := range s.history[gameID] {
+		if len(out) == limit {
+			break
+		}
+		if item.Ply >= fromPly {
+			out = append(out, item)
+		}
+	}
+	return out, nil
+}
+
+func (s *Store) ListContributors(_ context.Context, gameID uuid.UUID) ([]ports.Contributor, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []ports.Contributor{}
+	seen := make(map[uuid.UUID]struct{})
+	// History is kept in ply order, so first sight is the first ply.
+	for _, item := range s.history[gameID] {
+		if _, ok := seen[item.ClientID]; ok {
+			continue
+		}
+		seen[item.ClientID] = struct{}{}
+		out = append(out, ports.Contributor{ClientID: item.ClientID, FirstPly: item.Ply})
+	}
+	return out, nil
+}
+
+func (s *Store) ListClientClaims(_ context.Context, clientID uuid.UUID, since time.Time, afterGameID uuid.UUID, limit int) ([]ports.ClientClaim, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := []ports.ClientClaim{}
+	for gameID, assignedSet := range s.assigned {
+		claimedAt, ok := assignedSet[clientID]
+		if !ok {
+			continue
+		}
+		if c := claimedAt.Compare(since); c < 0 || (c == 0 && bytes.Compare(gameID[:], afterGameID[:]) <= 0) {
+			continue
+		}
+		claim := ports.ClientClaim{GameID: gameID, ClaimedAt: claimedAt}
+		_, claim.HasMoved = s.moved[gameID][clientID]
+		for _, item := range s.history[gameID] {
+			if item.ClientID == clientID {
+				claim.Move = &item
+				break
+			}
+		}
+		out = append(out, claim)
+	}
+	slices.SortFunc(out, func(a, b ports.ClientClaim) int {
+		if c := a.ClaimedAt.Compare(b.ClaimedAt); c != 0 {
+			return c
+		}
+		return bytes.Compare(a.GameID[:], b.GameID[:])
+	})
+	if len(out) > limit {
+		out = out[:limit]
+	}
+	return out, nil
+}
+
+func (s *Store) ListGameEvents(_ context.Context, gameID uuid.UUID) ([]ports.GameEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]ports.GameEvent{}, s.events[gameID]...), nil
+}
+
+func (s *Store) IsAssigned(_ context.Context, gameID, clientID uuid.UUID) (bool, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.assigned[gameID][clientID]; !ok {
+		return false, false, nil
+	}
+	_, moved := s.moved[gameID][clientID]
+	return true, moved, nil
+}
+
+func (s *Store) PurgeFinished(_ context.Context, olderThan time.Time) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	purged := make(map[uuid.UUID]struct{})
+	for id, g := range s.games {
+		if g.Status == game.StatusWaiting || g.Status == game.StatusOngoing || !g.UpdatedAt.Before(olderThan) {
+			continue
+		}
+		purged[id] = struct{}{}
+		delete(s.games, id)
+		delete(s.assigned, id)
+		delete(s.moved, id)
+		delete(s.exclusive, id)
+		delete(s.history, id)
+		delete(s.events, id)
+	}
+	if len(purged) > 0 {
+		s.failedMoves = slices.DeleteFunc(slices.Clone(s.failedMoves), func(fm ports.FailedMove) bool {
+			_, ok := purged[fm.GameID]
+			return ok
+		})
+	}
+	return len(purged), nil
+}
+
+func (s *Store) LastMoveAt(_ context.Context, clientID uuid.UUID) (*time.Time, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	at, ok := s.lastMove[clientID]
+	if !ok {
+		return nil, nil
+	}
+	return &at, nil
+}
+
 func (s *Store) PersistMove(
 	_ context.Context,
 	gameID, clientID uuid.UUID,
@@ -188,23 +648,57 @@ func (s *Store) PersistMove(
 			return nil, ports.ErrAlreadyMoved
 		}
 	}
+	if s.heldByOther(gameID, clientID, time.Now()) {
+		return nil, ports.ErrClaimHeld
+	}
+
+	if err := s.appendMove(gameID, clientID, newGame, rec, ply); err != nil {
+		return nil, err
+	}
+	if s.moved[gameID] == nil {
+		s.moved[gameID] = make(map[uuid.UUID]struct{})
+	}
+	s.moved[gameID][clientID] = struct{}{}
+	s.lastMove[clientID] = rec.CreatedAt
+
+	hist := s.history[gameID]
+	if hist == nil {
+		hist = []game.MoveHistoryItem{}
+	}
+	return hist, nil
+}
+
+func (s *Store) PersistHouseMove(
+	_ context.Context,
+	gameID, clientID uuid.UUID,
+	newGame *game.Game,
+	rec game.MoveRecord,
+	ply int,
+) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.appendMove(gameID, clientID, newGame, rec, ply)
+}
 
-	// CAS check.
+// appendMove replaces the game with newGame if its version is still the one
+// newGame was derived from, logs the move and, unless history is skipped,
+// appends rec to the game's history.
+func (s *Store) appendMove(gameID, clientID uuid.UUID, newGame *game.Game, rec game.MoveRecord, ply int) error {
 	cur, ok := s.games[gameID]
 	if !ok {
-		return nil, ports.ErrNotFound
+		return ports.ErrNotFound
 	}
 	if cur.StateVersion != newGame.StateVersion-1 {
-		return nil, ports.ErrVersionConflict
+		return ports.ErrVersionConflict
 	}
-
-	// Persist.
 	s.games[gameID] = newGame
-
-	if s.moved[gameID] == nil {
-		s.moved[gameID] = make(map[uuid.UUID]struct{})
+	s.logEvent(gameID, ports.GameEvent{Kind: ports.EventMove, Actor: &clientID, Detail: rec.UCI, CreatedAt: rec.CreatedAt})
+	if newGame.Status != game.StatusOngoing {
+		s.logEvent(gameID, ports.GameEvent{Kind: ports.EventStatusChange, Actor: &clientID, Detail: string(newGame.Status), CreatedAt: rec.CreatedAt})
+	}
+	if s.skipHistory {
+		return nil
 	}
-	s.moved[gameID][clientID] = struct{}{}
 
 	fromSq := rec.UCI[:2]
 	toSq := rec.UCI[2:4]
@@ -213,18 +707,145 @@ func (s *Store) PersistMove(
 		p := rec.UCI[4:]
 		promotion = &p
 	}
-	item := game.MoveHistoryItem{
-		Ply:       ply,
-		UCI:       rec.UCI,
-		FromSq:    fromSq,
-		ToSq:      toSq,
-		Promotion: promotion,
-		ClientID:  clientID,
-		FENBefore: rec.FENBefore,
-		FENAfter:  rec.FENAfter,
-		CreatedAt: rec.CreatedAt,
+	s.history[gameID] = append(s.history[gameID], game.MoveHistoryItem{
+		ID:          rec.ID,
+		Ply:         ply,
+		UCI:         rec.UCI,
+		FromSq:      fromSq,
+		ToSq:        toSq,
+		Promotion:   promotion,
+		ClientID:    clientID,
+		FENBefore:   rec.FENBefore,
+		FENAfter:    rec.FENAfter,
+		IsCapture:   rec.IsCapture,
+		IsEnPassant: rec.IsEnPassant,
+		IsCastle:    rec.IsCastle,
+		IsBlunder:   rec.IsBlunder,
+		CreatedAt:   rec.CreatedAt,
+		UserAgent:   rec.UserAgent,
+	})
+	return nil
+}
+
+// logEvent appends ev to the game's event log. Callers hold the lock.
+func (st *state) logEvent(gameID uuid.UUID, ev ports.GameEvent) {
+	st.events[gameID] = append(st.events[gameID], ev)
+}
+
+func (s *Store) RevertUnmovedClaim(_ context.Context, gameID, clientID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	g, ok := s.games[gameID]
+	if !ok {
+		return ports.ErrNotFound
+	}
+	if _, assigned := s.assigned[gameID][clientID]; !assigned {
+		return ports.ErrNotAssigned
+	}
+	if _, moved := s.moved[gameID][clientID]; moved {
+		return ports.ErrAlreadyMoved
+	}
+	if g.PlyCount > 0 {
+		return ports.ErrGameHasMoves
+	}
+
+	now := time.Now()
+	delete(s.assigned[gameID], clientID)
+	if s.exclusive[gameID].clientID == clientID {
+		delete(s.exclusive, gameID)
+	}
+	s.logEvent(gameID, ports.GameEvent{Kind: ports.EventClaimReverted, Actor: &clientID, CreatedAt: now})
+
+	// With no moves, every remaining claimant is an unmoved one.
+	if len(s.assigned[gameID]) == 0 && g.Status == game.StatusOngoing {
+		updated := *g
+		updated.Status = game.StatusWaiting
+		updated.UpdatedAt = now
+		s.games[gameID] = &updated
+		s.logEvent(gameID, ports.GameEvent{Kind: ports.EventStatusChange, Detail: string(updated.Status), CreatedAt: now})
+	}
+	return nil
+}
+
+func (s *Store) RecordFailedMove(_ context.Context, fm ports.FailedMove) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failedMoves = append(s.failedMoves, fm)
+	return nil
+}
+
+func (s *Store) UpdateMetadata(_ context.Context, id uuid.UUID, patch ports.MetadataPatch) (*game.Game, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	cur, ok := s.games[id]
+	if !ok {
+		return nil, ports.ErrNotFound
+	}
+	updated := *cur
+	if patch.TitleSet {
+		updated.Title = patch.Title
+	}
+	if patch.TagsSet {
+		updated.Tags = append([]string{}, patch.Tags...)
+	}
+	updated.UpdatedAt = time.Now()
+	s.games[id] = &updated
+	return &updated, nil
+}
+
+func (s *Store) ListBlockedClients(_ context.Context) ([]uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]uuid.UUID, 0, len(s.blocked))
+	for id := range s.blocked {
+		out = append(out, id)
 	}
-	s.history[gameID] = append(s.history[gameID], item)
+	return out, nil
+}
+
+func (s *Store) BlockClient(_ context.Context, clientID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.blocked[clientID] = struct{}{}
+	return nil
+}
+
+func (s *Store) UnblockClient(_ context.Context, clientID uuid.UUID) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.blocked, clientID)
+	return nil
+}
 
-	return s.history[gameID], nil
+// clone copies st deeply enough for rollback: games are replaced rather than
+// mutated and history and event slices are only appended to, so the per-game sets are
+// the only nested values that need their own copies.
+func (st *state) clone() *state {
+	c := &state{
+		games:       maps.Clone(st.games),
+		assigned:    make(map[uuid.UUID]map[uuid.UUID]time.Time, len(st.assigned)),
+		moved:       make(map[uuid.UUID]map[uuid.UUID]struct{}, len(st.moved)),
+		exclusive:   maps.Clone(st.exclusive),
+		history:     maps.Clone(st.history),
+		events:      maps.Clone(st.events),
+		lastMove:    maps.Clone(st.lastMove),
+		blocked:     maps.Clone(st.blocked),
+		failedMoves: st.failedMoves,
+		rng:         st.rng,
+		newID:       st.newID,
+	}
+	for k, v := range st.assigned {
+		c.assigned[k] = maps.Clone(v)
+	}
+	for k, v := range st.moved {
+		c.moved[k] = maps.Clone(v)
+	}
+	return c
 }
+
+// noLock is the sync.Locker of a transactional view.
+type noLock struct{}
+
+func (noLock) Lock()   {}
+func (noLock) Unlock() {}
@@ -0,0 +1,95 @@
+package memory_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+func TestRevertUnmovedClaim_Unmoved(t *testing.T) {
+	s := memory.New(0)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	first, second := uuid.New(), uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, first)
+	if err != nil {
+		t.Fatalf("claim 1: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, second); err != nil {
+		t.Fatalf("claim 2: %v", err)
+	}
+
+	// Another unmoved claimant remains, so the game stays ongoing.
+	if err := s.RevertUnmovedClaim(ctx, g.ID, first); err != nil {
+		t.Fatalf("revert first: %v", err)
+	}
+	if assigned, _, _ := s.IsAssigned(ctx, g.ID, first); assigned {
+		t.Fatal("first claim still present")
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusOngoing {
+		t.Fatalf("want ongoing with a claimant left, got %s", got.Status)
+	}
+
+	if err := s.RevertUnmovedClaim(ctx, g.ID, second); err != nil {
+		t.Fatalf("revert second: %v", err)
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusWaiting {
+		t.Fatalf("want waiting, got %s", got.Status)
+	}
+	if err := s.RevertUnmovedClaim(ctx, g.ID, second); err != ports.ErrNotAssigned {
+		t.Fatalf("repeat revert: want ErrNotAssigned, got %v", err)
+	}
+	events, err := s.ListGameEvents(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if last := events[len(events)-1]; last.Kind != ports.EventStatusChange || last.Detail != string(game.StatusWaiting) {
+		t.Fatalf("want a status_change to waiting last, got %+v", last)
+	}
+}
+
+func TestRevertUnmovedClaim_Moved(t *testing.T) {
+	s := memory.New(0)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	mover, waiter := uuid.New(), uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, mover)
+	if err != nil {
+		t.Fatalf("claim 1: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, waiter); err != nil {
+		t.Fatalf("claim 2: %v", err)
+	}
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, mover, newGame, rec, 0); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	if err := s.RevertUnmovedClaim(ctx, g.ID, mover); err != ports.ErrAlreadyMoved {
+		t.Fatalf("mover: want ErrAlreadyMoved, got %v", err)
+	}
+	if err := s.RevertUnmovedClaim(ctx, g.ID, waiter); err != ports.ErrGameHasMoves {
+		t.Fatalf("waiter: want ErrGameHasMoves, got %v", err)
+	}
+	if assigned, _, _ := s.IsAssigned(ctx, g.ID, waiter); !assigned {
+		t.Fatal("a refused revert must keep the claim")
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusOngoing {
+		t.Fatalf("want ongoing, got %s", got.Status)
+	}
+}
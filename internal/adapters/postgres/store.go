@@ -3,10 +3,13 @@ package postgres
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgxpool"
 
 	"github.com/randomtoy/random-chess-backend/internal/domain/game"
@@ -17,16 +20,53 @@ const initialFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
 
 const queryGetByID = `
 SELECT id, status, result, fen, side_to_move, ply_count,
-       last_move_uci, last_move_at, state_version, created_at, updated_at
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
 FROM games
 WHERE id = $1`
 
 const queryListOngoing = `
 SELECT id, status, result, fen, side_to_move, ply_count,
-       last_move_uci, last_move_at, state_version, created_at, updated_at
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
 FROM games
 WHERE status = 'ongoing'`
 
+const queryListChangedSince = `
+SELECT id, status, result, fen, side_to_move, ply_count,
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
+FROM games
+WHERE (updated_at, id) > ($1, $2)
+ORDER BY updated_at, id
+LIMIT $3`
+
+const queryListGames = `
+SELECT id, status, result, fen, side_to_move, ply_count,
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
+FROM games
+WHERE ($1 = '' OR status = $1) AND ($2 = '' OR result = $2) AND id > $3
+ORDER BY id
+LIMIT $4`
+
+const queryRandomOngoing = `
+SELECT id, status, result, fen, side_to_move, ply_count,
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
+FROM games
+WHERE status = 'ongoing'
+ORDER BY random()
+LIMIT 1`
+
+const querySampleForAudit = `
+SELECT id, status, result, fen, side_to_move, ply_count,
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
+FROM games
+ORDER BY random()
+LIMIT $1`
+
 const querySaveIfVersion = `
 UPDATE games SET
     status        = $1,
@@ -40,51 +80,255 @@ UPDATE games SET
     updated_at    = $9
 WHERE id = $10 AND state_version = $11`
 
+// queryInsert creates a game and logs its created event in one statement;
+// rows affected counts the games actually created.
 const queryInsert = `
-INSERT INTO games
-    (id, status, result, fen, side_to_move, ply_count,
-     last_move_uci, last_move_at, state_version, created_at, updated_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
-ON CONFLICT (id) DO NOTHING`
+WITH created AS (
+    INSERT INTO games
+        (id, status, result, fen, side_to_move, ply_count,
+         last_move_uci, last_move_at, state_version, created_at, updated_at)
+    VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+    ON CONFLICT (id) DO NOTHING
+    RETURNING id, created_at
+)
+INSERT INTO game_events (game_id, kind, created_at)
+SELECT id, 'created', created_at FROM created`
+
+const queryInsertEvent = `
+INSERT INTO game_events (game_id, kind, actor, detail, created_at)
+VALUES ($1, $2, $3, $4, $5)`
+
+const queryListGameEvents = `
+SELECT kind, actor, detail, created_at FROM game_events
+WHERE game_id = $1
+ORDER BY id`
 
 const queryHasActive = `SELECT EXISTS(SELECT 1 FROM games WHERE status IN ('waiting','ongoing'))`
 
+const queryCountWaiting = `SELECT COUNT(*) FROM games WHERE status = 'waiting'`
+
+const queryTotalMoves = `SELECT COALESCE(SUM(ply_count), 0) FROM games`
+
 const queryClaimNextGame = `
 SELECT id, status, result, fen, side_to_move, ply_count,
-       last_move_uci, last_move_at, state_version, created_at, updated_at
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
 FROM games
 WHERE status IN ('waiting', 'ongoing')
   AND NOT EXISTS (
       SELECT 1 FROM game_players
       WHERE game_id = games.id AND client_id = $1
   )
-ORDER BY created_at ASC
+  AND NOT EXISTS (
+      SELECT 1 FROM game_players
+      WHERE game_id = games.id AND NOT has_moved AND exclusive_until > $2
+  )
+ORDER BY %s
 LIMIT 1
 FOR UPDATE SKIP LOCKED`
 
+// randomClaimCandidates bounds how many eligible games ClaimRandom shuffles;
+// keep it in step with the number documented on ports.ClaimRandom.
+const randomClaimCandidates = 100
+
+// queryClaimRandomGame is queryClaimNextGame for ClaimRandom: it picks at
+// random among the first randomClaimCandidates eligible games in claim order,
+// so it never sorts the whole eligible set by random().
+const queryClaimRandomGame = `
+SELECT id, status, result, fen, side_to_move, ply_count,
+       last_move_uci, last_move_at, state_version, created_at, updated_at,
+       title, tags
+FROM games
+WHERE status IN ('waiting', 'ongoing')
+  AND id IN (
+      SELECT id FROM games
+      WHERE status IN ('waiting', 'ongoing')
+        AND NOT EXISTS (
+            SELECT 1 FROM game_players
+            WHERE game_id = games.id AND client_id = $1
+        )
+        AND NOT EXISTS (
+            SELECT 1 FROM game_players
+            WHERE game_id = games.id AND NOT has_moved AND exclusive_until > $2
+        )
+      ORDER BY %s
+      LIMIT %d
+  )
+ORDER BY %s
+LIMIT 1
+FOR UPDATE SKIP LOCKED`
+
+// claimOrderBy maps each claim strategy to the ORDER BY of queryClaimNextGame.
+var claimOrderBy = map[ports.ClaimStrategy]string{
+	ports.ClaimOldest:     "created_at ASC",
+	ports.ClaimRandom:     "random()",
+	ports.ClaimMostActive: "updated_at DESC, created_at ASC",
+}
+
+// queryPeekNextGameID is queryClaimNextGame without the row lock and the
+// columns ClaimNextGame needs to return the game.
+const queryPeekNextGameID = `
+SELECT id
+FROM games
+WHERE status IN ('waiting', 'ongoing')
+  AND NOT EXISTS (
+      SELECT 1 FROM game_players
+      WHERE game_id = games.id AND client_id = $1
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM game_players
+      WHERE game_id = games.id AND NOT has_moved AND exclusive_until > $2
+  )
+ORDER BY %s
+LIMIT 1`
+
+// queryPeekRandomGameID is queryClaimRandomGame without the row lock and the
+// columns ClaimNextGame needs to return the game.
+const queryPeekRandomGameID = `
+SELECT id
+FROM games
+WHERE id IN (
+    SELECT id FROM games
+    WHERE status IN ('waiting', 'ongoing')
+      AND NOT EXISTS (
+          SELECT 1 FROM game_players
+          WHERE game_id = games.id AND client_id = $1
+      )
+      AND NOT EXISTS (
+          SELECT 1 FROM game_players
+          WHERE game_id = games.id AND NOT has_moved AND exclusive_until > $2
+      )
+    ORDER BY %s
+    LIMIT %d
+)
+ORDER BY %s
+LIMIT 1`
+
+const queryCountAvailableForClient = `
+SELECT COUNT(*)
+FROM games
+WHERE status IN ('waiting', 'ongoing')
+  AND NOT EXISTS (
+      SELECT 1 FROM game_players
+      WHERE game_id = games.id AND client_id = $1
+  )
+  AND NOT EXISTS (
+      SELECT 1 FROM game_players
+      WHERE game_id = games.id AND NOT has_moved AND exclusive_until > $2
+  )`
+
+// queryHeldByOther reports whether another client's unmoved claim still
+// holds the game exclusively.
+const queryHeldByOther = `
+SELECT EXISTS (
+    SELECT 1 FROM game_players
+    WHERE game_id = $1 AND client_id <> $2 AND NOT has_moved AND exclusive_until > $3
+)`
+
 const queryInsertGamePlayer = `
-INSERT INTO game_players (game_id, client_id, has_moved, created_at)
-VALUES ($1, $2, false, NOW())
+INSERT INTO game_players (game_id, client_id, has_moved, created_at, exclusive_until)
+VALUES ($1, $2, false, $3, $4)
 ON CONFLICT (game_id, client_id) DO NOTHING`
 
+const queryFindActiveClaim = `
+SELECT gp.game_id
+FROM game_players gp
+JOIN games g ON g.id = gp.game_id
+WHERE gp.client_id = $1
+  AND NOT gp.has_moved
+  AND g.status IN ('waiting', 'ongoing')
+ORDER BY gp.created_at DESC
+LIMIT 1`
+
+// queryLockClient takes a transaction-scoped advisory lock on the client.
+const queryLockClient = `
+SELECT pg_advisory_xact_lock(hashtextextended($1::text, 0))`
+
 const queryActivateGame = `
-UPDATE games SET status = 'ongoing', updated_at = NOW()
+UPDATE games SET status = 'ongoing', updated_at = $2
 WHERE id = $1 AND status = 'waiting'`
 
-const queryMoveHistory = `
-SELECT ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after, created_at
+const queryDeactivateGame = `
+UPDATE games SET status = 'waiting', updated_at = $2
+WHERE id = $1 AND status = 'ongoing'`
+
+const queryLockGamePlies = `
+SELECT ply_count FROM games WHERE id = $1 FOR UPDATE`
+
+const queryDeleteGamePlayer = `
+DELETE FROM game_players WHERE game_id = $1 AND client_id = $2`
+
+const queryHasUnmovedPlayers = `
+SELECT EXISTS (SELECT 1 FROM game_players WHERE game_id = $1 AND NOT has_moved)`
+
+const queryListContributors = `
+SELECT client_id, MIN(ply) AS first_ply
 FROM moves
 WHERE game_id = $1
+GROUP BY client_id
+ORDER BY first_ply`
+
+const querySelectPurgeable = `
+SELECT id FROM games
+WHERE status NOT IN ('waiting', 'ongoing') AND updated_at < $1
+FOR UPDATE`
+
+const queryPurgeMoves = `DELETE FROM moves WHERE game_id = ANY($1)`
+
+const queryPurgePlayers = `DELETE FROM game_players WHERE game_id = ANY($1)`
+
+const queryPurgeFailedMoves = `DELETE FROM failed_moves WHERE game_id = ANY($1)`
+
+const queryPurgeEvents = `DELETE FROM game_events WHERE game_id = ANY($1)`
+
+const queryPurgeGames = `DELETE FROM games WHERE id = ANY($1)`
+
+const queryMoveHistory = `
+SELECT id, ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after,
+       is_capture, is_en_passant, is_castle, is_blunder, created_at, user_agent
+FROM moves
+WHERE game_id = $1 AND ply >= $2
 ORDER BY ply ASC`
 
+const queryMovesPage = `
+SELECT id, ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after,
+       is_capture, is_en_passant, is_castle, is_blunder, created_at, user_agent
+FROM moves
+WHERE game_id = $1 AND ply >= $2
+ORDER BY ply ASC
+LIMIT $3`
+
+const queryClientClaims = `
+SELECT game_id, created_at, has_moved FROM game_players
+WHERE client_id = $1 AND (created_at, game_id) > ($2, $3)
+ORDER BY created_at, game_id
+LIMIT $4`
+
+const queryClientMoves = `
+SELECT game_id, id, ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after,
+       is_capture, is_en_passant, is_castle, is_blunder, created_at, user_agent
+FROM moves
+WHERE client_id = $1 AND game_id = ANY($2)`
+
+// queryLastMoveAt reads game_players rather than moves so it keeps working
+// when move history is not persisted.
+const queryLastMoveAt = `
+SELECT MAX(moved_at) FROM game_players WHERE client_id = $1`
+
 const queryGetGamePlayer = `
 SELECT has_moved FROM game_players
 WHERE game_id = $1 AND client_id = $2
 FOR UPDATE`
 
+// queryIsAssigned is the lock-free read of queryGetGamePlayer.
+const queryIsAssigned = `
+SELECT has_moved FROM game_players
+WHERE game_id = $1 AND client_id = $2`
+
 const queryInsertMove = `
-INSERT INTO moves (id, game_id, ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after, created_at)
-VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)`
+INSERT INTO moves (id, game_id, ply, uci, from_sq, to_sq, promotion, client_id, fen_before, fen_after,
+                   is_capture, is_en_passant, is_castle, is_blunder, created_at, user_agent)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)`
 
 const queryUpdateGame = `
 UPDATE games SET
@@ -100,21 +344,219 @@ UPDATE games SET
 WHERE id = $10 AND state_version = $11`
 
 const queryMarkMoved = `
-UPDATE game_players SET has_moved = true
+UPDATE game_players SET has_moved = true, moved_at = $3
 WHERE game_id = $1 AND client_id = $2`
 
+const queryInsertFailedMove = `
+INSERT INTO failed_moves (id, game_id, client_id, ply, uci, fen_before, fen_after, error, created_at)
+VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`
+
+const queryUpdateMetadata = `
+UPDATE games SET
+    title = CASE WHEN $2 THEN $3 ELSE title END,
+    tags  = CASE WHEN $4 THEN $5 ELSE tags END,
+    updated_at = $6
+WHERE id = $1
+RETURNING id, status, result, fen, side_to_move, ply_count,
+          last_move_uci, last_move_at, state_version, created_at, updated_at,
+          title, tags`
+
+const queryListBlockedClients = `SELECT client_id FROM blocked_clients`
+
+const queryBlockClient = `
+INSERT INTO blocked_clients (client_id, created_at)
+VALUES ($1, $2)
+ON CONFLICT (client_id) DO NOTHING`
+
+const queryUnblockClient = `DELETE FROM blocked_clients WHERE client_id = $1`
+
+// dbtx is the subset of pgx shared by *pgxpool.Pool and pgx.Tx. Begin on a
+// pgx.Tx opens a savepoint, so store methods that manage their own
+// transaction also work inside InTx.
+type dbtx interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
 // Store is a PostgreSQL-backed GameStore.
+//
+// All timestamps written by the store come from its clock rather than the
+// database NOW(), so created_at/updated_at are consistent with the times the
+// domain layer stamps on games and moves.
 type Store struct {
-	pool *pgxpool.Pool
+	db dbtx
+	// pool is the pool db was taken from; nil for a Store bound to a
+	// transaction.
+	pool       *pgxpool.Pool
+	now        func() time.Time
+	claimQuery string
+	// claimStrategy and preferInProgress are the inputs claimQuery was
+	// built from.
+	claimStrategy    ports.ClaimStrategy
+	preferInProgress bool
+	// skipHistory drops move records; see WithoutHistory.
+	skipHistory bool
+	// exclusiveWindow is how long a claim keeps the game to its claimant;
+	// see WithExclusiveWindow.
+	exclusiveWindow time.Duration
+	// acquireTimeout bounds the wait for a pool connection when opening a
+	// transaction; see WithAcquireTimeout.
+	acquireTimeout time.Duration
 }
 
 // New creates a Store backed by the given connection pool.
 func New(pool *pgxpool.Pool) *Store {
-	return &Store{pool: pool}
+	return NewWithClock(pool, time.Now)
+}
+
+// NewWithClock creates a Store that reads the current time from now.
+func NewWithClock(pool *pgxpool.Pool, now func() time.Time) *Store {
+	return &Store{db: pool, pool: pool, now: now, claimQuery: claimQueryFor(ports.ClaimOldest, false), claimStrategy: ports.ClaimOldest}
+}
+
+// Stats reports connection pool utilization. A Store bound to a transaction
+// reports zeros.
+func (s *Store) Stats() ports.PoolStats {
+	if s.pool == nil {
+		return ports.PoolStats{}
+	}
+	st := s.pool.Stat()
+	return ports.PoolStats{
+		TotalConns:      st.TotalConns(),
+		IdleConns:       st.IdleConns(),
+		InUseConns:      st.AcquiredConns(),
+		AcquireCount:    st.AcquireCount(),
+		AcquireDuration: st.AcquireDuration(),
+	}
+}
+
+// WithClaimStrategy returns a copy of s whose ClaimNextGame orders eligible
+// games by strategy. Unknown strategies fall back to ClaimOldest.
+func (s *Store) WithClaimStrategy(strategy ports.ClaimStrategy) *Store {
+	c := *s
+	c.claimStrategy = strategy
+	c.claimQuery = claimQueryFor(strategy, c.preferInProgress)
+	return &c
+}
+
+// WithPreferInProgress returns a copy of s whose ClaimNextGame hands out
+// games that already have moves before untouched ones, applying the claim
+// strategy within each group.
+func (s *Store) WithPreferInProgress() *Store {
+	c := *s
+	c.preferInProgress = true
+	c.claimQuery = claimQueryFor(c.claimStrategy, true)
+	return &c
+}
+
+// WithoutHistory returns a copy of the store that applies moves to the game
+// and game_players rows without inserting them into moves, trading move
+// history for write volume. state_version still advances with every move.
+func (s *Store) WithoutHistory() *Store {
+	c := *s
+	c.skipHistory = true
+	return &c
+}
+
+// WithExclusiveWindow returns a copy of s whose claims hold the game for the
+// claimant alone for d: until it moves or d elapses, ClaimNextGame hands the
+// game to nobody else. Zero disables the window.
+func (s *Store) WithExclusiveWindow(d time.Duration) *Store {
+	c := *s
+	c.exclusiveWindow = d
+	return &c
+}
+
+// WithAcquireTimeout returns a copy of s that gives up opening a transaction
+// when no pool connection frees up within d, returning ports.ErrStoreBusy
+// instead of queueing until the request's own deadline. Zero waits as long as
+// the caller's context allows.
+func (s *Store) WithAcquireTimeout(d time.Duration) *Store {
+	c := *s
+	c.acquireTimeout = d
+	return &c
+}
+
+// begin opens a transaction, applying acquireTimeout when s owns the pool.
+func (s *Store) begin(ctx context.Context) (pgx.Tx, error) {
+	if s.pool == nil || s.acquireTimeout <= 0 {
+		return s.db.Begin(ctx)
+	}
+	// The context only governs acquiring the connection and running BEGIN;
+	// the returned transaction does not keep it.
+	acquireCtx, cancel := context.WithTimeout(ctx, s.acquireTimeout)
+	defer cancel()
+	tx, err := s.db.Begin(acquireCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(acquireCtx.Err(), context.DeadlineExceeded) {
+		return nil, fmt.Errorf("%w: %v", ports.ErrStoreBusy, err)
+	}
+	return tx, err
+}
+
+func claimQueryFor(strategy ports.ClaimStrategy, preferInProgress bool) string {
+	if strategy == ports.ClaimRandom {
+		return randomQueryFor(queryClaimRandomGame, preferInProgress)
+	}
+	return fmt.Sprintf(queryClaimNextGame, claimOrderFor(strategy, preferInProgress))
+}
+
+func peekQueryFor(strategy ports.ClaimStrategy, preferInProgress bool) string {
+	if strategy == ports.ClaimRandom {
+		return randomQueryFor(queryPeekRandomGameID, preferInProgress)
+	}
+	return fmt.Sprintf(queryPeekNextGameID, claimOrderFor(strategy, preferInProgress))
+}
+
+// randomQueryFor fills in a ClaimRandom template: candidates are taken in
+// ClaimOldest order, then shuffled.
+func randomQueryFor(template string, preferInProgress bool) string {
+	return fmt.Sprintf(template,
+		claimOrderFor(ports.ClaimOldest, preferInProgress),
+		randomClaimCandidates,
+		claimOrderFor(ports.ClaimRandom, preferInProgress),
+	)
+}
+
+func claimOrderFor(strategy ports.ClaimStrategy, preferInProgress bool) string {
+	orderBy, ok := claimOrderBy[strategy]
+	if !ok {
+		orderBy = claimOrderBy[ports.ClaimOldest]
+	}
+	if preferInProgress {
+		orderBy = "(ply_count > 0) DESC, " + orderBy
+	}
+	return orderBy
+}
+
+// InTx runs fn against a Store bound to a single transaction, committing when
+// fn returns nil and rolling back otherwise.
+func (s *Store) InTx(ctx context.Context, fn func(txStore ports.GameStore) error) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	txStore := &Store{
+		db:               tx,
+		now:              s.now,
+		claimQuery:       s.claimQuery,
+		claimStrategy:    s.claimStrategy,
+		preferInProgress: s.preferInProgress,
+		skipHistory:      s.skipHistory,
+		exclusiveWindow:  s.exclusiveWindow,
+	}
+	if err := fn(txStore); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
 }
 
 func (s *Store) GetByID(ctx context.Context, id uuid.UUID) (*game.Game, error) {
-	row := s.pool.QueryRow(ctx, queryGetByID, id)
+	row := s.db.QueryRow(ctx, queryGetByID, id)
 	g, err := scanGame(row)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, ports.ErrNotFound
@@ -123,7 +565,7 @@ func (s *Store) GetByID(ctx context.Context, id uuid.UUID) (*game.Game, error) {
 }
 
 func (s *Store) ListOngoing(ctx context.Context) ([]*game.Game, error) {
-	rows, err := s.pool.Query(ctx, queryListOngoing)
+	rows, err := s.db.Query(ctx, queryListOngoing)
 	if err != nil {
 		return nil, err
 	}
@@ -140,16 +582,85 @@ func (s *Store) ListOngoing(ctx context.Context) ([]*game.Game, error) {
 	return out, rows.Err()
 }
 
+func (s *Store) ListGames(ctx context.Context, filter ports.GameFilter, afterID uuid.UUID, limit int) ([]*game.Game, error) {
+	rows, err := s.db.Query(ctx, queryListGames, string(filter.Status), string(filter.Result), afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*game.Game{}
+	for rows.Next() {
+		g, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListChangedSince(ctx context.Context, since time.Time, afterID uuid.UUID, limit int) ([]*game.Game, error) {
+	rows, err := s.db.Query(ctx, queryListChangedSince, since, afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*game.Game{}
+	for rows.Next() {
+		g, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) SampleForAudit(ctx context.Context, n int) ([]*game.Game, error) {
+	rows, err := s.db.Query(ctx, querySampleForAudit, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []*game.Game{}
+	for rows.Next() {
+		g, err := scanGame(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, g)
+	}
+	return out, rows.Err()
+}
+
+// RandomOngoing sorts the ongoing games randomly, which is fine while the
+// active pool stays small; finished games are excluded by the WHERE clause.
+func (s *Store) RandomOngoing(ctx context.Context) (*game.Game, error) {
+	g, err := scanGame(s.db.QueryRow(ctx, queryRandomOngoing))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ports.ErrNotFound
+	}
+	return g, err
+}
+
 // SaveIfVersion atomically updates the game only when the stored state_version
 // matches expectedVersion. Returns ErrVersionConflict when the version differs.
 func (s *Store) SaveIfVersion(ctx context.Context, g *game.Game, expectedVersion int) error {
+	if g.StateVersion != expectedVersion+1 {
+		log.Printf("game %s: refusing to save state_version %d over expected %d", g.ID, g.StateVersion, expectedVersion)
+		return ports.ErrVersionNotIncremented
+	}
+
 	var resultStr *string
 	if g.Result != nil {
 		r := string(*g.Result)
 		resultStr = &r
 	}
 
-	tag, err := s.pool.Exec(ctx, querySaveIfVersion,
+	tag, err := s.db.Exec(ctx, querySaveIfVersion,
 		string(g.Status),
 		resultStr,
 		g.FEN,
@@ -179,7 +690,7 @@ func (s *Store) Insert(ctx context.Context, g *game.Game) error {
 		resultStr = &r
 	}
 
-	_, err := s.pool.Exec(ctx, queryInsert,
+	_, err := s.db.Exec(ctx, queryInsert,
 		g.ID,
 		string(g.Status),
 		resultStr,
@@ -197,51 +708,71 @@ func (s *Store) Insert(ctx context.Context, g *game.Game) error {
 
 func (s *Store) HasActiveGames(ctx context.Context) (bool, error) {
 	var exists bool
-	if err := s.pool.QueryRow(ctx, queryHasActive).Scan(&exists); err != nil {
+	if err := s.db.QueryRow(ctx, queryHasActive).Scan(&exists); err != nil {
 		return false, err
 	}
 	return exists, nil
 }
 
-func (s *Store) CreateWaitingBatch(ctx context.Context, count int) error {
-	now := time.Now()
+func (s *Store) CountWaiting(ctx context.Context) (int, error) {
+	var n int
+	if err := s.db.QueryRow(ctx, queryCountWaiting).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *Store) TotalMoves(ctx context.Context) (int64, error) {
+	var n int64
+	if err := s.db.QueryRow(ctx, queryTotalMoves).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *Store) CreateWaitingBatch(ctx context.Context, count int) (int, error) {
+	now := s.now()
 	batch := &pgx.Batch{}
 	for i := 0; i < count; i++ {
 		id := uuid.New()
 		batch.Queue(queryInsert,
 			id,
 			string(game.StatusWaiting),
-			nil,        // result
+			nil, // result
 			initialFEN,
 			"white",
-			0,          // ply_count
-			nil,        // last_move_uci
-			nil,        // last_move_at
-			0,          // state_version
+			0,   // ply_count
+			nil, // last_move_uci
+			nil, // last_move_at
+			0,   // state_version
 			now,
 			now,
 		)
 	}
-	br := s.pool.SendBatch(ctx, batch)
+	br := s.db.SendBatch(ctx, batch)
 	defer br.Close()
+	created := 0
 	for i := 0; i < count; i++ {
-		if _, err := br.Exec(); err != nil {
-			return err
+		tag, err := br.Exec()
+		if err != nil {
+			return created, err
 		}
+		created += int(tag.RowsAffected())
 	}
-	return nil
+	return created, nil
 }
 
 // ClaimNextGame finds a suitable game, atomically claims it for the client, and
 // transitions it from waiting to ongoing if needed.
 func (s *Store) ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return nil, nil, err
 	}
 	defer tx.Rollback(ctx) //nolint:errcheck
 
-	row := tx.QueryRow(ctx, queryClaimNextGame, clientID)
+	now := s.now()
+	row := tx.QueryRow(ctx, s.claimQuery, clientID, now)
 	g, err := scanGame(row)
 	if errors.Is(err, pgx.ErrNoRows) {
 		return nil, nil, ports.ErrNoGamesAvailable
@@ -250,8 +781,14 @@ func (s *Store) ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Ga
 		return nil, nil, err
 	}
 
+	var exclusiveUntil *time.Time
+	if s.exclusiveWindow > 0 {
+		until := now.Add(s.exclusiveWindow)
+		exclusiveUntil = &until
+	}
+
 	// Insert game_players row.
-	tag, err := tx.Exec(ctx, queryInsertGamePlayer, g.ID, clientID)
+	tag, err := tx.Exec(ctx, queryInsertGamePlayer, g.ID, clientID, now, exclusiveUntil)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -261,14 +798,22 @@ func (s *Store) ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Ga
 	}
 
 	// Transition waiting -> ongoing (no-op if already ongoing).
-	if _, err := tx.Exec(ctx, queryActivateGame, g.ID); err != nil {
+	if _, err := tx.Exec(ctx, queryActivateGame, g.ID, now); err != nil {
+		return nil, nil, err
+	}
+	if err := insertEvent(ctx, tx, g.ID, ports.GameEvent{Kind: ports.EventClaimed, Actor: &clientID, CreatedAt: now}); err != nil {
 		return nil, nil, err
 	}
 	if g.Status == game.StatusWaiting {
 		g.Status = game.StatusOngoing
+		g.UpdatedAt = now
+		ev := ports.GameEvent{Kind: ports.EventStatusChange, Actor: &clientID, Detail: string(g.Status), CreatedAt: now}
+		if err := insertEvent(ctx, tx, g.ID, ev); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	history, err := fetchMoveHistory(ctx, tx, g.ID)
+	history, err := fetchMoveHistory(ctx, tx, g.ID, 0)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -279,18 +824,225 @@ func (s *Store) ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Ga
 	return g, history, nil
 }
 
+func (s *Store) LockClient(ctx context.Context, clientID uuid.UUID) error {
+	_, err := s.db.Exec(ctx, queryLockClient, clientID)
+	return err
+}
+
+// PeekNextGameID returns the ID of the game ClaimNextGame would hand clientID
+// right now, without claiming or locking it. Under ClaimRandom the claim may
+// still pick another game.
+func (s *Store) PeekNextGameID(ctx context.Context, clientID uuid.UUID) (uuid.UUID, error) {
+	query := peekQueryFor(s.claimStrategy, s.preferInProgress)
+	var id uuid.UUID
+	err := s.db.QueryRow(ctx, query, clientID, s.now()).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, ports.ErrNoGamesAvailable
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return id, nil
+}
+
+func (s *Store) CountAvailableForClient(ctx context.Context, clientID uuid.UUID) (int, error) {
+	var n int
+	if err := s.db.QueryRow(ctx, queryCountAvailableForClient, clientID, s.now()).Scan(&n); err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+// FindActiveClaim returns the most recent unmoved claim of clientID on a game
+// that is still waiting or ongoing.
+func (s *Store) FindActiveClaim(ctx context.Context, clientID uuid.UUID) (uuid.UUID, bool, error) {
+	var gameID uuid.UUID
+	err := s.db.QueryRow(ctx, queryFindActiveClaim, clientID).Scan(&gameID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return uuid.Nil, false, nil
+	}
+	if err != nil {
+		return uuid.Nil, false, err
+	}
+	return gameID, true, nil
+}
+
 func (s *Store) GetGameWithHistory(ctx context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
 	g, err := s.GetByID(ctx, id)
 	if err != nil {
 		return nil, nil, err
 	}
-	hist, err := fetchMoveHistory(ctx, s.pool, id)
+	hist, err := fetchMoveHistory(ctx, s.db, id, 0)
 	if err != nil {
 		return nil, nil, err
 	}
 	return g, hist, nil
 }
 
+func (s *Store) GetMovesSince(ctx context.Context, gameID uuid.UUID, fromPly int) ([]game.MoveHistoryItem, error) {
+	return fetchMoveHistory(ctx, s.db, gameID, fromPly)
+}
+
+func (s *Store) GetMovesPage(ctx context.Context, gameID uuid.UUID, fromPly, limit int) ([]game.MoveHistoryItem, error) {
+	rows, err := s.db.Query(ctx, queryMovesPage, gameID, fromPly, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []game.MoveHistoryItem{}
+	for rows.Next() {
+		var item game.MoveHistoryItem
+		if err := rows.Scan(moveHistoryDest(&item)...); err != nil {
+			return nil, err
+		}
+		out = append(out, item)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListContributors(ctx context.Context, gameID uuid.UUID) ([]ports.Contributor, error) {
+	rows, err := s.db.Query(ctx, queryListContributors, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ports.Contributor{}
+	for rows.Next() {
+		var c ports.Contributor
+		if err := rows.Scan(&c.ClientID, &c.FirstPly); err != nil {
+			return nil, err
+		}
+		out = append(out, c)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) ListClientClaims(ctx context.Context, clientID uuid.UUID, since time.Time, afterGameID uuid.UUID, limit int) ([]ports.ClientClaim, error) {
+	rows, err := s.db.Query(ctx, queryClientClaims, clientID, since, afterGameID, limit)
+	if err != nil {
+		return nil, err
+	}
+	out := []ports.ClientClaim{}
+	var moved []uuid.UUID
+	for rows.Next() {
+		var claim ports.ClientClaim
+		if err := rows.Scan(&claim.GameID, &claim.ClaimedAt, &claim.HasMoved); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if claim.HasMoved {
+			moved = append(moved, claim.GameID)
+		}
+		out = append(out, claim)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(moved) == 0 {
+		return out, nil
+	}
+
+	rows, err = s.db.Query(ctx, queryClientMoves, clientID, moved)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	moves := make(map[uuid.UUID]*game.MoveHistoryItem, len(moved))
+	for rows.Next() {
+		var gameID uuid.UUID
+		item := new(game.MoveHistoryItem)
+		if err := rows.Scan(append([]any{&gameID}, moveHistoryDest(item)...)...); err != nil {
+			return nil, err
+		}
+		moves[gameID] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range out {
+		out[i].Move = moves[out[i].GameID]
+	}
+	return out, nil
+}
+
+func (s *Store) ListGameEvents(ctx context.Context, gameID uuid.UUID) ([]ports.GameEvent, error) {
+	rows, err := s.db.Query(ctx, queryListGameEvents, gameID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []ports.GameEvent{}
+	for rows.Next() {
+		var ev ports.GameEvent
+		var kind string
+		if err := rows.Scan(&kind, &ev.Actor, &ev.Detail, &ev.CreatedAt); err != nil {
+			return nil, err
+		}
+		ev.Kind = ports.GameEventKind(kind)
+		out = append(out, ev)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) IsAssigned(ctx context.Context, gameID, clientID uuid.UUID) (bool, bool, error) {
+	var hasMoved bool
+	err := s.db.QueryRow(ctx, queryIsAssigned, gameID, clientID).Scan(&hasMoved)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return false, false, nil
+	}
+	if err != nil {
+		return false, false, err
+	}
+	return true, hasMoved, nil
+}
+
+func (s *Store) PurgeFinished(ctx context.Context, olderThan time.Time) (int, error) {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	rows, err := tx.Query(ctx, querySelectPurgeable, olderThan)
+	if err != nil {
+		return 0, err
+	}
+	ids, err := pgx.CollectRows(rows, pgx.RowTo[uuid.UUID])
+	if err != nil {
+		return 0, err
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	// Children first: the foreign keys have no ON DELETE CASCADE.
+	for _, q := range []string{queryPurgeMoves, queryPurgePlayers, queryPurgeFailedMoves, queryPurgeEvents} {
+		if _, err := tx.Exec(ctx, q, ids); err != nil {
+			return 0, err
+		}
+	}
+	tag, err := tx.Exec(ctx, queryPurgeGames, ids)
+	if err != nil {
+		return 0, err
+	}
+	if err := tx.Commit(ctx); err != nil {
+		return 0, err
+	}
+	return int(tag.RowsAffected()), nil
+}
+
+func (s *Store) LastMoveAt(ctx context.Context, clientID uuid.UUID) (*time.Time, error) {
+	var at *time.Time
+	if err := s.db.QueryRow(ctx, queryLastMoveAt, clientID).Scan(&at); err != nil {
+		return nil, err
+	}
+	return at, nil
+}
+
 // PersistMove atomically checks the client assignment, inserts the move, updates
 // the game state (with version CAS), marks the player as moved, and returns the
 // full ordered move history.
@@ -301,7 +1053,7 @@ func (s *Store) PersistMove(
 	rec game.MoveRecord,
 	ply int,
 ) ([]game.MoveHistoryItem, error) {
-	tx, err := s.pool.Begin(ctx)
+	tx, err := s.begin(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -319,8 +1071,65 @@ func (s *Store) PersistMove(
 	if hasMoved {
 		return nil, ports.ErrAlreadyMoved
 	}
+	var held bool
+	if err := tx.QueryRow(ctx, queryHeldByOther, gameID, clientID, s.now()).Scan(&held); err != nil {
+		return nil, err
+	}
+	if held {
+		return nil, ports.ErrClaimHeld
+	}
 
-	// Insert move record.
+	if err := s.writeMove(ctx, tx, gameID, clientID, newGame, rec, ply); err != nil {
+		return nil, err
+	}
+
+	// Mark player as moved.
+	if _, err := tx.Exec(ctx, queryMarkMoved, gameID, clientID, rec.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	// Return full history.
+	history, err := fetchMoveHistory(ctx, tx, gameID, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+func (s *Store) PersistHouseMove(
+	ctx context.Context,
+	gameID, clientID uuid.UUID,
+	newGame *game.Game,
+	rec game.MoveRecord,
+	ply int,
+) error {
+	tx, err := s.begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx) //nolint:errcheck
+
+	if err := s.writeMove(ctx, tx, gameID, clientID, newGame, rec, ply); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// writeMove inserts rec, unless history is skipped, and updates the game to
+// newGame, failing with ErrVersionConflict if the game moved on since newGame
+// was derived.
+func (s *Store) writeMove(
+	ctx context.Context,
+	tx pgx.Tx,
+	gameID, clientID uuid.UUID,
+	newGame *game.Game,
+	rec game.MoveRecord,
+	ply int,
+) error {
 	fromSq := rec.UCI[:2]
 	toSq := rec.UCI[2:4]
 	var promotion *string
@@ -328,14 +1137,17 @@ func (s *Store) PersistMove(
 		p := rec.UCI[4:]
 		promotion = &p
 	}
-	if _, err := tx.Exec(ctx, queryInsertMove,
-		rec.ID, gameID, ply, rec.UCI, fromSq, toSq, promotion,
-		clientID, rec.FENBefore, rec.FENAfter, rec.CreatedAt,
-	); err != nil {
-		return nil, err
+	if !s.skipHistory {
+		if _, err := tx.Exec(ctx, queryInsertMove,
+			rec.ID, gameID, ply, rec.UCI, fromSq, toSq, promotion,
+			clientID, rec.FENBefore, rec.FENAfter,
+			rec.IsCapture, rec.IsEnPassant, rec.IsCastle, rec.IsBlunder, rec.CreatedAt, rec.UserAgent,
+		); err != nil {
+			return err
+		}
 	}
 
-	// Update game (CAS on state_version).
+	// CAS on state_version.
 	var resultStr *string
 	if newGame.Result != nil {
 		r := string(*newGame.Result)
@@ -349,34 +1161,145 @@ func (s *Store) PersistMove(
 		gameID, expectedVersion,
 	)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if tag.RowsAffected() == 0 {
-		return nil, ports.ErrVersionConflict
+		return ports.ErrVersionConflict
 	}
 
-	// Mark player as moved.
-	if _, err := tx.Exec(ctx, queryMarkMoved, gameID, clientID); err != nil {
-		return nil, err
+	ev := ports.GameEvent{Kind: ports.EventMove, Actor: &clientID, Detail: rec.UCI, CreatedAt: rec.CreatedAt}
+	if err := insertEvent(ctx, tx, gameID, ev); err != nil {
+		return err
 	}
+	if newGame.Status != game.StatusOngoing {
+		ev = ports.GameEvent{Kind: ports.EventStatusChange, Actor: &clientID, Detail: string(newGame.Status), CreatedAt: rec.CreatedAt}
+		return insertEvent(ctx, tx, gameID, ev)
+	}
+	return nil
+}
 
-	// Return full history.
-	history, err := fetchMoveHistory(ctx, tx, gameID)
+// insertEvent appends ev to the game's event log within tx.
+func insertEvent(ctx context.Context, tx pgx.Tx, gameID uuid.UUID, ev ports.GameEvent) error {
+	_, err := tx.Exec(ctx, queryInsertEvent, gameID, string(ev.Kind), ev.Actor, ev.Detail, ev.CreatedAt)
+	return err
+}
+
+// RevertUnmovedClaim locks the game row first, so a concurrent move cannot
+// land between the checks and the revert.
+func (s *Store) RevertUnmovedClaim(ctx context.Context, gameID, clientID uuid.UUID) error {
+	tx, err := s.begin(ctx)
 	if err != nil {
-		return nil, err
+		return err
 	}
+	defer tx.Rollback(ctx) //nolint:errcheck
 
-	if err := tx.Commit(ctx); err != nil {
+	var plies int
+	err = tx.QueryRow(ctx, queryLockGamePlies, gameID).Scan(&plies)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	var hasMoved bool
+	err = tx.QueryRow(ctx, queryGetGamePlayer, gameID, clientID).Scan(&hasMoved)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return ports.ErrNotAssigned
+	}
+	if err != nil {
+		return err
+	}
+	if hasMoved {
+		return ports.ErrAlreadyMoved
+	}
+	if plies > 0 {
+		return ports.ErrGameHasMoves
+	}
+
+	now := s.now()
+	if _, err := tx.Exec(ctx, queryDeleteGamePlayer, gameID, clientID); err != nil {
+		return err
+	}
+	if err := insertEvent(ctx, tx, gameID, ports.GameEvent{Kind: ports.EventClaimReverted, Actor: &clientID, CreatedAt: now}); err != nil {
+		return err
+	}
+
+	var others bool
+	if err := tx.QueryRow(ctx, queryHasUnmovedPlayers, gameID).Scan(&others); err != nil {
+		return err
+	}
+	if !others {
+		tag, err := tx.Exec(ctx, queryDeactivateGame, gameID, now)
+		if err != nil {
+			return err
+		}
+		if tag.RowsAffected() > 0 {
+			ev := ports.GameEvent{Kind: ports.EventStatusChange, Detail: string(game.StatusWaiting), CreatedAt: now}
+			if err := insertEvent(ctx, tx, gameID, ev); err != nil {
+				return err
+			}
+		}
+	}
+	return tx.Commit(ctx)
+}
+
+func (s *Store) RecordFailedMove(ctx context.Context, fm ports.FailedMove) error {
+	_, err := s.db.Exec(ctx, queryInsertFailedMove,
+		fm.ID, fm.GameID, fm.ClientID, fm.Ply, fm.UCI,
+		fm.FENBefore, fm.FENAfter, fm.Error, fm.CreatedAt,
+	)
+	return err
+}
+
+// UpdateMetadata applies patch to the game's metadata columns only; the chess
+// state and state_version are untouched so it never conflicts with moves.
+// updated_at advances so the changed-games feed picks up the edit.
+func (s *Store) UpdateMetadata(ctx context.Context, id uuid.UUID, patch ports.MetadataPatch) (*game.Game, error) {
+	tags := patch.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	row := s.db.QueryRow(ctx, queryUpdateMetadata, id, patch.TitleSet, patch.Title, patch.TagsSet, tags, s.now())
+	g, err := scanGame(row)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, ports.ErrNotFound
+	}
+	return g, err
+}
+
+func (s *Store) ListBlockedClients(ctx context.Context) ([]uuid.UUID, error) {
+	rows, err := s.db.Query(ctx, queryListBlockedClients)
+	if err != nil {
 		return nil, err
 	}
-	return history, nil
+	defer rows.Close()
+
+	out := []uuid.UUID{}
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		out = append(out, id)
+	}
+	return out, rows.Err()
+}
+
+func (s *Store) BlockClient(ctx context.Context, clientID uuid.UUID) error {
+	_, err := s.db.Exec(ctx, queryBlockClient, clientID, s.now())
+	return err
+}
+
+func (s *Store) UnblockClient(ctx context.Context, clientID uuid.UUID) error {
+	_, err := s.db.Exec(ctx, queryUnblockClient, clientID)
+	return err
 }
 
 // fetchMoveHistory queries moves for gameID using any pgx querier (pool or tx).
 func fetchMoveHistory(ctx context.Context, q interface {
 	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
-}, gameID uuid.UUID) ([]game.MoveHistoryItem, error) {
-	rows, err := q.Query(ctx, queryMoveHistory, gameID)
+}, gameID uuid.UUID, fromPly int) ([]game.MoveHistoryItem, error) {
+	rows, err := q.Query(ctx, queryMoveHistory, gameID, fromPly)
 	if err != nil {
 		return nil, err
 	}
@@ -385,19 +1308,24 @@ func fetchMoveHistory(ctx context.Context, q interface {
 	out := []game.MoveHistoryItem{}
 	for rows.Next() {
 		var item game.MoveHistoryItem
-		var clientID uuid.UUID
-		if err := rows.Scan(
-			&item.Ply, &item.UCI, &item.FromSq, &item.ToSq, &item.Promotion,
-			&clientID, &item.FENBefore, &item.FENAfter, &item.CreatedAt,
-		); err != nil {
+		if err := rows.Scan(moveHistoryDest(&item)...); err != nil {
 			return nil, err
 		}
-		item.ClientID = clientID
 		out = append(out, item)
 	}
 	return out, rows.Err()
 }
 
+// moveHistoryDest returns scan destinations for the moves columns selected by
+// queryMoveHistory, in order.
+func moveHistoryDest(item *game.MoveHistoryItem) []any {
+	return []any{
+		&item.ID, &item.Ply, &item.UCI, &item.FromSq, &item.ToSq, &item.Promotion,
+		&item.ClientID, &item.FENBefore, &item.FENAfter,
+		&item.IsCapture, &item.IsEnPassant, &item.IsCastle, &item.IsBlunder, &item.CreatedAt, &item.UserAgent,
+	}
+}
+
 // scanGame reads a game row from either a pgx.Row or pgx.Rows.
 func scanGame(s interface {
 	Scan(dest ...any) error
@@ -414,11 +1342,14 @@ func scanGame(s interface {
 		stateVersion int
 		createdAt    time.Time
 		updatedAt    time.Time
+		title        *string
+		tags         []string
 	)
 
 	err := s.Scan(
 		&id, &statusStr, &resultStr, &fen, &sideToMove, &plyCount,
 		&lastMoveUCI, &lastMoveAt, &stateVersion, &createdAt, &updatedAt,
+		&title, &tags,
 	)
 	if err != nil {
 		return nil, err
@@ -435,6 +1366,8 @@ func scanGame(s interface {
 		StateVersion: stateVersion,
 		CreatedAt:    createdAt,
 		UpdatedAt:    updatedAt,
+		Title:        title,
+		Tags:         tags,
 	}
 	if resultStr != nil {
 		r := game.Result(*resultStr)
@@ -3,14 +3,17 @@
 package postgres_test
 
 import (
+	"cmp"
 	"context"
 	"database/sql"
+	"errors"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/jackc/pgx/v5/pgxpool"
+	_ "github.com/jackc/pgx/v5/stdlib"
 	"github.com/pressly/goose/v3"
 	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
 
@@ -21,6 +24,12 @@ import (
 )
 
 func setupStore(t *testing.T) *pgstore.Store {
+	t.Helper()
+	return pgstore.New(setupPool(t))
+}
+
+// setupPool starts a migrated postgres container and returns a pool for it.
+func setupPool(t *testing.T) *pgxpool.Pool {
 	t.Helper()
 	ctx := context.Background()
 
@@ -62,7 +71,7 @@ func setupStore(t *testing.T) *pgstore.Store {
 	}
 	t.Cleanup(pool.Close)
 
-	return pgstore.New(pool)
+	return pool
 }
 
 func newTestGame(t *testing.T) *game.Game {
@@ -156,6 +165,59 @@ func TestSaveIfVersion_OK(t *testing.T) {
 	}
 }
 
+func TestRandomOngoing(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.RandomOngoing(ctx); err != ports.ErrNotFound {
+		t.Fatalf("empty: want ErrNotFound, got %v", err)
+	}
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if _, err := s.RandomOngoing(ctx); err != ports.ErrNotFound {
+		t.Fatalf("only waiting games: want ErrNotFound, got %v", err)
+	}
+	claimed, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	got, err := s.RandomOngoing(ctx)
+	if err != nil {
+		t.Fatalf("RandomOngoing: %v", err)
+	}
+	if got.ID != claimed.ID {
+		t.Fatalf("want the only ongoing game %s, got %s", claimed.ID, got.ID)
+	}
+}
+
+func TestSaveIfVersion_RejectsVersionNotIncremented(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	g := newTestGame(t)
+	if err := s.Insert(ctx, g); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+	newG, _, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply move: %v", err)
+	}
+
+	// A stale object carrying the stored version must not be written back.
+	newG.StateVersion = g.StateVersion
+	if err := s.SaveIfVersion(ctx, newG, g.StateVersion); err != ports.ErrVersionNotIncremented {
+		t.Fatalf("want ErrVersionNotIncremented, got %v", err)
+	}
+	got, err := s.GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.FEN != g.FEN || got.StateVersion != g.StateVersion {
+		t.Fatal("rejected save must not change the game")
+	}
+}
+
 func TestSaveIfVersion_Conflict(t *testing.T) {
 	s := setupStore(t)
 	ctx := context.Background()
@@ -171,6 +233,7 @@ func TestSaveIfVersion_Conflict(t *testing.T) {
 	}
 
 	// Wrong expected version → conflict.
+	newG.StateVersion = 100
 	if err := s.SaveIfVersion(ctx, newG, 99); err != ports.ErrVersionConflict {
 		t.Fatalf("want ErrVersionConflict, got %v", err)
 	}
@@ -207,9 +270,13 @@ func TestCreateWaitingBatch(t *testing.T) {
 	s := setupStore(t)
 	ctx := context.Background()
 
-	if err := s.CreateWaitingBatch(ctx, 5); err != nil {
+	created, err := s.CreateWaitingBatch(ctx, 5)
+	if err != nil {
 		t.Fatalf("CreateWaitingBatch: %v", err)
 	}
+	if created != 5 {
+		t.Fatalf("created %d, want 5", created)
+	}
 
 	has, err := s.HasActiveGames(ctx)
 	if err != nil {
@@ -225,7 +292,7 @@ func TestClaimNextGame_NeverRepeatsSameClient(t *testing.T) {
 	ctx := context.Background()
 
 	// Create 2 waiting games.
-	if err := s.CreateWaitingBatch(ctx, 2); err != nil {
+	if _, err := s.CreateWaitingBatch(ctx, 2); err != nil {
 		t.Fatalf("batch: %v", err)
 	}
 
@@ -259,7 +326,7 @@ func TestPersistMove_Full(t *testing.T) {
 	s := setupStore(t)
 	ctx := context.Background()
 
-	if err := s.CreateWaitingBatch(ctx, 1); err != nil {
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
 		t.Fatalf("batch: %v", err)
 	}
 	clientID := uuid.New()
@@ -284,6 +351,9 @@ func TestPersistMove_Full(t *testing.T) {
 	if hist[0].UCI != "e2e4" {
 		t.Errorf("history uci: want e2e4, got %q", hist[0].UCI)
 	}
+	if hist[0].ID != rec.ID {
+		t.Errorf("history id: want %s, got %s", rec.ID, hist[0].ID)
+	}
 
 	// Second move attempt by same client → ErrAlreadyMoved.
 	newGame2, rec2, err := newGame.ApplyMove("e7e5", time.Now().UTC())
@@ -296,6 +366,64 @@ func TestPersistMove_Full(t *testing.T) {
 	}
 }
 
+func TestPersistMove_WithoutHistory(t *testing.T) {
+	s := setupStore(t).WithoutHistory()
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	hist, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, newGame.PlyCount-1)
+	if err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if len(hist) != 0 {
+		t.Fatalf("want no history, got %d items", len(hist))
+	}
+	got, err := s.GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got.StateVersion != g.StateVersion+1 || got.PlyCount != 1 {
+		t.Fatalf("want version %d and 1 ply, got version %d and %d plies", g.StateVersion+1, got.StateVersion, got.PlyCount)
+	}
+}
+
+func TestPersistMove_IsBlunder(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	rec.IsBlunder = true
+	hist, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, newGame.PlyCount-1)
+	if err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if len(hist) != 1 || !hist[0].IsBlunder {
+		t.Fatalf("want one history item flagged as a blunder, got %+v", hist)
+	}
+}
+
 func TestPersistMove_NotAssigned(t *testing.T) {
 	s := setupStore(t)
 	ctx := context.Background()
@@ -321,7 +449,7 @@ func TestGetGameWithHistory(t *testing.T) {
 	s := setupStore(t)
 	ctx := context.Background()
 
-	if err := s.CreateWaitingBatch(ctx, 1); err != nil {
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
 		t.Fatalf("batch: %v", err)
 	}
 	clientID := uuid.New()
@@ -352,3 +480,1136 @@ func TestGetGameWithHistory(t *testing.T) {
 		t.Errorf("ply: want 0, got %d", hist[0].Ply)
 	}
 }
+
+// TestLockClient_SerializesClaims: with the client locked, the active-claim
+// check and the claim are atomic, so concurrent claims by one client in
+// single-active-claim style yield exactly one game.
+func TestLockClient_SerializesClaims(t *testing.T) {
+	const claims = 10
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, claims); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	errActive := errors.New("active claim")
+
+	errs := make([]error, claims)
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = s.InTx(ctx, func(tx ports.GameStore) error {
+				if err := tx.LockClient(ctx, clientID); err != nil {
+					return err
+				}
+				if _, found, err := tx.FindActiveClaim(ctx, clientID); err != nil || found {
+					return cmp.Or(err, errActive)
+				}
+				_, _, err := tx.ClaimNextGame(ctx, clientID)
+				return err
+			})
+		}()
+	}
+	wg.Wait()
+
+	claimed := 0
+	for _, err := range errs {
+		switch {
+		case err == nil:
+			claimed++
+		case !errors.Is(err, errActive):
+			t.Fatalf("claim: %v", err)
+		}
+	}
+	if claimed != 1 {
+		t.Fatalf("got %d claims, want exactly 1", claimed)
+	}
+}
+
+func TestFindActiveClaim(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+
+	if _, found, err := s.FindActiveClaim(ctx, clientID); err != nil || found {
+		t.Fatalf("before claim: want not found, got found=%v err=%v", found, err)
+	}
+
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	gameID, found, err := s.FindActiveClaim(ctx, clientID)
+	if err != nil {
+		t.Fatalf("FindActiveClaim: %v", err)
+	}
+	if !found || gameID != g.ID {
+		t.Fatalf("want active claim on %s, got found=%v id=%s", g.ID, found, gameID)
+	}
+
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, 0); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if _, found, err := s.FindActiveClaim(ctx, clientID); err != nil || found {
+		t.Fatalf("after move: want not found, got found=%v err=%v", found, err)
+	}
+}
+
+// TestClaimNextGame_UsesStoreClock: activation stamps updated_at from the
+// store clock, not the database NOW().
+func TestClaimNextGame_UsesStoreClock(t *testing.T) {
+	ctx := context.Background()
+	fixed := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := pgstore.NewWithClock(setupPool(t), func() time.Time { return fixed })
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	g, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if !g.UpdatedAt.Equal(fixed) {
+		t.Errorf("returned updated_at: want %v, got %v", fixed, g.UpdatedAt)
+	}
+
+	got, err := s.GetByID(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if !got.UpdatedAt.Equal(fixed) {
+		t.Errorf("stored updated_at: want %v, got %v", fixed, got.UpdatedAt)
+	}
+	if !got.CreatedAt.Equal(fixed) {
+		t.Errorf("stored created_at: want %v, got %v", fixed, got.CreatedAt)
+	}
+}
+
+// TestClaimNextGame_MostActive: the most recently touched game is handed out
+// before untouched waiting games.
+func TestClaimNextGame_MostActive(t *testing.T) {
+	ctx := context.Background()
+	tick := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	s := pgstore.NewWithClock(setupPool(t), clock).WithClaimStrategy(ports.ClaimMostActive)
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	first, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim A: %v", err)
+	}
+	second, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim B: %v", err)
+	}
+	if second.ID != first.ID {
+		t.Fatalf("most_active: want active game %s, got %s", first.ID, second.ID)
+	}
+}
+
+// TestClaimNextGame_Random: the bounded random pick still reaches every
+// eligible game and nothing else.
+func TestClaimNextGame_Random(t *testing.T) {
+	ctx := context.Background()
+	s := setupStore(t).WithClaimStrategy(ports.ClaimRandom)
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	client := uuid.New()
+	seen := make(map[uuid.UUID]bool)
+	for i := range 3 {
+		if _, err := s.PeekNextGameID(ctx, client); err != nil {
+			t.Fatalf("peek %d: %v", i, err)
+		}
+		g, _, err := s.ClaimNextGame(ctx, client)
+		if err != nil {
+			t.Fatalf("claim %d: %v", i, err)
+		}
+		if seen[g.ID] {
+			t.Fatalf("claim %d: game %s handed out twice", i, g.ID)
+		}
+		seen[g.ID] = true
+	}
+	if _, _, err := s.ClaimNextGame(ctx, client); !errors.Is(err, ports.ErrNoGamesAvailable) {
+		t.Fatalf("all claimed: want ErrNoGamesAvailable, got %v", err)
+	}
+}
+
+// TestClaimNextGame_PreferInProgress: a newer game with a move is claimed
+// ahead of an older untouched one.
+func TestRevertUnmovedClaim_Unmoved(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	first, second := uuid.New(), uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, first)
+	if err != nil {
+		t.Fatalf("claim 1: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, second); err != nil {
+		t.Fatalf("claim 2: %v", err)
+	}
+
+	// Another unmoved claimant remains, so the game stays ongoing.
+	if err := s.RevertUnmovedClaim(ctx, g.ID, first); err != nil {
+		t.Fatalf("revert first: %v", err)
+	}
+	if assigned, _, _ := s.IsAssigned(ctx, g.ID, first); assigned {
+		t.Fatal("first claim still present")
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusOngoing {
+		t.Fatalf("want ongoing with a claimant left, got %s", got.Status)
+	}
+
+	if err := s.RevertUnmovedClaim(ctx, g.ID, second); err != nil {
+		t.Fatalf("revert second: %v", err)
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusWaiting {
+		t.Fatalf("want waiting, got %s", got.Status)
+	}
+	if err := s.RevertUnmovedClaim(ctx, g.ID, second); err != ports.ErrNotAssigned {
+		t.Fatalf("repeat revert: want ErrNotAssigned, got %v", err)
+	}
+	events, err := s.ListGameEvents(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	if last := events[len(events)-1]; last.Kind != ports.EventStatusChange || last.Detail != string(game.StatusWaiting) {
+		t.Fatalf("want a status_change to waiting last, got %+v", last)
+	}
+}
+
+func TestRevertUnmovedClaim_Moved(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	mover, waiter := uuid.New(), uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, mover)
+	if err != nil {
+		t.Fatalf("claim 1: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, waiter); err != nil {
+		t.Fatalf("claim 2: %v", err)
+	}
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, mover, newGame, rec, 0); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	if err := s.RevertUnmovedClaim(ctx, g.ID, mover); err != ports.ErrAlreadyMoved {
+		t.Fatalf("mover: want ErrAlreadyMoved, got %v", err)
+	}
+	if err := s.RevertUnmovedClaim(ctx, g.ID, waiter); err != ports.ErrGameHasMoves {
+		t.Fatalf("waiter: want ErrGameHasMoves, got %v", err)
+	}
+	if assigned, _, _ := s.IsAssigned(ctx, g.ID, waiter); !assigned {
+		t.Fatal("a refused revert must keep the claim")
+	}
+	if got, _ := s.GetByID(ctx, g.ID); got.Status != game.StatusOngoing {
+		t.Fatalf("want ongoing, got %s", got.Status)
+	}
+}
+
+func TestClaimNextGame_ExclusiveWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := pgstore.NewWithClock(setupPool(t), func() time.Time { return now }).WithExclusiveWindow(30 * time.Second)
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	holder := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, holder)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	now = now.Add(10 * time.Second)
+	if _, _, err := s.ClaimNextGame(ctx, uuid.New()); err != ports.ErrNoGamesAvailable {
+		t.Fatalf("within the window: want ErrNoGamesAvailable, got %v", err)
+	}
+
+	now = now.Add(30 * time.Second)
+	got, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("after the window: %v", err)
+	}
+	if got.ID != g.ID {
+		t.Fatalf("after the window: want %s, got %s", g.ID, got.ID)
+	}
+}
+
+func TestClaimNextGame_ExclusiveWindowEndsOnMove(t *testing.T) {
+	ctx := context.Background()
+	s := setupStore(t).WithExclusiveWindow(time.Hour)
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	holder := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, holder)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	newGame, rec, err := g.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, holder, newGame, rec, 0); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	got, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim after move: %v", err)
+	}
+	if got.ID != g.ID {
+		t.Fatalf("want %s once the holder moved, got %s", g.ID, got.ID)
+	}
+}
+
+func TestPersistMove_ExclusiveWindow(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	s := pgstore.NewWithClock(setupPool(t), func() time.Time { return now }).WithExclusiveWindow(30 * time.Second)
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	early := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, early)
+	if err != nil {
+		t.Fatalf("early claim: %v", err)
+	}
+	// The early claim's window runs out, so the holder can claim the game
+	// and open a window of its own.
+	now = now.Add(time.Minute)
+	holder := uuid.New()
+	if _, _, err := s.ClaimNextGame(ctx, holder); err != nil {
+		t.Fatalf("holder claim: %v", err)
+	}
+
+	newGame, rec, err := g.ApplyMove("e2e4", now)
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, early, newGame, rec, 0); !errors.Is(err, ports.ErrClaimHeld) {
+		t.Fatalf("inside the window: want ErrClaimHeld, got %v", err)
+	}
+	if n, err := s.CountAvailableForClient(ctx, uuid.New()); err != nil || n != 0 {
+		t.Fatalf("available inside the window = %d, %v; want 0", n, err)
+	}
+
+	now = now.Add(time.Minute)
+	if _, err := s.PersistMove(ctx, g.ID, early, newGame, rec, 0); err != nil {
+		t.Fatalf("after the window: %v", err)
+	}
+}
+
+func TestClaimNextGame_PreferInProgress(t *testing.T) {
+	ctx := context.Background()
+	tick := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := func() time.Time {
+		tick = tick.Add(time.Second)
+		return tick
+	}
+	s := pgstore.NewWithClock(setupPool(t), clock)
+
+	if _, err := s.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	mover := uuid.New()
+	oldest, _, err := s.ClaimNextGame(ctx, mover)
+	if err != nil {
+		t.Fatalf("claim 1: %v", err)
+	}
+	newer, _, err := s.ClaimNextGame(ctx, mover)
+	if err != nil {
+		t.Fatalf("claim 2: %v", err)
+	}
+	moved, rec, err := newer.ApplyMove("e2e4", time.Now().UTC())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, newer.ID, mover, moved, rec, 0); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	got, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("default claim: %v", err)
+	}
+	if got.ID != oldest.ID {
+		t.Fatalf("default: want oldest game %s, got %s", oldest.ID, got.ID)
+	}
+	got, _, err = s.WithPreferInProgress().ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("preferring claim: %v", err)
+	}
+	if got.ID != newer.ID {
+		t.Fatalf("prefer in-progress: want started game %s, got %s", newer.ID, got.ID)
+	}
+}
+
+func TestCountAvailableForClient(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	if _, _, err := s.ClaimNextGame(ctx, clientID); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	n, err := s.CountAvailableForClient(ctx, clientID)
+	if err != nil {
+		t.Fatalf("CountAvailableForClient: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("want 2 available, got %d", n)
+	}
+}
+
+func TestCountWaiting(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, uuid.New()); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	n, err := s.CountWaiting(ctx)
+	if err != nil {
+		t.Fatalf("CountWaiting: %v", err)
+	}
+	if n != 2 {
+		t.Fatalf("waiting = %d, want 2", n)
+	}
+}
+
+func TestListChangedSince(t *testing.T) {
+	ctx := context.Background()
+	fixed := time.Date(2030, 1, 2, 3, 4, 5, 0, time.UTC)
+	s := pgstore.NewWithClock(setupPool(t), func() time.Time { return fixed })
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	// All three share updated_at; the id tiebreaker must page through them.
+	first, err := s.ListChangedSince(ctx, fixed, uuid.Nil, 2)
+	if err != nil {
+		t.Fatalf("page 1: %v", err)
+	}
+	if len(first) != 2 {
+		t.Fatalf("page 1: got %d games, want 2", len(first))
+	}
+	last := first[1]
+	second, err := s.ListChangedSince(ctx, last.UpdatedAt, last.ID, 2)
+	if err != nil {
+		t.Fatalf("page 2: %v", err)
+	}
+	if len(second) != 1 {
+		t.Fatalf("page 2: got %d games, want 1", len(second))
+	}
+	if second[0].ID == first[0].ID || second[0].ID == first[1].ID {
+		t.Fatal("page 2 repeated a game from page 1")
+	}
+}
+
+func TestInTx_RollsBack(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	boom := errors.New("boom")
+
+	err := s.InTx(ctx, func(tx ports.GameStore) error {
+		if _, _, err := tx.ClaimNextGame(ctx, clientID); err != nil {
+			return err
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("want boom, got %v", err)
+	}
+
+	n, err := s.CountAvailableForClient(ctx, clientID)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("claim was not rolled back: %d available, want 1", n)
+	}
+}
+
+func TestRecordFailedMove(t *testing.T) {
+	pool := setupPool(t)
+	s := pgstore.New(pool)
+	ctx := context.Background()
+
+	fm := ports.FailedMove{
+		ID:        uuid.New(),
+		GameID:    uuid.New(),
+		ClientID:  uuid.New(),
+		Ply:       0,
+		UCI:       "e2e4",
+		FENBefore: "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		FENAfter:  "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq - 0 1",
+		Error:     "connection reset",
+		CreatedAt: time.Now().UTC().Truncate(time.Millisecond),
+	}
+	if err := s.RecordFailedMove(ctx, fm); err != nil {
+		t.Fatalf("RecordFailedMove: %v", err)
+	}
+
+	var uci, errText string
+	if err := pool.QueryRow(ctx, `SELECT uci, error FROM failed_moves WHERE id = $1`, fm.ID).Scan(&uci, &errText); err != nil {
+		t.Fatalf("select: %v", err)
+	}
+	if uci != fm.UCI || errText != fm.Error {
+		t.Errorf("got uci=%q error=%q", uci, errText)
+	}
+}
+
+func TestGetMovesSince(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	var gameID uuid.UUID
+	for ply, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		clientID := uuid.New()
+		g, _, err := s.ClaimNextGame(ctx, clientID)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		gameID = g.ID
+		newGame, rec, err := g.ApplyMove(uci, time.Now().UTC().Truncate(time.Millisecond))
+		if err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+		if _, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, ply); err != nil {
+			t.Fatalf("persist %s: %v", uci, err)
+		}
+	}
+
+	moves, err := s.GetMovesSince(ctx, gameID, 1)
+	if err != nil {
+		t.Fatalf("GetMovesSince: %v", err)
+	}
+	if len(moves) != 2 || moves[0].Ply != 1 || moves[1].UCI != "g1f3" {
+		t.Fatalf("unexpected window: %+v", moves)
+	}
+
+	page, err := s.GetMovesPage(ctx, gameID, 1, 1)
+	if err != nil {
+		t.Fatalf("GetMovesPage: %v", err)
+	}
+	if len(page) != 1 || page[0].UCI != "e7e5" {
+		t.Fatalf("unexpected page: %+v", page)
+	}
+}
+
+// TestLastMoveAt: the cooldown input does not depend on move history.
+func TestLastMoveAt(t *testing.T) {
+	base := setupStore(t)
+	for name, s := range map[string]*pgstore.Store{"history": base, "without history": base.WithoutHistory()} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+
+			if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+				t.Fatalf("batch: %v", err)
+			}
+			clientID := uuid.New()
+
+			at, err := s.LastMoveAt(ctx, clientID)
+			if err != nil {
+				t.Fatalf("LastMoveAt: %v", err)
+			}
+			if at != nil {
+				t.Fatalf("want nil before any move, got %v", at)
+			}
+
+			g, _, err := s.ClaimNextGame(ctx, clientID)
+			if err != nil {
+				t.Fatalf("claim: %v", err)
+			}
+			// The other subtest's game may be claimed here, so play whatever
+			// is legal.
+			legal, err := g.LegalMoves()
+			if err != nil || len(legal) == 0 {
+				t.Fatalf("legal moves: %v", err)
+			}
+			newGame, rec, err := g.ApplyMove(legal[0], time.Now().UTC().Truncate(time.Millisecond))
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if _, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, newGame.PlyCount-1); err != nil {
+				t.Fatalf("persist: %v", err)
+			}
+
+			at, err = s.LastMoveAt(ctx, clientID)
+			if err != nil {
+				t.Fatalf("LastMoveAt after move: %v", err)
+			}
+			if at == nil || !at.Equal(rec.CreatedAt) {
+				t.Fatalf("want %v, got %v", rec.CreatedAt, at)
+			}
+		})
+	}
+}
+
+func TestBlockClient(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	clientID := uuid.New()
+
+	if err := s.BlockClient(ctx, clientID); err != nil {
+		t.Fatalf("block: %v", err)
+	}
+	// Blocking twice is a no-op.
+	if err := s.BlockClient(ctx, clientID); err != nil {
+		t.Fatalf("block again: %v", err)
+	}
+	ids, err := s.ListBlockedClients(ctx)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(ids) != 1 || ids[0] != clientID {
+		t.Fatalf("want [%s], got %v", clientID, ids)
+	}
+
+	if err := s.UnblockClient(ctx, clientID); err != nil {
+		t.Fatalf("unblock: %v", err)
+	}
+	if ids, err = s.ListBlockedClients(ctx); err != nil || len(ids) != 0 {
+		t.Fatalf("after unblock: want none, got %v err=%v", ids, err)
+	}
+}
+
+func TestUpdateMetadata(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	g := newTestGame(t)
+	if err := s.Insert(ctx, g); err != nil {
+		t.Fatalf("insert: %v", err)
+	}
+
+	title := "featured"
+	got, err := s.UpdateMetadata(ctx, g.ID, ports.MetadataPatch{
+		TitleSet: true, Title: &title,
+		TagsSet: true, Tags: []string{"a", "b"},
+	})
+	if err != nil {
+		t.Fatalf("update: %v", err)
+	}
+	if got.Title == nil || *got.Title != title {
+		t.Errorf("title: want %q, got %v", title, got.Title)
+	}
+	if len(got.Tags) != 2 {
+		t.Errorf("tags: want 2, got %v", got.Tags)
+	}
+	if got.StateVersion != g.StateVersion {
+		t.Errorf("state_version changed: want %d, got %d", g.StateVersion, got.StateVersion)
+	}
+	if !got.UpdatedAt.After(g.UpdatedAt) {
+		t.Errorf("updated_at not advanced: was %v, got %v", g.UpdatedAt, got.UpdatedAt)
+	}
+
+	// A patch without TitleSet keeps the title.
+	got, err = s.UpdateMetadata(ctx, g.ID, ports.MetadataPatch{TagsSet: true})
+	if err != nil {
+		t.Fatalf("update tags: %v", err)
+	}
+	if got.Title == nil || *got.Title != title {
+		t.Errorf("title lost: got %v", got.Title)
+	}
+	if len(got.Tags) != 0 {
+		t.Errorf("tags: want empty, got %v", got.Tags)
+	}
+
+	if _, err := s.UpdateMetadata(ctx, uuid.New(), ports.MetadataPatch{}); err != ports.ErrNotFound {
+		t.Fatalf("unknown game: want ErrNotFound, got %v", err)
+	}
+}
+
+func TestStats(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.HasActiveGames(ctx); err != nil {
+		t.Fatalf("query: %v", err)
+	}
+	st := s.Stats()
+	if st.TotalConns < 1 || st.AcquireCount < 1 {
+		t.Fatalf("expected pool activity, got %+v", st)
+	}
+
+	err := s.InTx(ctx, func(tx ports.GameStore) error {
+		if got := tx.(ports.PoolStatsProvider).Stats(); got != (ports.PoolStats{}) {
+			t.Errorf("tx-bound store: want zero stats, got %+v", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InTx: %v", err)
+	}
+}
+
+func TestSampleForAudit(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 5); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	games, err := s.SampleForAudit(ctx, 3)
+	if err != nil {
+		t.Fatalf("sample: %v", err)
+	}
+	if len(games) != 3 {
+		t.Fatalf("want 3 games, got %d", len(games))
+	}
+	for _, g := range games {
+		if problems := g.Inconsistencies(); len(problems) != 0 {
+			t.Fatalf("game %s: %v", g.ID, problems)
+		}
+	}
+}
+
+func TestListContributors(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	var first uuid.UUID
+	var gameID uuid.UUID
+	for i, uci := range []string{"e2e4", "e7e5"} {
+		clientID := uuid.New()
+		if i == 0 {
+			first = clientID
+		}
+		g, _, err := s.ClaimNextGame(ctx, clientID)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		gameID = g.ID
+		if i == 0 {
+			contributors, err := s.ListContributors(ctx, gameID)
+			if err != nil || len(contributors) != 0 {
+				t.Fatalf("before moves: want empty, got %v err=%v", contributors, err)
+			}
+		}
+		next, rec, err := g.ApplyMove(uci, time.Now())
+		if err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+		if _, err := s.PersistMove(ctx, gameID, clientID, next, rec, next.PlyCount-1); err != nil {
+			t.Fatalf("persist: %v", err)
+		}
+	}
+
+	contributors, err := s.ListContributors(ctx, gameID)
+	if err != nil {
+		t.Fatalf("ListContributors: %v", err)
+	}
+	if len(contributors) != 2 || contributors[0].ClientID != first || contributors[0].FirstPly != 0 || contributors[1].FirstPly != 1 {
+		t.Fatalf("unexpected contributors: %+v", contributors)
+	}
+}
+
+func TestPurgeFinished(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	old, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	recent, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	for g, updatedAt := range map[*game.Game]time.Time{
+		old:    time.Now().Add(-48 * time.Hour),
+		recent: time.Now(),
+	} {
+		done := *g
+		done.Status = game.StatusDraw
+		done.UpdatedAt = updatedAt
+		done.StateVersion++
+		if err := s.SaveIfVersion(ctx, &done, g.StateVersion); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+
+	n, err := s.PurgeFinished(ctx, time.Now().Add(-24*time.Hour))
+	if err != nil {
+		t.Fatalf("purge: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("want 1 purged game, got %d", n)
+	}
+	if _, err := s.GetByID(ctx, old.ID); err != ports.ErrNotFound {
+		t.Fatalf("old game: want ErrNotFound, got %v", err)
+	}
+	if _, err := s.GetByID(ctx, recent.ID); err != nil {
+		t.Fatalf("recent game: %v", err)
+	}
+}
+
+func TestIsAssigned(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	if assigned, moved, err := s.IsAssigned(ctx, g.ID, uuid.New()); err != nil || assigned || moved {
+		t.Fatalf("stranger: assigned=%v moved=%v err=%v", assigned, moved, err)
+	}
+	if assigned, moved, err := s.IsAssigned(ctx, g.ID, clientID); err != nil || !assigned || moved {
+		t.Fatalf("before move: assigned=%v moved=%v err=%v", assigned, moved, err)
+	}
+
+	next, rec, err := g.ApplyMove("e2e4", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, clientID, next, rec, next.PlyCount-1); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	if assigned, moved, err := s.IsAssigned(ctx, g.ID, clientID); err != nil || !assigned || !moved {
+		t.Fatalf("after move: assigned=%v moved=%v err=%v", assigned, moved, err)
+	}
+}
+
+func TestPersistHouseMove(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	g, _, err := s.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	// The house client holds no assignment and may move repeatedly.
+	house := uuid.New()
+	for _, uci := range []string{"e2e4", "e7e5"} {
+		next, rec, err := g.ApplyMove(uci, time.Now())
+		if err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+		if err := s.PersistHouseMove(ctx, g.ID, house, next, rec, next.PlyCount-1); err != nil {
+			t.Fatalf("house move %s: %v", uci, err)
+		}
+		g = next
+	}
+	stale, rec, err := g.ApplyMove("g1f3", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	stale.StateVersion = g.StateVersion // derived from an outdated version
+	if err := s.PersistHouseMove(ctx, g.ID, house, stale, rec, 2); err != ports.ErrVersionConflict {
+		t.Fatalf("stale move: want ErrVersionConflict, got %v", err)
+	}
+
+	_, hist, err := s.GetGameWithHistory(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("history: %v", err)
+	}
+	if len(hist) != 2 || hist[0].ClientID != house || hist[1].ClientID != house {
+		t.Fatalf("unexpected history: %+v", hist)
+	}
+}
+
+func TestListClientClaims(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	first, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	next, rec, err := first.ApplyMove("e2e4", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, first.ID, clientID, next, rec, next.PlyCount-1); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+	second, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	claims, err := s.ListClientClaims(ctx, clientID, time.Time{}, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(claims) != 2 || claims[0].GameID != first.ID || claims[1].GameID != second.ID {
+		t.Fatalf("unexpected claims: %+v", claims)
+	}
+	if !claims[0].HasMoved || claims[0].Move == nil || claims[0].Move.ID != rec.ID {
+		t.Fatalf("first claim: %+v", claims[0])
+	}
+	if claims[1].HasMoved || claims[1].Move != nil {
+		t.Fatalf("second claim: %+v", claims[1])
+	}
+
+	page, err := s.ListClientClaims(ctx, clientID, claims[0].ClaimedAt, claims[0].GameID, 10)
+	if err != nil {
+		t.Fatalf("list after cursor: %v", err)
+	}
+	if len(page) != 1 || page[0].GameID != second.ID {
+		t.Fatalf("page after cursor: %+v", page)
+	}
+}
+
+func TestGameEvents(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	clientID := uuid.New()
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	next, rec, err := g.ApplyMove("e2e4", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if _, err := s.PersistMove(ctx, g.ID, clientID, next, rec, next.PlyCount-1); err != nil {
+		t.Fatalf("persist: %v", err)
+	}
+
+	events, err := s.ListGameEvents(ctx, g.ID)
+	if err != nil {
+		t.Fatalf("events: %v", err)
+	}
+	want := []ports.GameEventKind{ports.EventCreated, ports.EventClaimed, ports.EventStatusChange, ports.EventMove}
+	if len(events) != len(want) {
+		t.Fatalf("want %d events, got %+v", len(want), events)
+	}
+	for i, ev := range events {
+		if ev.Kind != want[i] {
+			t.Fatalf("event %d: kind %s, want %s", i, ev.Kind, want[i])
+		}
+	}
+	if events[0].Actor != nil || events[3].Actor == nil || *events[3].Actor != clientID || events[3].Detail != "e2e4" {
+		t.Fatalf("unexpected events: %+v", events)
+	}
+
+	// A failed move leaves no event behind.
+	stale := *next
+	if _, err := s.PersistMove(ctx, g.ID, clientID, &stale, rec, 0); err == nil {
+		t.Fatal("repeat move: want error")
+	}
+	if events, _ := s.ListGameEvents(ctx, g.ID); len(events) != len(want) {
+		t.Fatalf("failed move logged events: %+v", events)
+	}
+}
+
+func TestListGames(t *testing.T) {
+	s := setupStore(t)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 3); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	if _, _, err := s.ClaimNextGame(ctx, uuid.New()); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+
+	waiting, err := s.ListGames(ctx, ports.GameFilter{Status: game.StatusWaiting}, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("waiting: %v", err)
+	}
+	if len(waiting) != 2 {
+		t.Fatalf("waiting: got %d games, want 2", len(waiting))
+	}
+	rest, err := s.ListGames(ctx, ports.GameFilter{}, waiting[0].ID, 10)
+	if err != nil {
+		t.Fatalf("after cursor: %v", err)
+	}
+	for _, g := range rest {
+		if g.ID.String() <= waiting[0].ID.String() {
+			t.Fatalf("game %s is not after the cursor %s", g.ID, waiting[0].ID)
+		}
+	}
+
+	drawn := *waiting[0]
+	draw := game.ResultDraw
+	drawn.Status, drawn.Result = game.StatusDraw, &draw
+	drawn.StateVersion++
+	if err := s.SaveIfVersion(ctx, &drawn, waiting[0].StateVersion); err != nil {
+		t.Fatalf("save: %v", err)
+	}
+	draws, err := s.ListGames(ctx, ports.GameFilter{Result: game.ResultDraw}, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("draws: %v", err)
+	}
+	if len(draws) != 1 || draws[0].ID != drawn.ID {
+		t.Fatalf("draws: got %d games, want only %s", len(draws), drawn.ID)
+	}
+	wins, err := s.ListGames(ctx, ports.GameFilter{Status: game.StatusDraw, Result: game.ResultWhite}, uuid.Nil, 10)
+	if err != nil {
+		t.Fatalf("white wins: %v", err)
+	}
+	if len(wins) != 0 {
+		t.Fatalf("white wins: got %d games, want 0", len(wins))
+	}
+}
+
+// TestTotalMoves: the ply-count sum agrees with the moves row count.
+func TestTotalMoves(t *testing.T) {
+	pool := setupPool(t)
+	s := pgstore.New(pool)
+	ctx := context.Background()
+
+	if _, err := s.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		clientID := uuid.New()
+		g, _, err := s.ClaimNextGame(ctx, clientID)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		newGame, rec, err := g.ApplyMove(uci, time.Now().UTC())
+		if err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+		if _, err := s.PersistMove(ctx, g.ID, clientID, newGame, rec, newGame.PlyCount-1); err != nil {
+			t.Fatalf("persist %s: %v", uci, err)
+		}
+	}
+
+	total, err := s.TotalMoves(ctx)
+	if err != nil {
+		t.Fatalf("TotalMoves: %v", err)
+	}
+	var rows int64
+	if err := pool.QueryRow(ctx, `SELECT COUNT(*) FROM moves`).Scan(&rows); err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if total != 3 || total != rows {
+		t.Fatalf("TotalMoves = %d, moves rows = %d; want 3", total, rows)
+	}
+}
+
+func TestAcquireTimeout_PoolExhausted(t *testing.T) {
+	ctx := context.Background()
+	cfg := setupPool(t).Config()
+	cfg.MaxConns = 1
+	pool, err := pgxpool.NewWithConfig(ctx, cfg)
+	if err != nil {
+		t.Fatalf("pgxpool.NewWithConfig: %v", err)
+	}
+	t.Cleanup(pool.Close)
+	store := pgstore.New(pool).WithAcquireTimeout(50 * time.Millisecond)
+	if _, err := store.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("CreateWaitingBatch: %v", err)
+	}
+
+	// Hold the only connection so the claim cannot begin its transaction.
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		t.Fatalf("Acquire: %v", err)
+	}
+	if _, _, err := store.ClaimNextGame(ctx, uuid.New()); !errors.Is(err, ports.ErrStoreBusy) {
+		t.Fatalf("exhausted pool: want ErrStoreBusy, got %v", err)
+	}
+
+	conn.Release()
+	if _, _, err := store.ClaimNextGame(ctx, uuid.New()); err != nil {
+		t.Fatalf("after release: %v", err)
+	}
+}
+
+func TestPeekNextGameID(t *testing.T) {
+	ctx := context.Background()
+	s := setupStore(t)
+	clientID := uuid.New()
+
+	if _, err := s.PeekNextGameID(ctx, clientID); !errors.Is(err, ports.ErrNoGamesAvailable) {
+		t.Fatalf("empty store: want ErrNoGamesAvailable, got %v", err)
+	}
+	if _, err := s.CreateWaitingBatch(ctx, 1); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	id, err := s.PeekNextGameID(ctx, clientID)
+	if err != nil {
+		t.Fatalf("peek: %v", err)
+	}
+	// Peeking claims nothing, so a second peek and the claim agree.
+	again, err := s.PeekNextGameID(ctx, clientID)
+	if err != nil || again != id {
+		t.Fatalf("second peek: got %s, %v; want %s", again, err, id)
+	}
+	g, _, err := s.ClaimNextGame(ctx, clientID)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	if g.ID != id {
+		t.Fatalf("claimed %s, peek said %s", g.ID, id)
+	}
+
+	if _, err := s.PeekNextGameID(ctx, clientID); !errors.Is(err, ports.ErrNoGamesAvailable) {
+		t.Fatalf("after claiming: want ErrNoGamesAvailable, got %v", err)
+	}
+}
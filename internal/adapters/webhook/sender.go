@@ -0,0 +1,199 @@
+// Package webhook delivers game-completion notifications to an external URL.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+)
+
+// SignatureHeader carries the hex HMAC-SHA256 of the request body, keyed by
+// the shared secret, as "sha256=<hex>".
+const SignatureHeader = "X-Signature-256"
+
+// Options configures a Sender. Zero values select the defaults noted below.
+type Options struct {
+	// Secret signs each body; empty sends unsigned requests.
+	Secret string
+	// QueueSize bounds pending notifications (default 100). Notifications
+	// arriving while the queue is full are dropped.
+	QueueSize int
+	// MaxAttempts per notification (default 5).
+	MaxAttempts int
+	// BaseBackoff is the delay before the first retry, doubled after each
+	// failure (default 1s).
+	BaseBackoff time.Duration
+	// Client sends the requests (default: 10s timeout).
+	Client *http.Client
+	// Dropped counts notifications lost to a full queue. Optional.
+	Dropped *metrics.Counter
+}
+
+// Sender posts finished games to a webhook URL from a background worker, so
+// notifying never blocks the caller.
+type Sender struct {
+	url   string
+	opts  Options
+	queue chan []byte
+}
+
+// New creates a Sender for url. Call Run to start delivering.
+func New(url string, opts Options) *Sender {
+	if opts.QueueSize <= 0 {
+		opts.QueueSize = 100
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.BaseBackoff <= 0 {
+		opts.BaseBackoff = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Sender{url: url, opts: opts, queue: make(chan []byte, opts.QueueSize)}
+}
+
+type gamePayload struct {
+	GameID    string     `json:"game_id"`
+	Status    string     `json:"status"`
+	Result    *string    `json:"result"`
+	FEN       string     `json:"fen"`
+	PlyCount  int        `json:"ply_count"`
+	Title     *string    `json:"title"`
+	Tags      []string   `json:"tags"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt time.Time  `json:"updated_at"`
+	EndedAt   *time.Time `json:"ended_at"`
+}
+
+type payload struct {
+	Event string      `json:"event"`
+	Game  gamePayload `json:"game"`
+	// PGN is omitted when the history passed in does not cover every ply,
+	// as when the store does not persist moves.
+	PGN string `json:"pgn,omitempty"`
+}
+
+// GameCompleted queues a notification for g. It never blocks: when the queue
+// is full the notification is dropped and counted.
+func (s *Sender) GameCompleted(g *game.Game, history []game.MoveHistoryItem) {
+	var pgn string
+	if len(history) == g.PlyCount {
+		var err error
+		if pgn, err = g.PGN(history); err != nil {
+			log.Printf("webhook: pgn for game %s: %v", g.ID, err)
+		}
+	}
+	var result *string
+	if g.Result != nil {
+		r := string(*g.Result)
+		result = &r
+	}
+	tags := g.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	body, err := json.Marshal(payload{
+		Event: "game.completed",
+		Game: gamePayload{
+			GameID:    g.ID.String(),
+			Status:    string(g.Status),
+			Result:    result,
+			FEN:       g.FEN,
+			PlyCount:  g.PlyCount,
+			Title:     g.Title,
+			Tags:      tags,
+			CreatedAt: g.CreatedAt,
+			UpdatedAt: g.UpdatedAt,
+			EndedAt:   g.LastMoveAt,
+		},
+		PGN: pgn,
+	})
+	if err != nil {
+		log.Printf("webhook: encode game %s: %v", g.ID, err)
+		return
+	}
+
+	select {
+	case s.queue <- body:
+	default:
+		if s.opts.Dropped != nil {
+			s.opts.Dropped.Inc()
+		}
+		log.Printf("webhook: queue full, dropped notification for game %s", g.ID)
+	}
+}
+
+// Run delivers queued notifications until ctx is cancelled.
+func (s *Sender) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case body := <-s.queue:
+			s.deliver(ctx, body)
+		}
+	}
+}
+
+// deliver posts body, retrying with exponential backoff on transport errors
+// and non-2xx responses.
+func (s *Sender) deliver(ctx context.Context, body []byte) {
+	backoff := s.opts.BaseBackoff
+	for attempt := 1; ; attempt++ {
+		err := s.post(ctx, body)
+		if err == nil {
+			return
+		}
+		if attempt == s.opts.MaxAttempts {
+			log.Printf("webhook: giving up after %d attempts: %v", attempt, err)
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+func (s *Sender) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if s.opts.Secret != "" {
+		req.Header.Set(SignatureHeader, "sha256="+Sign([]byte(s.opts.Secret), body))
+	}
+
+	resp, err := s.opts.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body under secret, as sent in
+// SignatureHeader.
+func Sign(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
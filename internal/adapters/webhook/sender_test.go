@@ -0,0 +1,155 @@
+package webhook_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/webhook"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+)
+
+func finishedGame(t *testing.T) (*game.Game, []game.MoveHistoryItem) {
+	t.Helper()
+	now := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	g := game.NewGame(uuid.New(), now)
+	var history []game.MoveHistoryItem
+	for i, uci := range []string{"f2f3", "e7e5", "g2g4", "d8h4"} {
+		next, rec, err := g.ApplyMove(uci, now)
+		if err != nil {
+			t.Fatalf("ApplyMove %s: %v", uci, err)
+		}
+		history = append(history, game.MoveHistoryItem{Ply: i + 1, UCI: rec.UCI, CreatedAt: now})
+		g = next
+	}
+	return g, history
+}
+
+func TestSender_DeliversSignedPayload(t *testing.T) {
+	type delivery struct {
+		body []byte
+		sig  string
+	}
+	got := make(chan delivery, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- delivery{body: body, sig: r.Header.Get(webhook.SignatureHeader)}
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := webhook.New(srv.URL, webhook.Options{Secret: "s3cret"})
+	go s.Run(ctx)
+
+	g, history := finishedGame(t)
+	s.GameCompleted(g, history)
+
+	var d delivery
+	select {
+	case d = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	if want := "sha256=" + webhook.Sign([]byte("s3cret"), d.body); d.sig != want {
+		t.Fatalf("signature %q, want %q", d.sig, want)
+	}
+
+	var payload struct {
+		Event string `json:"event"`
+		Game  struct {
+			GameID string `json:"game_id"`
+			Result string `json:"result"`
+		} `json:"game"`
+		PGN string `json:"pgn"`
+	}
+	if err := json.Unmarshal(d.body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if payload.Event != "game.completed" || payload.Game.GameID != g.ID.String() || payload.Game.Result != "0-1" || payload.PGN == "" {
+		t.Fatalf("unexpected payload: %s", d.body)
+	}
+}
+
+// TestSender_OmitsPGNWithoutHistory: a game finished while history is not
+// persisted is reported without a PGN rather than with an empty move list.
+func TestSender_OmitsPGNWithoutHistory(t *testing.T) {
+	got := make(chan []byte, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got <- body
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := webhook.New(srv.URL, webhook.Options{})
+	go s.Run(ctx)
+
+	g, _ := finishedGame(t)
+	s.GameCompleted(g, []game.MoveHistoryItem{})
+
+	var body []byte
+	select {
+	case body = <-got:
+	case <-time.After(5 * time.Second):
+		t.Fatal("webhook not delivered")
+	}
+	var payload map[string]any
+	if err := json.Unmarshal(body, &payload); err != nil {
+		t.Fatalf("decode payload: %v", err)
+	}
+	if _, ok := payload["pgn"]; ok {
+		t.Fatalf("want no pgn, got %s", body)
+	}
+}
+
+func TestSender_RetriesOnFailure(t *testing.T) {
+	var calls atomic.Int32
+	done := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		close(done)
+	}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s := webhook.New(srv.URL, webhook.Options{BaseBackoff: time.Millisecond})
+	go s.Run(ctx)
+
+	g, history := finishedGame(t)
+	s.GameCompleted(g, history)
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("not delivered after retries; %d calls", calls.Load())
+	}
+}
+
+func TestSender_DropsWhenQueueFull(t *testing.T) {
+	dropped := metrics.NewRegistry().Counter("webhook_dropped_total", "")
+	// No worker runs, so the single queue slot fills up.
+	s := webhook.New("http://127.0.0.1:0", webhook.Options{QueueSize: 1, Dropped: dropped})
+
+	g, history := finishedGame(t)
+	s.GameCompleted(g, history)
+	s.GameCompleted(g, history)
+	s.GameCompleted(g, history)
+
+	if n := dropped.Value(); n != 2 {
+		t.Fatalf("dropped = %d, want 2", n)
+	}
+}
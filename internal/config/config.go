@@ -1,15 +1,98 @@
 package config
 
 import (
+	"log"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/ports"
 )
 
 // Config holds application configuration read from environment variables.
 type Config struct {
-	Port                string
-	DatabaseURL         string
-	GameCreateBatchSize int
+	Port                 string
+	DatabaseURL          string
+	GameCreateBatchSize  int
+	SingleActiveClaim    bool
+	MoveCooldown         time.Duration
+	RunMigrationsOnStart bool
+	CheckSchemaOnStart   bool
+	AdminToken           string
+	AdminSignedRequests  bool
+	AdminSignatureSkew   time.Duration
+	BlockedClientIDs     []uuid.UUID
+	BlocklistRefresh     time.Duration
+	MoveMaxBody          string
+	AdminMaxBody         string
+	DeadLetterMoves      bool
+	TargetWaitingPool    int
+	MinWaitingOnStart    int
+	MaxWaitingGames      int
+	PoolRefillInterval   time.Duration
+	// ClaimStrategy is oldest, random or most_active. random only shuffles
+	// the 100 oldest eligible games; see ports.ClaimRandom.
+	ClaimStrategy        ports.ClaimStrategy
+	MaxConcurrentClaims  int
+	ClaimOverflow        string
+	ClaimQueueTimeout    time.Duration
+	PreferInProgress     bool
+	ClaimExclusiveWindow time.Duration
+	DBAcquireTimeout     time.Duration
+	RouteInFlight        bool
+	BasePath             string
+	FinishedMaxAge       time.Duration
+	RobotsTxt            string
+	ReadTimeout          time.Duration
+	WriteTimeout         time.Duration
+	EnablePprof          bool
+	PprofAddr            string
+	PlaceholderClientIDs []uuid.UUID
+	CursorSecret         string
+	RecordUserAgent      bool
+	BlunderThresholdCP   int
+	HistoryLimit         int
+	WebhookURL           string
+	WebhookSecret        string
+	MaxGamesPerIP        int
+	MaxGamesPerIPWindow  time.Duration
+	IPv4KeyPrefixLen     int
+	IPv6KeyPrefixLen     int
+	RetryAfter           time.Duration
+	RetryJitter          time.Duration
+	// RNGSeed, when set, makes the memory store's game IDs and random claim
+	// order reproducible.
+	RNGSeed *uint64
+	// DegradeHistory serves GET /games/:id without history, instead of a
+	// 500, when only the history query fails.
+	DegradeHistory bool
+	// AuditInterval enables the FEN consistency self-check when positive.
+	AuditInterval   time.Duration
+	AuditSampleSize int
+	ReadOnly        bool
+	// GameRetention purges finished games older than this when positive;
+	// zero keeps them forever.
+	GameRetention time.Duration
+	PurgeInterval time.Duration
+	// HouseMoves plays a random legal move on ongoing games idle for longer
+	// than HouseMoveStall, checking every HouseMoveInterval.
+	HouseMoves        bool
+	HouseMoveStall    time.Duration
+	HouseMoveInterval time.Duration
+	// StrictMoveInput rejects moves that send both uci and from/to.
+	StrictMoveInput bool
+	// RejectMovesOnWaiting refuses moves on games still in waiting status
+	// instead of activating them.
+	RejectMovesOnWaiting bool
+	// PersistHistory stores each move's record; when false only the game
+	// row advances and move history reads come back empty.
+	PersistHistory bool
+	// DraftTTL is how long a client's saved move draft is kept; zero uses
+	// the usecase default.
+	DraftTTL time.Duration
 }
 
 // Load reads configuration from environment variables with sensible defaults.
@@ -26,9 +109,340 @@ func Load() *Config {
 		}
 	}
 
+	singleActiveClaim, _ := strconv.ParseBool(os.Getenv("SINGLE_ACTIVE_CLAIM"))
+	runMigrations, _ := strconv.ParseBool(os.Getenv("RUN_MIGRATIONS_ON_START"))
+	checkSchema, _ := strconv.ParseBool(os.Getenv("CHECK_SCHEMA_ON_START"))
+	adminSigned, _ := strconv.ParseBool(os.Getenv("ADMIN_SIGNED_REQUESTS"))
+	deadLetterMoves, _ := strconv.ParseBool(os.Getenv("DEAD_LETTER_MOVES"))
+	enablePprof, _ := strconv.ParseBool(os.Getenv("ENABLE_PPROF"))
+	recordUserAgent, _ := strconv.ParseBool(os.Getenv("RECORD_USER_AGENT"))
+	degradeHistory, _ := strconv.ParseBool(os.Getenv("DEGRADE_HISTORY_ON_ERROR"))
+	readOnly, _ := strconv.ParseBool(os.Getenv("READ_ONLY"))
+	strictMoveInput, _ := strconv.ParseBool(os.Getenv("STRICT_MOVE_INPUT"))
+	rejectMovesOnWaiting, _ := strconv.ParseBool(os.Getenv("REJECT_MOVES_ON_WAITING"))
+	preferInProgress, _ := strconv.ParseBool(os.Getenv("PREFER_IN_PROGRESS"))
+	routeInFlight, _ := strconv.ParseBool(os.Getenv("METRICS_ROUTE_IN_FLIGHT"))
+
+	persistHistory := true
+	if v := os.Getenv("PERSIST_HISTORY"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			persistHistory = b
+		}
+	}
+
+	// BLUNDER_THRESHOLD_CP=0 turns the crude blunder flag off.
+	blunderThreshold := 300
+	if v := os.Getenv("BLUNDER_THRESHOLD_CP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			blunderThreshold = n
+		}
+	}
+
+	finishedMaxAge := 24 * time.Hour
+	if v := os.Getenv("FINISHED_CACHE_MAX_AGE_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			finishedMaxAge = time.Duration(n) * time.Second
+		}
+	}
+
+	// BASE_PATH is normalized to a leading slash and no trailing one, so
+	// "chess/", "/chess" and "/chess/" all mount the API at /chess/api/v1.
+	basePath := strings.Trim(os.Getenv("BASE_PATH"), "/")
+	if basePath != "" {
+		basePath = "/" + basePath
+	}
+
+	// Loopback by default so profiles are never exposed publicly by accident.
+	pprofAddr := os.Getenv("PPROF_ADDR")
+	if pprofAddr == "" {
+		pprofAddr = "127.0.0.1:6060"
+	}
+
+	var moveCooldown time.Duration
+	if v := os.Getenv("MOVE_COOLDOWN_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			moveCooldown = time.Duration(n) * time.Second
+		}
+	}
+
+	var maxGamesPerIP int
+	if v := os.Getenv("MAX_GAMES_PER_IP"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxGamesPerIP = n
+		}
+	}
+
+	maxGamesPerIPWindow := time.Hour
+	if v := os.Getenv("MAX_GAMES_PER_IP_WINDOW_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxGamesPerIPWindow = time.Duration(n) * time.Second
+		}
+	}
+
+	var claimExclusiveWindow time.Duration
+	if v := os.Getenv("CLAIM_EXCLUSIVE_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			claimExclusiveWindow = time.Duration(n) * time.Second
+		}
+	}
+
+	var adminSignatureSkew time.Duration
+	if v := os.Getenv("ADMIN_SIGNATURE_SKEW_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			adminSignatureSkew = time.Duration(n) * time.Second
+		}
+	}
+
+	var readTimeout, writeTimeout time.Duration
+	if v := os.Getenv("READ_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			readTimeout = time.Duration(n) * time.Millisecond
+		}
+	}
+	if v := os.Getenv("WRITE_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			writeTimeout = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	var dbAcquireTimeout time.Duration
+	if v := os.Getenv("DB_ACQUIRE_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			dbAcquireTimeout = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	// Unset prefixes fall back to the usecase defaults (/32 and /64).
+	var ipv4PrefixLen, ipv6PrefixLen int
+	if v := os.Getenv("IPV4_KEY_PREFIX_LEN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 32 {
+			ipv4PrefixLen = n
+		}
+	}
+	if v := os.Getenv("IPV6_KEY_PREFIX_LEN"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 && n <= 128 {
+			ipv6PrefixLen = n
+		}
+	}
+
+	retryAfter := 2 * time.Second
+	if v := os.Getenv("RETRY_AFTER_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			retryAfter = time.Duration(n) * time.Second
+		}
+	}
+
+	retryJitter := time.Second
+	if v := os.Getenv("RETRY_JITTER_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			retryJitter = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	var rngSeed *uint64
+	if v := os.Getenv("RNG_SEED"); v != "" {
+		if n, err := strconv.ParseUint(v, 10, 64); err == nil {
+			rngSeed = &n
+		}
+	}
+
+	blocklistRefresh := 30 * time.Second
+	if v := os.Getenv("BLOCKLIST_REFRESH_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			blocklistRefresh = time.Duration(n) * time.Second
+		}
+	}
+
+	var targetWaitingPool, maxWaitingGames, minWaitingOnStart int
+	if v := os.Getenv("TARGET_WAITING_POOL"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			targetWaitingPool = n
+		}
+	}
+	if v := os.Getenv("MAX_WAITING_GAMES"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxWaitingGames = n
+		}
+	}
+	if v := os.Getenv("MIN_WAITING_ON_START"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			minWaitingOnStart = n
+		}
+	}
+
+	historyLimit := 200
+	if v := os.Getenv("HISTORY_DEFAULT_LIMIT"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n >= 0 {
+			historyLimit = n
+		}
+	}
+
+	poolRefillInterval := 5 * time.Second
+	if v := os.Getenv("POOL_REFILL_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			poolRefillInterval = time.Duration(n) * time.Second
+		}
+	}
+
+	var auditInterval time.Duration
+	if v := os.Getenv("AUDIT_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			auditInterval = time.Duration(n) * time.Second
+		}
+	}
+
+	auditSampleSize := 50
+	if v := os.Getenv("AUDIT_SAMPLE_SIZE"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			auditSampleSize = n
+		}
+	}
+
+	var gameRetention time.Duration
+	if v := os.Getenv("GAME_RETENTION_DAYS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			gameRetention = time.Duration(n) * 24 * time.Hour
+		}
+	}
+
+	purgeInterval := time.Hour
+	if v := os.Getenv("PURGE_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			purgeInterval = time.Duration(n) * time.Second
+		}
+	}
+
+	houseMoves, _ := strconv.ParseBool(os.Getenv("HOUSE_MOVES_ENABLED"))
+	houseMoveStall := 24 * time.Hour
+	if v := os.Getenv("HOUSE_MOVE_STALL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			houseMoveStall = time.Duration(n) * time.Second
+		}
+	}
+	houseMoveInterval := time.Minute
+	if v := os.Getenv("HOUSE_MOVE_INTERVAL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			houseMoveInterval = time.Duration(n) * time.Second
+		}
+	}
+
+	claimStrategy := ports.ClaimOldest
+	switch v := ports.ClaimStrategy(os.Getenv("CLAIM_STRATEGY")); v {
+	case ports.ClaimRandom, ports.ClaimMostActive:
+		claimStrategy = v
+	}
+
+	var maxConcurrentClaims int
+	if v := os.Getenv("MAX_CONCURRENT_CLAIMS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			maxConcurrentClaims = n
+		}
+	}
+	// queue or reject; see usecase.ClaimOverflowPolicy.
+	claimOverflow := "queue"
+	switch v := os.Getenv("CLAIM_OVERFLOW_POLICY"); v {
+	case "", "queue":
+	case "reject":
+		claimOverflow = v
+	default:
+		log.Printf("CLAIM_OVERFLOW_POLICY=%q not recognized, using queue", v)
+	}
+	var claimQueueTimeout time.Duration
+	if v := os.Getenv("CLAIM_QUEUE_TIMEOUT_MS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			claimQueueTimeout = time.Duration(n) * time.Millisecond
+		}
+	}
+
+	var draftTTL time.Duration
+	if v := os.Getenv("DRAFT_TTL_SECONDS"); v != "" {
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			draftTTL = time.Duration(n) * time.Second
+		}
+	}
+
+	moveMaxBody := os.Getenv("MOVE_MAX_BODY")
+	if moveMaxBody == "" {
+		moveMaxBody = "16K"
+	}
+	adminMaxBody := os.Getenv("ADMIN_MAX_BODY")
+	if adminMaxBody == "" {
+		adminMaxBody = "1M"
+	}
+
 	return &Config{
-		Port:                port,
-		DatabaseURL:         os.Getenv("DATABASE_URL"),
-		GameCreateBatchSize: batchSize,
+		Port:                 port,
+		DatabaseURL:          os.Getenv("DATABASE_URL"),
+		GameCreateBatchSize:  batchSize,
+		SingleActiveClaim:    singleActiveClaim,
+		MoveCooldown:         moveCooldown,
+		RunMigrationsOnStart: runMigrations,
+		CheckSchemaOnStart:   checkSchema,
+		AdminToken:           os.Getenv("ADMIN_TOKEN"),
+		AdminSignedRequests:  adminSigned,
+		AdminSignatureSkew:   adminSignatureSkew,
+		BlockedClientIDs:     parseUUIDList(os.Getenv("BLOCKED_CLIENT_IDS")),
+		BlocklistRefresh:     blocklistRefresh,
+		MoveMaxBody:          moveMaxBody,
+		AdminMaxBody:         adminMaxBody,
+		DeadLetterMoves:      deadLetterMoves,
+		TargetWaitingPool:    targetWaitingPool,
+		MinWaitingOnStart:    minWaitingOnStart,
+		MaxWaitingGames:      maxWaitingGames,
+		PoolRefillInterval:   poolRefillInterval,
+		ClaimStrategy:        claimStrategy,
+		MaxConcurrentClaims:  maxConcurrentClaims,
+		ClaimOverflow:        claimOverflow,
+		ClaimQueueTimeout:    claimQueueTimeout,
+		PreferInProgress:     preferInProgress,
+		ClaimExclusiveWindow: claimExclusiveWindow,
+		DBAcquireTimeout:     dbAcquireTimeout,
+		RouteInFlight:        routeInFlight,
+		BasePath:             basePath,
+		FinishedMaxAge:       finishedMaxAge,
+		RobotsTxt:            os.Getenv("ROBOTS_TXT"),
+		ReadTimeout:          readTimeout,
+		WriteTimeout:         writeTimeout,
+		EnablePprof:          enablePprof,
+		PprofAddr:            pprofAddr,
+		PlaceholderClientIDs: parseUUIDList(os.Getenv("PLACEHOLDER_CLIENT_IDS")),
+		CursorSecret:         os.Getenv("CURSOR_SECRET"),
+		RecordUserAgent:      recordUserAgent,
+		BlunderThresholdCP:   blunderThreshold,
+		HistoryLimit:         historyLimit,
+		WebhookURL:           os.Getenv("GAME_COMPLETE_WEBHOOK_URL"),
+		WebhookSecret:        os.Getenv("GAME_COMPLETE_WEBHOOK_SECRET"),
+		MaxGamesPerIP:        maxGamesPerIP,
+		MaxGamesPerIPWindow:  maxGamesPerIPWindow,
+		IPv4KeyPrefixLen:     ipv4PrefixLen,
+		IPv6KeyPrefixLen:     ipv6PrefixLen,
+		RetryAfter:           retryAfter,
+		RetryJitter:          retryJitter,
+		RNGSeed:              rngSeed,
+		DegradeHistory:       degradeHistory,
+		AuditInterval:        auditInterval,
+		AuditSampleSize:      auditSampleSize,
+		ReadOnly:             readOnly,
+		GameRetention:        gameRetention,
+		PurgeInterval:        purgeInterval,
+		HouseMoves:           houseMoves,
+		HouseMoveStall:       houseMoveStall,
+		HouseMoveInterval:    houseMoveInterval,
+		StrictMoveInput:      strictMoveInput,
+		RejectMovesOnWaiting: rejectMovesOnWaiting,
+		PersistHistory:       persistHistory,
+		DraftTTL:             draftTTL,
+	}
+}
+
+// parseUUIDList parses a comma-separated list of UUIDs, skipping blanks and
+// malformed entries.
+func parseUUIDList(v string) []uuid.UUID {
+	var out []uuid.UUID
+	for _, part := range strings.Split(v, ",") {
+		if id, err := uuid.Parse(strings.TrimSpace(part)); err == nil {
+			out = append(out, id)
+		}
 	}
+	return out
 }
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "github.com/jackc/pgx/v5/stdlib" // registers the "pgx" database/sql driver
+	"github.com/pressly/goose/v3"
+)
+
+// migrationLockID is the pg_advisory_lock key that serializes migration runs
+// across replicas starting at the same time.
+const migrationLockID int64 = 0x72636d6967 // "rcmig"
+
+// MigrateUp applies all pending embedded migrations to databaseURL. It holds a
+// session-level advisory lock for the duration of the run, so concurrent
+// callers wait for each other instead of racing on the goose version table.
+func MigrateUp(ctx context.Context, databaseURL string) error {
+	sqlDB, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer sqlDB.Close()
+
+	// The advisory lock is tied to a single session, so pin one connection
+	// for lock and unlock.
+	conn, err := sqlDB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("acquire conn: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", migrationLockID); err != nil {
+		return fmt.Errorf("advisory lock: %w", err)
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", migrationLockID) //nolint:errcheck
+
+	goose.SetBaseFS(Migrations)
+	if err := goose.SetDialect("postgres"); err != nil {
+		return fmt.Errorf("goose set dialect: %w", err)
+	}
+	if err := goose.UpContext(ctx, sqlDB, "migrations"); err != nil {
+		return fmt.Errorf("goose up: %w", err)
+	}
+	return nil
+}
+
+// ErrSchemaBehind is returned by CheckSchema when the database has not been
+// migrated to the latest embedded migration.
+var ErrSchemaBehind = errors.New("database schema is behind the embedded migrations")
+
+// CheckSchema compares the goose version of databaseURL with the newest
+// embedded migration and returns ErrSchemaBehind when the database is older,
+// which means this build would run against a schema it does not know.
+func CheckSchema(ctx context.Context, databaseURL string) error {
+	sqlDB, err := sql.Open("pgx", databaseURL)
+	if err != nil {
+		return fmt.Errorf("open db: %w", err)
+	}
+	defer sqlDB.Close()
+
+	goose.SetBaseFS(Migrations)
+	if err := goose.SetDialect("postgres"); err != nil {
+		return fmt.Errorf("goose set dialect: %w", err)
+	}
+	current, err := goose.GetDBVersionContext(ctx, sqlDB)
+	if err != nil {
+		return fmt.Errorf("goose version: %w", err)
+	}
+	migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		return fmt.Errorf("collect migrations: %w", err)
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		return fmt.Errorf("latest migration: %w", err)
+	}
+	if current < latest.Version {
+		return fmt.Errorf("%w: database is at %d, build expects %d", ErrSchemaBehind, current, latest.Version)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+//go:build integration
+
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	tcpostgres "github.com/testcontainers/testcontainers-go/modules/postgres"
+
+	"github.com/randomtoy/random-chess-backend/internal/db"
+)
+
+func TestCheckSchema(t *testing.T) {
+	ctx := context.Background()
+	ctr, err := tcpostgres.Run(ctx,
+		"postgres:16-alpine",
+		tcpostgres.WithDatabase("testdb"),
+		tcpostgres.WithUsername("test"),
+		tcpostgres.WithPassword("test"),
+		tcpostgres.BasicWaitStrategies(),
+	)
+	if err != nil {
+		t.Fatalf("start postgres container: %v", err)
+	}
+	t.Cleanup(func() { _ = ctr.Terminate(ctx) })
+	connStr, err := ctr.ConnectionString(ctx, "sslmode=disable")
+	if err != nil {
+		t.Fatalf("connection string: %v", err)
+	}
+	sqlDB, err := sql.Open("pgx", connStr)
+	if err != nil {
+		t.Fatalf("open sql.DB: %v", err)
+	}
+	t.Cleanup(func() { _ = sqlDB.Close() })
+
+	goose.SetBaseFS(db.Migrations)
+	if err := goose.SetDialect("postgres"); err != nil {
+		t.Fatalf("goose set dialect: %v", err)
+	}
+	migrations, err := goose.CollectMigrations("migrations", 0, goose.MaxVersion)
+	if err != nil {
+		t.Fatalf("collect: %v", err)
+	}
+	latest, err := migrations.Last()
+	if err != nil {
+		t.Fatalf("last: %v", err)
+	}
+
+	// One migration short, as after deploying new code without migrating.
+	if err := goose.UpTo(sqlDB, "migrations", latest.Version-1); err != nil {
+		t.Fatalf("goose up-to: %v", err)
+	}
+	if err := db.CheckSchema(ctx, connStr); !errors.Is(err, db.ErrSchemaBehind) {
+		t.Fatalf("behind schema: want ErrSchemaBehind, got %v", err)
+	}
+
+	if err := goose.Up(sqlDB, "migrations"); err != nil {
+		t.Fatalf("goose up: %v", err)
+	}
+	if err := db.CheckSchema(ctx, connStr); err != nil {
+		t.Fatalf("current schema: %v", err)
+	}
+}
@@ -0,0 +1,13 @@
+-- +goose Up
+
+-- Special-move flags so clients can animate captures, castling and en passant.
+ALTER TABLE moves
+    ADD COLUMN is_capture    BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN is_en_passant BOOLEAN NOT NULL DEFAULT false,
+    ADD COLUMN is_castle     BOOLEAN NOT NULL DEFAULT false;
+
+-- +goose Down
+ALTER TABLE moves
+    DROP COLUMN is_castle,
+    DROP COLUMN is_en_passant,
+    DROP COLUMN is_capture;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Supports per-client lookups such as the move cooldown check.
+CREATE INDEX idx_moves_client_created ON moves (client_id, created_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_moves_client_created;
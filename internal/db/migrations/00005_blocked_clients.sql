@@ -0,0 +1,10 @@
+-- +goose Up
+
+-- Moderation: clients listed here may not claim games or submit moves.
+CREATE TABLE blocked_clients (
+    client_id  UUID        PRIMARY KEY,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+-- +goose Down
+DROP TABLE blocked_clients;
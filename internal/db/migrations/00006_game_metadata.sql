@@ -0,0 +1,11 @@
+-- +goose Up
+
+-- Operator-editable metadata; never part of the chess state.
+ALTER TABLE games
+    ADD COLUMN title TEXT,
+    ADD COLUMN tags  TEXT[] NOT NULL DEFAULT '{}';
+
+-- +goose Down
+ALTER TABLE games
+    DROP COLUMN tags,
+    DROP COLUMN title;
@@ -0,0 +1,20 @@
+-- +goose Up
+
+-- Dead-letter records of moves that passed validation but failed to persist.
+-- No foreign keys: the evidence must survive whatever broke the write.
+CREATE TABLE failed_moves (
+    id         UUID        PRIMARY KEY,
+    game_id    UUID        NOT NULL,
+    client_id  UUID        NOT NULL,
+    ply        INT         NOT NULL,
+    uci        TEXT        NOT NULL,
+    fen_before TEXT        NOT NULL,
+    fen_after  TEXT        NOT NULL,
+    error      TEXT        NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_failed_moves_created ON failed_moves (created_at);
+
+-- +goose Down
+DROP TABLE failed_moves;
@@ -0,0 +1,7 @@
+-- +goose Up
+
+-- Supports incremental sync: keyset pagination over (updated_at, id).
+CREATE INDEX idx_games_updated ON games (updated_at, id);
+
+-- +goose Down
+DROP INDEX idx_games_updated;
@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- Optional analytics capture of the submitting client's User-Agent. NULL when
+-- capture is disabled.
+ALTER TABLE moves ADD COLUMN user_agent TEXT;
+
+-- +goose Down
+ALTER TABLE moves DROP COLUMN user_agent;
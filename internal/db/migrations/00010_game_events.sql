@@ -0,0 +1,17 @@
+-- +goose Up
+
+-- Append-only lifecycle log per game, written in the same transaction as the
+-- state change each row describes. actor is NULL for system events.
+CREATE TABLE game_events (
+    id         BIGSERIAL   PRIMARY KEY,
+    game_id    UUID        NOT NULL REFERENCES games(id),
+    kind       TEXT        NOT NULL,
+    actor      UUID,
+    detail     TEXT        NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ NOT NULL
+);
+
+CREATE INDEX idx_game_events_game ON game_events (game_id, id);
+
+-- +goose Down
+DROP TABLE game_events;
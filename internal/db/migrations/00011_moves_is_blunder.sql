@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- Set when the move's heuristic eval swing against the mover exceeded the
+-- configured blunder threshold. A crude spectator signal, not engine analysis.
+ALTER TABLE moves ADD COLUMN is_blunder BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- +goose Down
+ALTER TABLE moves DROP COLUMN is_blunder;
@@ -0,0 +1,8 @@
+-- +goose Up
+
+-- While a claimant that has not moved holds exclusive_until in the future,
+-- no other client is handed the game. NULL when the window is disabled.
+ALTER TABLE game_players ADD COLUMN exclusive_until TIMESTAMPTZ;
+
+-- +goose Down
+ALTER TABLE game_players DROP COLUMN exclusive_until;
@@ -0,0 +1,12 @@
+-- +goose Up
+
+-- When the client's move in this game was accepted. Kept on game_players so
+-- the move cooldown works when moves are not persisted.
+ALTER TABLE game_players ADD COLUMN moved_at TIMESTAMPTZ;
+
+UPDATE game_players gp SET moved_at = m.created_at
+FROM moves m
+WHERE m.game_id = gp.game_id AND m.client_id = gp.client_id AND gp.has_moved;
+
+-- +goose Down
+ALTER TABLE game_players DROP COLUMN moved_at;
@@ -0,0 +1,161 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// ErrInvalidFEN is returned by ValidateFEN for unparsable positions.
+var ErrInvalidFEN = errors.New("invalid FEN")
+
+// ValidateFEN reports whether fen parses as a chess position.
+func ValidateFEN(fen string) error {
+	if _, err := chess.FEN(fen); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFEN, err)
+	}
+	return nil
+}
+
+// ErrIllegalPosition is returned by ValidateLegalPosition for positions that
+// parse but cannot arise in a game.
+var ErrIllegalPosition = errors.New("illegal position")
+
+// ValidateLegalPosition is ValidateFEN plus the rules a parsable position can
+// still break: each side has exactly one king, no pawn stands on the first or
+// eighth rank, and the side not to move is not in check. The error names the
+// broken rule.
+func ValidateLegalPosition(fen string) error {
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidFEN, err)
+	}
+	pos := chess.NewGame(fenOpt).Position()
+	board := pos.Board()
+
+	kings := map[chess.Color][]chess.Square{}
+	for sq, p := range board.SquareMap() {
+		switch p.Type() {
+		case chess.King:
+			kings[p.Color()] = append(kings[p.Color()], sq)
+		case chess.Pawn:
+			if r := sq.Rank(); r == chess.Rank1 || r == chess.Rank8 {
+				return fmt.Errorf("%w: %s pawn on %s", ErrIllegalPosition, colorName(p.Color()), sq)
+			}
+		}
+	}
+	for _, c := range []chess.Color{chess.White, chess.Black} {
+		if n := len(kings[c]); n != 1 {
+			return fmt.Errorf("%w: %s has %d kings", ErrIllegalPosition, colorName(c), n)
+		}
+	}
+	idle := pos.Turn().Other()
+	if attacked(board, kings[idle][0], pos.Turn()) {
+		return fmt.Errorf("%w: %s is in check with %s to move", ErrIllegalPosition, colorName(idle), colorName(pos.Turn()))
+	}
+	return nil
+}
+
+// attacked reports whether a piece of color by attacks sq on board.
+func attacked(board *chess.Board, sq chess.Square, by chess.Color) bool {
+	file, rank := int(sq.File()), int(sq.Rank())
+	at := func(df, dr int) chess.Piece {
+		f, r := file+df, rank+dr
+		if f < 0 || f > 7 || r < 0 || r > 7 {
+			return chess.NoPiece
+		}
+		return board.Piece(chess.Square(r*8 + f))
+	}
+	is := func(p chess.Piece, types ...chess.PieceType) bool {
+		return p != chess.NoPiece && p.Color() == by && slices.Contains(types, p.Type())
+	}
+
+	// A pawn attacks diagonally forward, so it sits one rank behind sq from
+	// its own side's point of view.
+	pawnRank := -1
+	if by == chess.Black {
+		pawnRank = 1
+	}
+	if is(at(-1, pawnRank), chess.Pawn) || is(at(1, pawnRank), chess.Pawn) {
+		return true
+	}
+	for _, d := range [][2]int{{1, 2}, {2, 1}, {2, -1}, {1, -2}, {-1, -2}, {-2, -1}, {-2, 1}, {-1, 2}} {
+		if is(at(d[0], d[1]), chess.Knight) {
+			return true
+		}
+	}
+	for _, d := range [][2]int{{1, 0}, {-1, 0}, {0, 1}, {0, -1}, {1, 1}, {1, -1}, {-1, 1}, {-1, -1}} {
+		if is(at(d[0], d[1]), chess.King) {
+			return true
+		}
+		slider := []chess.PieceType{chess.Queen, chess.Rook}
+		if d[0] != 0 && d[1] != 0 {
+			slider = []chess.PieceType{chess.Queen, chess.Bishop}
+		}
+		for step := 1; ; step++ {
+			f, r := file+d[0]*step, rank+d[1]*step
+			if f < 0 || f > 7 || r < 0 || r > 7 {
+				break
+			}
+			if p := at(d[0]*step, d[1]*step); p != chess.NoPiece {
+				if is(p, slider...) {
+					return true
+				}
+				break
+			}
+		}
+	}
+	return false
+}
+
+// Inconsistencies re-derives side to move, ply count and terminal status from
+// the stored FEN and describes every stored field that disagrees. Ply count is
+// derived from the fullmove counter, which holds because every game starts
+// from the standard initial position. Draws other than stalemate depend on
+// history, so only checkmate and stalemate are checked.
+func (g *Game) Inconsistencies() []string {
+	fenOpt, err := chess.FEN(g.FEN)
+	if err != nil {
+		return []string{fmt.Sprintf("fen %q does not parse: %v", g.FEN, err)}
+	}
+	pos := chess.NewGame(fenOpt).Position()
+
+	var out []string
+	if side := colorName(pos.Turn()); side != g.SideToMove {
+		out = append(out, fmt.Sprintf("side_to_move is %s, fen says %s", g.SideToMove, side))
+	}
+	ply := 2 * (fullMoveNumber(g.FEN) - 1)
+	if pos.Turn() == chess.Black {
+		ply++
+	}
+	if ply != g.PlyCount {
+		out = append(out, fmt.Sprintf("ply_count is %d, fen says %d", g.PlyCount, ply))
+	}
+
+	switch pos.Status() {
+	case chess.Checkmate:
+		if g.Status != StatusCheckmate {
+			out = append(out, fmt.Sprintf("status is %s, fen is checkmate", g.Status))
+		}
+	case chess.Stalemate:
+		if g.Status != StatusStalemate {
+			out = append(out, fmt.Sprintf("status is %s, fen is stalemate", g.Status))
+		}
+	default:
+		if g.Status == StatusCheckmate || g.Status == StatusStalemate {
+			out = append(out, fmt.Sprintf("status is %s, fen is not terminal", g.Status))
+		}
+	}
+	return out
+}
+
+// fullMoveNumber returns the fullmove counter, the last FEN field.
+func fullMoveNumber(fen string) int {
+	fields := strings.Fields(fen)
+	n, _ := strconv.Atoi(fields[len(fields)-1])
+	return n
+}
@@ -0,0 +1,58 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// SquareChange is a square whose contents differ between two positions.
+// Pieces are FEN letters, uppercase for white; "" is an empty square.
+type SquareChange struct {
+	Square      string
+	BeforePiece string
+	AfterPiece  string
+}
+
+// BoardDiff lists the squares, in a1..h8 order, whose contents differ between
+// fenBefore and fenAfter. A castle yields four changes and an en passant
+// capture three, since the taken pawn leaves a square the mover never
+// touched. Unparsable FENs yield nil.
+func BoardDiff(fenBefore, fenAfter string) []SquareChange {
+	before, err := boardOf(fenBefore)
+	if err != nil {
+		return nil
+	}
+	after, err := boardOf(fenAfter)
+	if err != nil {
+		return nil
+	}
+	var out []SquareChange
+	for sq := chess.A1; sq <= chess.H8; sq++ {
+		b, a := before.Piece(sq), after.Piece(sq)
+		if b != a {
+			out = append(out, SquareChange{Square: sq.String(), BeforePiece: fenLetter(b), AfterPiece: fenLetter(a)})
+		}
+	}
+	return out
+}
+
+func boardOf(fen string) (*chess.Board, error) {
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return nil, err
+	}
+	return chess.NewGame(fenOpt).Position().Board(), nil
+}
+
+// fenLetter writes p as its FEN letter.
+func fenLetter(p chess.Piece) string {
+	if p == chess.NoPiece {
+		return ""
+	}
+	s := p.Type().String()
+	if p.Color() == chess.White {
+		s = strings.ToUpper(s)
+	}
+	return s
+}
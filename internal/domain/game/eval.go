@@ -0,0 +1,80 @@
+package game
+
+import "github.com/notnil/chess"
+
+// MateScore is the evaluation of a decided game, in centipawns.
+const MateScore = 100000
+
+var pieceValues = map[chess.PieceType]int{
+	chess.Pawn:   100,
+	chess.Knight: 320,
+	chess.Bishop: 330,
+	chess.Rook:   500,
+	chess.Queen:  900,
+}
+
+// HeuristicEval returns an approximate static evaluation of the position in
+// centipawns, positive when White is better. It counts material and adds
+// small bonuses for central minor pieces and advanced pawns; there is no
+// search, so tactics are invisible to it. It is meant for spectator displays
+// only. Decided games score ±MateScore and drawn games 0.
+func (g *Game) HeuristicEval() int {
+	if g.Result != nil {
+		switch *g.Result {
+		case ResultWhite:
+			return MateScore
+		case ResultBlack:
+			return -MateScore
+		default:
+			return 0
+		}
+	}
+
+	fenOpt, err := chess.FEN(g.FEN)
+	if err != nil {
+		return 0
+	}
+	board := chess.NewGame(fenOpt).Position().Board()
+
+	score := 0
+	for sq, p := range board.SquareMap() {
+		v := pieceValues[p.Type()] + positionalBonus(sq, p)
+		if p.Color() == chess.White {
+			score += v
+		} else {
+			score -= v
+		}
+	}
+	return score
+}
+
+// positionalBonus is a tiny piece-square term: knights and bishops like the
+// centre, pawns gain value as they advance.
+func positionalBonus(sq chess.Square, p chess.Piece) int {
+	file, rank := relative(sq, p.Color())
+	switch p.Type() {
+	case chess.Knight, chess.Bishop:
+		// 0 on the rim, up to 30 on the four centre squares.
+		return 10 * (3 - max(abs(2*file-7), abs(2*rank-7))/2)
+	case chess.Pawn:
+		// Rank 1 is the pawn's starting rank.
+		return 10 * (rank - 1)
+	}
+	return 0
+}
+
+// IsBlunder reports whether the move from before to after cost the mover more
+// than thresholdCP centipawns of HeuristicEval. It is a crude signal, not
+// engine analysis: the static eval cannot see a piece left hanging, so it
+// mostly flags moves that throw away a won position, such as stalemating the
+// opponent. A threshold of zero or less never flags a move.
+func IsBlunder(before, after *Game, thresholdCP int) bool {
+	if thresholdCP <= 0 {
+		return false
+	}
+	drop := before.HeuristicEval() - after.HeuristicEval()
+	if before.SideToMove == "black" {
+		drop = -drop
+	}
+	return drop > thresholdCP
+}
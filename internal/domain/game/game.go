@@ -2,6 +2,7 @@ package game
 
 import (
 	"errors"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -20,6 +21,20 @@ const (
 	StatusResigned  Status = "resigned"
 )
 
+// Valid reports whether s is one of the contract's status values.
+func (s Status) Valid() bool {
+	switch s {
+	case StatusWaiting, StatusOngoing, StatusCheckmate, StatusStalemate, StatusDraw, StatusResigned:
+		return true
+	}
+	return false
+}
+
+// Finished reports whether s is a terminal status.
+func (s Status) Finished() bool {
+	return s.Valid() && s != StatusWaiting && s != StatusOngoing
+}
+
 // Result values match the contract enum.
 type Result string
 
@@ -29,6 +44,18 @@ const (
 	ResultDraw  Result = "1/2-1/2"
 )
 
+// Valid reports whether r is one of the contract's result values.
+func (r Result) Valid() bool {
+	switch r {
+	case ResultWhite, ResultBlack, ResultDraw:
+		return true
+	}
+	return false
+}
+
+// StartingFEN is the standard initial position every game starts from.
+const StartingFEN = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
 // Sentinel errors returned by ApplyMove; transport layer maps these to HTTP codes.
 var (
 	ErrInvalidUCI     = errors.New("invalid_uci")
@@ -50,30 +77,54 @@ type Game struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 
+	// Title and Tags are operator metadata; they never affect play.
+	Title *string
+	Tags  []string
+
 	// chessGame holds live chess state and is never serialized directly.
 	chessGame *chess.Game
 }
 
 // MoveRecord is the accepted-move detail returned inside SubmitMoveAccepted.
 type MoveRecord struct {
-	ID        uuid.UUID
-	UCI       string
-	FENBefore string
-	FENAfter  string
+	ID          uuid.UUID
+	UCI         string
+	FENBefore   string
+	FENAfter    string
+	IsCapture   bool
+	IsEnPassant bool
+	IsCastle    bool
+	// GaveCheck is true when the move leaves the opponent in check,
+	// including checkmate. It is not persisted.
+	GaveCheck bool
+	// IsBlunder is set by the usecase when IsBlunder flags the move;
+	// ApplyMove leaves it false.
+	IsBlunder bool
 	CreatedAt time.Time
+
+	// UserAgent is analytics metadata attached by the usecase when capture is
+	// enabled; ApplyMove leaves it nil.
+	UserAgent *string
 }
 
 // MoveHistoryItem is one entry in a game's persisted move history.
 type MoveHistoryItem struct {
-	Ply       int
-	UCI       string
-	FromSq    string
-	ToSq      string
-	Promotion *string
-	ClientID  uuid.UUID
-	FENBefore string
-	FENAfter  string
-	CreatedAt time.Time
+	ID          uuid.UUID
+	Ply         int
+	UCI         string
+	FromSq      string
+	ToSq        string
+	Promotion   *string
+	ClientID    uuid.UUID
+	FENBefore   string
+	FENAfter    string
+	IsCapture   bool
+	IsEnPassant bool
+	IsCastle    bool
+	IsBlunder   bool
+	CreatedAt   time.Time
+	// UserAgent is only exposed through admin views.
+	UserAgent *string
 }
 
 // NewGame creates a Game seeded from the standard starting position.
@@ -106,10 +157,14 @@ func fromChessGame(id uuid.UUID, cg *chess.Game, now time.Time) *Game {
 // caller can safely pass the new game to SaveIfVersion while the store still
 // holds the original pointer for CAS comparison.
 //
+// A waiting game accepts moves and becomes ongoing; callers that require a
+// claim first enforce that themselves.
+//
 // Returns:
 //   - ErrGameNotOngoing — game has already ended
 //   - ErrInvalidUCI     — string is not valid UCI syntax
-//   - ErrIllegalMove    — syntactically valid but not legal in this position
+//   - ErrIllegalMove    — syntactically valid but not legal in this position;
+//     ErrWouldBeInCheck when only king safety forbids it
 func (g *Game) ApplyMove(uci string, now time.Time) (*Game, MoveRecord, error) {
 	if g.Status != StatusOngoing && g.Status != StatusWaiting {
 		return nil, MoveRecord{}, ErrGameNotOngoing
@@ -129,6 +184,9 @@ func (g *Game) ApplyMove(uci string, now time.Time) (*Game, MoveRecord, error) {
 	fenBefore := g.FEN
 
 	if err := newCG.MoveStr(uci); err != nil {
+		if leavesKingInCheck(newCG.Position(), uci) {
+			return nil, MoveRecord{}, ErrWouldBeInCheck
+		}
 		return nil, MoveRecord{}, ErrIllegalMove
 	}
 
@@ -147,22 +205,144 @@ func (g *Game) ApplyMove(uci string, now time.Time) (*Game, MoveRecord, error) {
 		StateVersion: g.StateVersion + 1,
 		CreatedAt:    g.CreatedAt,
 		UpdatedAt:    now,
+		Title:        g.Title,
+		Tags:         g.Tags,
 		chessGame:    newCG,
 	}
 	newG.Status, newG.Result = outcomeToStatus(newCG.Outcome(), newCG.Method())
 
-	rec := MoveRecord{
-		ID:        uuid.New(),
-		UCI:       uci,
-		FENBefore: fenBefore,
-		FENAfter:  fenAfter,
-		CreatedAt: now,
-	}
+	rec := lastMoveRecord(newCG, uci, fenBefore, now)
 	return newG, rec, nil
 }
 
+// lastMoveRecord describes uci, the move cg played last, from fenBefore. The last
+// move returned by the library is the validated one, carrying the
+// capture/castle/en passant tags computed from the position. The library does
+// not tag en passant as a capture, so fold it in here.
+func lastMoveRecord(cg *chess.Game, uci, fenBefore string, now time.Time) MoveRecord {
+	moves := cg.Moves()
+	played := moves[len(moves)-1]
+	enPassant := played.HasTag(chess.EnPassant)
+	return MoveRecord{
+		ID:          uuid.New(),
+		UCI:         uci,
+		FENBefore:   fenBefore,
+		FENAfter:    cg.Position().String(),
+		IsCapture:   played.HasTag(chess.Capture) || enPassant,
+		IsEnPassant: enPassant,
+		IsCastle:    played.HasTag(chess.KingSideCastle) || played.HasTag(chess.QueenSideCastle),
+		GaveCheck:   played.HasTag(chess.Check),
+		CreatedAt:   now,
+	}
+}
+
+// MoveGivesCheck reports whether uci would put the opponent in check, without
+// changing g. It fails like ApplyMove for finished games, malformed UCI, and
+// illegal moves.
+func (g *Game) MoveGivesCheck(uci string) (bool, error) {
+	if g.Status != StatusOngoing && g.Status != StatusWaiting {
+		return false, ErrGameNotOngoing
+	}
+	if !isValidUCISyntax(uci) {
+		return false, ErrInvalidUCI
+	}
+
+	// Throwaway game rebuilt from the FEN, as in ApplyMove.
+	fenOpt, err := chess.FEN(g.FEN)
+	if err != nil {
+		return false, ErrIllegalMove
+	}
+	cg := chess.NewGame(fenOpt, chess.UseNotation(chess.UCINotation{}))
+	if err := cg.MoveStr(uci); err != nil {
+		return false, ErrIllegalMove
+	}
+	moves := cg.Moves()
+	return moves[len(moves)-1].HasTag(chess.Check), nil
+}
+
+// ValidateMove plays uci from fen, with no stored game involved, and returns
+// the resulting FEN. It fails with ErrInvalidFEN, ErrIllegalPosition,
+// ErrInvalidUCI, or ErrIllegalMove, which also covers positions that are
+// already over.
+func ValidateMove(fen, uci string) (string, error) {
+	if err := ValidateLegalPosition(fen); err != nil {
+		return "", err
+	}
+	if !isValidUCISyntax(uci) {
+		return "", ErrInvalidUCI
+	}
+	pos, m, err := legalMove(fen, uci)
+	if err != nil {
+		return "", err
+	}
+	return pos.Update(m).String(), nil
+}
+
+// LegalMoves returns the legal moves of the current position in UCI notation,
+// sorted. Games that are no longer in play have none.
+func (g *Game) LegalMoves() ([]string, error) {
+	moves := []string{}
+	if g.Status != StatusOngoing && g.Status != StatusWaiting {
+		return moves, nil
+	}
+	fenOpt, err := chess.FEN(g.FEN)
+	if err != nil {
+		return nil, err
+	}
+	cg := chess.NewGame(fenOpt, chess.UseNotation(chess.UCINotation{}))
+	for _, m := range cg.ValidMoves() {
+		moves = append(moves, m.String())
+	}
+	sort.Strings(moves)
+	return moves, nil
+}
+
+// Draw claim reasons returned by DrawClaimable.
+const (
+	DrawClaimThreefold = "threefold_repetition"
+	DrawClaimFiftyMove = "fifty_move_rule"
+)
+
+// DrawClaimable reports whether the side to move could claim a draw, as
+// opposed to one the rules apply automatically. Repetitions are only counted
+// within history, which should end at the current position; a shorter
+// window may miss a threefold repetition but never reports a false one.
+func (g *Game) DrawClaimable(history []MoveHistoryItem) (bool, string) {
+	if g.Status != StatusOngoing {
+		return false, ""
+	}
+	fen := g.FEN
+	if len(history) > 0 {
+		fen = history[0].FENBefore
+	}
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return false, ""
+	}
+	cg := chess.NewGame(fenOpt, chess.UseNotation(chess.UCINotation{}))
+	for _, item := range history {
+		if err := cg.MoveStr(item.UCI); err != nil {
+			return false, ""
+		}
+	}
+	var fiftyMove bool
+	for _, m := range cg.EligibleDraws() {
+		switch m {
+		case chess.ThreefoldRepetition:
+			return true, DrawClaimThreefold
+		case chess.FiftyMoveRule:
+			fiftyMove = true
+		}
+	}
+	if fiftyMove {
+		return true, DrawClaimFiftyMove
+	}
+	return false, ""
+}
+
 // isValidUCISyntax returns true iff s is valid UCI move notation:
-// [a-h][1-8][a-h][1-8] with an optional promotion piece [qrbn].
+// [a-h][1-8][a-h][1-8] with an optional promotion piece [qrbn], and the two
+// squares differ.
 func isValidUCISyntax(s string) bool {
 	if len(s) < 4 || len(s) > 5 {
 		return false
@@ -179,6 +359,10 @@ func isValidUCISyntax(s string) bool {
 	if s[3] < '1' || s[3] > '8' {
 		return false
 	}
+	// A null move (from == to) is malformed input, not an illegal move.
+	if s[:2] == s[2:4] {
+		return false
+	}
 	if len(s) == 5 {
 		switch s[4] {
 		case 'q', 'r', 'b', 'n':
@@ -0,0 +1,659 @@
+package game_test
+
+import (
+	"errors"
+	"maps"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+)
+
+// gameFromFEN returns an ongoing game positioned at fen.
+func gameFromFEN(t *testing.T, fen string) *game.Game {
+	t.Helper()
+	return &game.Game{
+		ID:     uuid.New(),
+		Status: game.StatusOngoing,
+		FEN:    fen,
+	}
+}
+
+func TestApplyMove_WouldBeInCheck(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		uci  string
+		want error
+	}{
+		{"pinned knight", "4r1k1/8/8/8/8/8/4N3/4K3 w - - 0 1", "e2c3", game.ErrWouldBeInCheck},
+		{"king steps into attack", "4k3/8/8/8/8/8/r7/4K3 w - - 0 1", "e1e2", game.ErrWouldBeInCheck},
+		{"en passant uncovers the king", "8/8/8/K2Pp2r/8/8/8/7k w - e6 0 1", "d5e6", game.ErrWouldBeInCheck},
+		{"black pinned bishop", "4k3/8/2b5/8/Q7/8/8/4K3 b - - 0 1", "c6e4", game.ErrWouldBeInCheck},
+		{"piece cannot move that way", "4r1k1/8/8/8/8/8/4N3/4K3 w - - 0 1", "e2e5", game.ErrIllegalMove},
+		{"castling through check", "4k3/8/8/8/8/8/5r2/4K2R w K - 0 1", "e1g1", game.ErrIllegalMove},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := gameFromFEN(t, tt.fen)
+			_, _, err := g.ApplyMove(tt.uci, time.Now())
+			if !errors.Is(err, game.ErrIllegalMove) {
+				t.Fatalf("want an illegal move, got %v", err)
+			}
+			if got := errors.Is(err, game.ErrWouldBeInCheck); got != (tt.want == game.ErrWouldBeInCheck) {
+				t.Fatalf("got %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyMove_Castle(t *testing.T) {
+	g := gameFromFEN(t, "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1")
+
+	_, rec, err := g.ApplyMove("e1g1", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !rec.IsCastle {
+		t.Error("expected is_castle for e1g1")
+	}
+	if rec.IsCapture || rec.IsEnPassant {
+		t.Errorf("castle must not be a capture: %+v", rec)
+	}
+}
+
+func TestApplyMove_EnPassant(t *testing.T) {
+	g := gameFromFEN(t, "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3")
+
+	_, rec, err := g.ApplyMove("e5f6", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !rec.IsEnPassant {
+		t.Error("expected is_en_passant for e5f6")
+	}
+	if !rec.IsCapture {
+		t.Error("en passant must also be a capture")
+	}
+	if rec.IsCastle {
+		t.Error("en passant must not be a castle")
+	}
+}
+
+func TestApplyMove_NormalCapture(t *testing.T) {
+	g := gameFromFEN(t, "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2")
+
+	_, rec, err := g.ApplyMove("e4d5", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if !rec.IsCapture {
+		t.Error("expected is_capture for e4d5")
+	}
+	if rec.IsEnPassant || rec.IsCastle {
+		t.Errorf("plain capture flagged as special: %+v", rec)
+	}
+}
+
+func TestApplyMove_GaveCheck(t *testing.T) {
+	cases := []struct {
+		name string
+		fen  string
+		uci  string
+		want bool
+	}{
+		{"check", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a8", true},
+		{"checkmate", "rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", true},
+		{"quiet", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", "a1a2", false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, rec, err := gameFromFEN(t, tc.fen).ApplyMove(tc.uci, time.Now())
+			if err != nil {
+				t.Fatalf("apply: %v", err)
+			}
+			if rec.GaveCheck != tc.want {
+				t.Fatalf("GaveCheck = %v, want %v", rec.GaveCheck, tc.want)
+			}
+		})
+	}
+}
+
+func TestApplyMove_QuietMove(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Now())
+
+	_, rec, err := g.ApplyMove("e2e4", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if rec.IsCapture || rec.IsEnPassant || rec.IsCastle {
+		t.Errorf("quiet move flagged as special: %+v", rec)
+	}
+}
+
+func TestLegalMoves_InitialPosition(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Now())
+
+	moves, err := g.LegalMoves()
+	if err != nil {
+		t.Fatalf("LegalMoves: %v", err)
+	}
+	if len(moves) != 20 {
+		t.Fatalf("got %d legal moves, want 20", len(moves))
+	}
+	if moves[0] != "a2a3" {
+		t.Errorf("moves not sorted: first is %q", moves[0])
+	}
+}
+
+func TestLegalMoves_FinishedGame(t *testing.T) {
+	// Fool's mate: white is checkmated.
+	g := gameFromFEN(t, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	g.Status = game.StatusCheckmate
+
+	moves, err := g.LegalMoves()
+	if err != nil {
+		t.Fatalf("LegalMoves: %v", err)
+	}
+	if moves == nil || len(moves) != 0 {
+		t.Fatalf("want empty non-nil slice, got %#v", moves)
+	}
+}
+
+func TestHeuristicEval(t *testing.T) {
+	if eval := game.NewGame(uuid.New(), time.Now()).HeuristicEval(); eval != 0 {
+		t.Errorf("initial position: got %d, want 0", eval)
+	}
+
+	// White is up a queen.
+	upQueen := gameFromFEN(t, "rnb1kbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1")
+	if eval := upQueen.HeuristicEval(); eval < 800 {
+		t.Errorf("white up a queen: got %d, want a large positive score", eval)
+	}
+
+	// Black is up a rook.
+	upRook := gameFromFEN(t, "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/1NBQKBNR w Kkq - 0 1")
+	if eval := upRook.HeuristicEval(); eval > -400 {
+		t.Errorf("black up a rook: got %d, want a large negative score", eval)
+	}
+
+	mated := gameFromFEN(t, "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3")
+	black := game.ResultBlack
+	mated.Status, mated.Result = game.StatusCheckmate, &black
+	if eval := mated.HeuristicEval(); eval != -game.MateScore {
+		t.Errorf("black won: got %d, want %d", eval, -game.MateScore)
+	}
+}
+
+func TestIsBlunder(t *testing.T) {
+	tests := []struct {
+		name string
+		fen  string
+		side string
+		uci  string
+		want bool
+	}{
+		{"quiet queen move", "7k/4Q3/6K1/8/8/8/8/8 w - - 0 1", "white", "e7d7", false},
+		{"white stalemates a lone king", "7k/4Q3/6K1/8/8/8/8/8 w - - 0 1", "white", "e7f7", true},
+		{"black stalemates a lone king", "8/8/8/8/8/6k1/4q3/7K b - - 0 1", "black", "e2f2", true},
+		{"mate is never a blunder", "7k/4Q3/6K1/8/8/8/8/8 w - - 0 1", "white", "e7e8", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := gameFromFEN(t, tt.fen)
+			before.SideToMove = tt.side
+			after, _, err := before.ApplyMove(tt.uci, time.Now())
+			if err != nil {
+				t.Fatalf("ApplyMove(%s): %v", tt.uci, err)
+			}
+			if got := game.IsBlunder(before, after, 300); got != tt.want {
+				t.Errorf("IsBlunder = %v, want %v (eval %d -> %d)", got, tt.want, before.HeuristicEval(), after.HeuristicEval())
+			}
+			if game.IsBlunder(before, after, 0) {
+				t.Error("a zero threshold must never flag a move")
+			}
+		})
+	}
+}
+
+func TestApplyMoveSequence(t *testing.T) {
+	start := "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+
+	// Fool's mate.
+	g, recs, err := game.ApplyMoveSequence(start, []string{"f2f3", "e7e5", "g2g4", "d8h4"}, time.Now())
+	if err != nil {
+		t.Fatalf("fool's mate: %v", err)
+	}
+	if g.Status != game.StatusCheckmate || g.Result == nil || *g.Result != game.ResultBlack {
+		t.Fatalf("want black checkmate, got %s %v", g.Status, g.Result)
+	}
+	if g.PlyCount != 4 || len(recs) != 4 || g.LastMoveUCI == nil || *g.LastMoveUCI != "d8h4" {
+		t.Fatalf("want 4 plies ending d8h4, got %d plies, %d records, last %v", g.PlyCount, len(recs), g.LastMoveUCI)
+	}
+	for i := 1; i < len(recs); i++ {
+		if recs[i].FENBefore != recs[i-1].FENAfter {
+			t.Fatalf("record %d does not continue from record %d", i, i-1)
+		}
+	}
+	if recs[0].FENBefore != start || recs[3].FENAfter != g.FEN || !recs[3].GaveCheck {
+		t.Fatalf("records do not span the game: %+v", recs)
+	}
+
+	tests := []struct {
+		name  string
+		ucis  []string
+		index int
+		want  error
+	}{
+		{"illegal move", []string{"e2e4", "e7e5", "e4e5"}, 2, game.ErrIllegalMove},
+		{"malformed move", []string{"e2e4", "xx"}, 1, game.ErrInvalidUCI},
+		{"move after mate", []string{"f2f3", "e7e5", "g2g4", "d8h4", "a2a3"}, 4, game.ErrGameNotOngoing},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, recs, err := game.ApplyMoveSequence(start, tt.ucis, time.Now())
+			var seqErr *game.MoveSequenceError
+			if !errors.As(err, &seqErr) || seqErr.Index != tt.index || !errors.Is(err, tt.want) {
+				t.Fatalf("want %v at move %d, got %v", tt.want, tt.index, err)
+			}
+			if g != nil || recs != nil {
+				t.Fatal("a failed sequence must return no game or records")
+			}
+		})
+	}
+
+	if _, _, err := game.ApplyMoveSequence("not a fen", nil, time.Now()); !errors.Is(err, game.ErrInvalidFEN) {
+		t.Fatalf("bad start: want ErrInvalidFEN, got %v", err)
+	}
+}
+
+func TestMoveGivesCheck(t *testing.T) {
+	// After 1.e4 d6 the bishop checks from b5.
+	g := gameFromFEN(t, "rnbqkbnr/ppp1pppp/3p4/8/4P3/8/PPPP1PPP/RNBQKBNR w KQkq - 0 2")
+	fenBefore := g.FEN
+
+	check, err := g.MoveGivesCheck("f1b5")
+	if err != nil {
+		t.Fatalf("f1b5: %v", err)
+	}
+	if !check {
+		t.Error("f1b5 should give check")
+	}
+
+	check, err = g.MoveGivesCheck("g1f3")
+	if err != nil {
+		t.Fatalf("g1f3: %v", err)
+	}
+	if check {
+		t.Error("g1f3 should not give check")
+	}
+
+	if _, err := g.MoveGivesCheck("e4e6"); !errors.Is(err, game.ErrIllegalMove) {
+		t.Errorf("illegal move: want ErrIllegalMove, got %v", err)
+	}
+	if g.FEN != fenBefore {
+		t.Error("MoveGivesCheck must not modify the game")
+	}
+}
+
+func TestApplyMove_NullMoveIsInvalidUCI(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Now())
+
+	if _, _, err := g.ApplyMove("e2e2", time.Now()); !errors.Is(err, game.ErrInvalidUCI) {
+		t.Fatalf("e2e2: want ErrInvalidUCI, got %v", err)
+	}
+}
+
+func TestSAN(t *testing.T) {
+	cases := []struct {
+		fen, uci, want string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", "Nf3"},
+		{"r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1", "e1c1", "O-O-O"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", "Qh4#"},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e8=Q"},
+	}
+	for _, tc := range cases {
+		got, err := game.SAN(tc.fen, tc.uci)
+		if err != nil {
+			t.Fatalf("SAN(%s): %v", tc.uci, err)
+		}
+		if got != tc.want {
+			t.Errorf("SAN(%s) = %q, want %q", tc.uci, got, tc.want)
+		}
+	}
+}
+
+// TestSAN_Disambiguation covers positions where more than one piece of the
+// same kind can reach the target square.
+func TestSAN_Disambiguation(t *testing.T) {
+	cases := []struct {
+		fen, uci, want string
+	}{
+		// Knights on b1 and f3 both reach d2: the file tells them apart.
+		{"4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1", "b1d2", "Nbd2"},
+		{"4k3/8/8/8/8/5N2/8/1N2K3 w - - 0 1", "f3d2", "Nfd2"},
+		// Rooks on a1 and a5 share a file, so the rank is used, also on a capture.
+		{"4k3/8/8/R7/8/p7/8/R3K3 w - - 0 1", "a1a3", "R1xa3"},
+		{"4k3/8/8/R7/8/p7/8/R3K3 w - - 0 1", "a5a3", "R5xa3"},
+		// Queens on a1, a3 and c1 all reach b2: only the a1 queen needs both
+		// coordinates, since it shares a file with one and a rank with the other.
+		{"8/8/6k1/8/8/Q7/8/Q1Q4K w - - 0 1", "a1b2", "Qa1b2"},
+		{"8/8/6k1/8/8/Q7/8/Q1Q4K w - - 0 1", "a3b2", "Q3b2"},
+		{"8/8/6k1/8/8/Q7/8/Q1Q4K w - - 0 1", "c1b2", "Qcb2"},
+		// The e2 knight is pinned, so b1 is the only knight that can reach c3.
+		{"4k3/4r3/8/8/8/8/4N3/1N2K3 w - - 0 1", "b1c3", "Nc3"},
+	}
+	for _, tc := range cases {
+		got, err := game.SAN(tc.fen, tc.uci)
+		if err != nil {
+			t.Fatalf("SAN(%s, %s): %v", tc.fen, tc.uci, err)
+		}
+		if got != tc.want {
+			t.Errorf("SAN(%s, %s) = %q, want %q", tc.fen, tc.uci, got, tc.want)
+		}
+	}
+}
+
+func TestLAN(t *testing.T) {
+	cases := []struct {
+		fen, uci, want string
+	}{
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "e2e4", "e2-e4"},
+		{"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", "g1f3", "Ng1-f3"},
+		{"rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2", "e4d5", "e4xd5"},
+		{"r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1", "e1g1", "O-O"},
+		{"rnbqkbnr/pppp1ppp/8/4p3/6P1/5P2/PPPPP2P/RNBQKBNR b KQkq - 0 2", "d8h4", "Qd8-h4#"},
+		{"8/4P1k1/8/8/8/8/8/4K3 w - - 0 1", "e7e8q", "e7-e8=Q"},
+	}
+	for _, tc := range cases {
+		got, err := game.LAN(tc.fen, tc.uci)
+		if err != nil {
+			t.Fatalf("LAN(%s): %v", tc.uci, err)
+		}
+		if got != tc.want {
+			t.Errorf("LAN(%s) = %q, want %q", tc.uci, got, tc.want)
+		}
+	}
+}
+
+func TestParseNotations(t *testing.T) {
+	got, err := game.ParseNotations("san, LAN,san")
+	if err != nil {
+		t.Fatalf("parse: %v", err)
+	}
+	if len(got) != 2 || got[0] != game.NotationSAN || got[1] != game.NotationLAN {
+		t.Fatalf("got %v", got)
+	}
+	if got, _ := game.ParseNotations(""); len(got) != 1 || got[0] != game.NotationUCI {
+		t.Fatalf("empty: got %v", got)
+	}
+	if _, err := game.ParseNotations("uci,fan"); !errors.Is(err, game.ErrUnknownNotation) {
+		t.Fatalf("want ErrUnknownNotation, got %v", err)
+	}
+}
+
+func TestInconsistencies(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Now())
+	g, _, err := g.ApplyMove("e2e4", time.Now())
+	if err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+	if got := g.Inconsistencies(); len(got) != 0 {
+		t.Fatalf("fresh game: unexpected inconsistencies %v", got)
+	}
+
+	bad := *g
+	bad.SideToMove = "white"
+	bad.PlyCount = 4
+	bad.Status = game.StatusCheckmate
+	if got := bad.Inconsistencies(); len(got) != 3 {
+		t.Fatalf("want 3 inconsistencies, got %v", got)
+	}
+
+	if err := game.ValidateFEN("not a fen"); !errors.Is(err, game.ErrInvalidFEN) {
+		t.Fatalf("ValidateFEN: want ErrInvalidFEN, got %v", err)
+	}
+}
+
+func TestPGN(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Date(2026, 3, 4, 0, 0, 0, 0, time.UTC))
+	var history []game.MoveHistoryItem
+	for i, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		next, rec, err := g.ApplyMove(uci, time.Now())
+		if err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+		history = append(history, game.MoveHistoryItem{Ply: i, UCI: rec.UCI})
+		g = next
+	}
+
+	pgn, err := g.PGN(history)
+	if err != nil {
+		t.Fatalf("PGN: %v", err)
+	}
+	for _, want := range []string{`[Date "2026.03.04"]`, `[Result "*"]`, "1. e4 e5", "2. Nf3"} {
+		if !strings.Contains(pgn, want) {
+			t.Errorf("PGN missing %q:\n%s", want, pgn)
+		}
+	}
+}
+
+func TestDrawClaimable(t *testing.T) {
+	g := game.NewGame(uuid.New(), time.Now())
+	var history []game.MoveHistoryItem
+	for i, uci := range []string{"g1f3", "g8f6", "f3g1", "f6g8", "g1f3", "g8f6", "f3g1", "f6g8"} {
+		if ok, _ := g.DrawClaimable(history); ok {
+			t.Fatalf("claimable after %d plies", i)
+		}
+		next, rec, err := g.ApplyMove(uci, time.Now())
+		if err != nil {
+			t.Fatalf("apply %s: %v", uci, err)
+		}
+		history = append(history, game.MoveHistoryItem{Ply: i, UCI: rec.UCI, FENBefore: rec.FENBefore})
+		g = next
+	}
+	if ok, reason := g.DrawClaimable(history); !ok || reason != game.DrawClaimThreefold {
+		t.Fatalf("after repetition: got %v %q", ok, reason)
+	}
+
+	quiet := *game.NewGame(uuid.New(), time.Now())
+	quiet.Status = game.StatusOngoing
+	quiet.FEN = "8/8/4k3/8/8/4K3/8/R7 w - - 100 80"
+	if ok, reason := quiet.DrawClaimable(nil); !ok || reason != game.DrawClaimFiftyMove {
+		t.Fatalf("fifty-move: got %v %q", ok, reason)
+	}
+}
+
+func TestBoardDiff(t *testing.T) {
+	cases := []struct {
+		name, fen, uci string
+		want           []game.SquareChange
+	}{
+		{
+			name: "castle",
+			fen:  "r3k2r/pppppppp/8/8/8/8/PPPPPPPP/R3K2R w KQkq - 0 1",
+			uci:  "e1g1",
+			want: []game.SquareChange{
+				{Square: "e1", BeforePiece: "K"},
+				{Square: "f1", AfterPiece: "R"},
+				{Square: "g1", AfterPiece: "K"},
+				{Square: "h1", BeforePiece: "R"},
+			},
+		},
+		{
+			name: "en passant",
+			fen:  "rnbqkbnr/ppp1p1pp/8/3pPp2/8/8/PPPP1PPP/RNBQKBNR w KQkq f6 0 3",
+			uci:  "e5f6",
+			want: []game.SquareChange{
+				{Square: "e5", BeforePiece: "P"},
+				{Square: "f5", BeforePiece: "p"},
+				{Square: "f6", AfterPiece: "P"},
+			},
+		},
+		{
+			name: "capture",
+			fen:  "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2",
+			uci:  "e4d5",
+			want: []game.SquareChange{
+				{Square: "e4", BeforePiece: "P"},
+				{Square: "d5", BeforePiece: "p", AfterPiece: "P"},
+			},
+		},
+	}
+	for _, tc := range cases {
+		g := gameFromFEN(t, tc.fen)
+		next, _, err := g.ApplyMove(tc.uci, time.Now())
+		if err != nil {
+			t.Fatalf("%s: apply: %v", tc.name, err)
+		}
+		got := game.BoardDiff(tc.fen, next.FEN)
+		if !slices.Equal(got, tc.want) {
+			t.Errorf("%s: BoardDiff = %+v, want %+v", tc.name, got, tc.want)
+		}
+	}
+
+	if got := game.BoardDiff("not a fen", "8/8/8/8/8/8/8/8 w - - 0 1"); got != nil {
+		t.Errorf("invalid FEN: want nil, got %+v", got)
+	}
+}
+
+func TestValidateMove(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	fen, err := game.ValidateMove(start, "e2e4")
+	if err != nil {
+		t.Fatalf("e2e4: %v", err)
+	}
+	if want := "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"; fen != want {
+		t.Errorf("e2e4: got %q, want %q", fen, want)
+	}
+
+	cases := []struct {
+		fen, uci string
+		want     error
+	}{
+		{start, "e2e5", game.ErrIllegalMove},
+		{start, "e2", game.ErrInvalidUCI},
+		{"not a fen", "e2e4", game.ErrInvalidFEN},
+		// Fool's mate: nothing is legal once the game is over.
+		{"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", "a2a3", game.ErrIllegalMove},
+	}
+	for _, tc := range cases {
+		if _, err := game.ValidateMove(tc.fen, tc.uci); !errors.Is(err, tc.want) {
+			t.Errorf("ValidateMove(%q, %q) = %v, want %v", tc.fen, tc.uci, err, tc.want)
+		}
+	}
+}
+
+func TestValidateLegalPosition(t *testing.T) {
+	legal := []string{
+		"rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1",
+		// The side to move may be in check.
+		"rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3",
+		// A blocked rook gives no check.
+		"4k3/4p3/8/8/8/8/8/3KR3 w - - 0 1",
+	}
+	for _, fen := range legal {
+		if err := game.ValidateLegalPosition(fen); err != nil {
+			t.Errorf("%s: %v", fen, err)
+		}
+	}
+
+	cases := []struct {
+		name, fen, rule string
+	}{
+		{"two white kings", "4k3/8/8/8/8/8/8/K3K3 w - - 0 1", "white has 2 kings"},
+		{"no black king", "8/8/8/8/8/8/8/4K3 w - - 0 1", "black has 0 kings"},
+		{"pawn on rank 1", "4k3/8/8/8/8/8/8/P3K3 w - - 0 1", "white pawn on a1"},
+		{"pawn on rank 8", "p3k3/8/8/8/8/8/8/4K3 w - - 0 1", "black pawn on a8"},
+		{"opponent in check by rook", "4k3/8/8/8/8/8/8/3KR3 w - - 0 1", "black is in check with white to move"},
+		{"opponent in check by knight", "4k3/8/5N2/8/8/8/8/4K3 w - - 0 1", "black is in check with white to move"},
+		{"opponent in check by pawn", "8/8/8/8/8/8/3p4/4K2k b - - 0 1", "white is in check with black to move"},
+	}
+	for _, tc := range cases {
+		err := game.ValidateLegalPosition(tc.fen)
+		if !errors.Is(err, game.ErrIllegalPosition) || !strings.Contains(err.Error(), tc.rule) {
+			t.Errorf("%s: got %v, want %q", tc.name, err, tc.rule)
+		}
+	}
+}
+
+func TestPhase(t *testing.T) {
+	cases := []struct {
+		name, fen string
+		ply       int
+		want      string
+	}{
+		{"start", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", 0, game.PhaseOpening},
+		{"full board after move 10", "r1bqkb1r/pppp1ppp/2n2n2/4p3/2B1P3/5N2/PPPP1PPP/RNBQK2R w KQkq - 4 11", 20, game.PhaseMiddlegame},
+		{"queens off, rooks and pawns", "r3k3/ppp2ppp/8/8/8/8/PPP2PPP/R3K3 w - - 0 25", 48, game.PhaseEndgame},
+		{"king and rook", "4k3/8/8/8/8/8/8/R3K3 w - - 0 1", 0, game.PhaseEndgame},
+	}
+	for _, tc := range cases {
+		g := gameFromFEN(t, tc.fen)
+		g.PlyCount = tc.ply
+		if got := g.Phase(); got != tc.want {
+			t.Errorf("%s: Phase() = %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+// TestRules_AutomaticDraws checks the automatic draws Rules reports.
+func TestRules_AutomaticDraws(t *testing.T) {
+	rules := game.Rules()
+	cases := []struct {
+		name, fen, uci string
+		auto           bool
+	}{
+		{"insufficient material", "4k3/8/8/8/8/8/4r3/4K3 w - - 0 1", "e1e2", rules.AutoInsufficientMaterial},
+		{"seventy-five move rule", "4k3/8/8/8/8/8/8/R3K3 w - - 149 120", "a1a2", rules.AutoSeventyFiveMove},
+		{"fifty move rule", "4k3/8/8/8/8/8/8/R3K3 w - - 99 120", "a1a2", false},
+	}
+	for _, tc := range cases {
+		g, _, err := gameFromFEN(t, tc.fen).ApplyMove(tc.uci, time.Now())
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if drawn := g.Status == game.StatusDraw; drawn != tc.auto {
+			t.Errorf("%s: drawn = %v, want %v", tc.name, drawn, tc.auto)
+		}
+	}
+}
+
+func TestPieceCounts(t *testing.T) {
+	start := game.NewGame(uuid.New(), time.Now()).PieceCounts()
+	want := map[string]int{
+		"wK": 1, "wQ": 1, "wR": 2, "wB": 2, "wN": 2, "wP": 8,
+		"bK": 1, "bQ": 1, "bR": 2, "bB": 2, "bN": 2, "bP": 8,
+	}
+	if !maps.Equal(start, want) {
+		t.Fatalf("start: got %v, want %v", start, want)
+	}
+
+	// exd5 takes a pawn.
+	g, _, err := gameFromFEN(t, "rnbqkbnr/ppp1pppp/8/3p4/4P3/8/PPPP1PPP/RNBQKBNR w KQkq d6 0 2").ApplyMove("e4d5", time.Now())
+	if err != nil {
+		t.Fatalf("capture: %v", err)
+	}
+	if got := g.PieceCounts(); got["wP"] != 8 || got["bP"] != 7 {
+		t.Fatalf("after capture: wP %d, bP %d; want 8, 7", got["wP"], got["bP"])
+	}
+
+	// Underpromoting by capture removes the pawn and the rook, adds a knight.
+	g, _, err = gameFromFEN(t, "3r2k1/4P3/8/8/8/8/8/4K3 w - - 0 1").ApplyMove("e7d8n", time.Now())
+	if err != nil {
+		t.Fatalf("promotion: %v", err)
+	}
+	got := g.PieceCounts()
+	if got["wP"] != 0 || got["wN"] != 1 || got["bR"] != 0 || got["wQ"] != 0 {
+		t.Fatalf("after promotion: %v", got)
+	}
+
+	if gameFromFEN(t, "not a fen").PieceCounts() != nil {
+		t.Fatal("unparsable FEN: want nil")
+	}
+}
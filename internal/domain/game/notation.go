@@ -0,0 +1,114 @@
+package game
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// Notation names a way of writing a move.
+type Notation string
+
+const (
+	NotationUCI Notation = "uci" // coordinate form, e.g. "g1f3"
+	NotationSAN Notation = "san" // standard algebraic, e.g. "Nf3"
+	NotationLAN Notation = "lan" // long algebraic, e.g. "Ng1-f3"
+)
+
+var ErrUnknownNotation = errors.New("unknown_notation")
+
+// ParseNotations parses a comma-separated list such as "uci,san". Duplicates
+// are dropped; an empty list yields just NotationUCI.
+func ParseNotations(s string) ([]Notation, error) {
+	var out []Notation
+	seen := make(map[Notation]bool)
+	for _, part := range strings.Split(s, ",") {
+		n := Notation(strings.ToLower(strings.TrimSpace(part)))
+		if n == "" {
+			continue
+		}
+		switch n {
+		case NotationUCI, NotationSAN, NotationLAN:
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnknownNotation, n)
+		}
+		if !seen[n] {
+			seen[n] = true
+			out = append(out, n)
+		}
+	}
+	if len(out) == 0 {
+		out = []Notation{NotationUCI}
+	}
+	return out, nil
+}
+
+// Notate writes uci, played from fenBefore, in notation n.
+func Notate(fenBefore, uci string, n Notation) (string, error) {
+	switch n {
+	case NotationUCI:
+		return uci, nil
+	case NotationSAN:
+		return SAN(fenBefore, uci)
+	case NotationLAN:
+		return LAN(fenBefore, uci)
+	}
+	return "", ErrUnknownNotation
+}
+
+// LAN converts uci, played from fenBefore, to long algebraic notation: the
+// piece letter, both squares joined by "-" or "x", then any promotion and
+// check suffix. Castling is written "O-O" / "O-O-O" as in SAN.
+func LAN(fenBefore, uci string) (string, error) {
+	pos, m, err := legalMove(fenBefore, uci)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	switch {
+	case m.HasTag(chess.KingSideCastle):
+		b.WriteString("O-O")
+	case m.HasTag(chess.QueenSideCastle):
+		b.WriteString("O-O-O")
+	default:
+		if p := pos.Board().Piece(m.S1()).Type(); p != chess.Pawn {
+			b.WriteString(strings.ToUpper(p.String()))
+		}
+		b.WriteString(m.S1().String())
+		if m.HasTag(chess.Capture) || m.HasTag(chess.EnPassant) {
+			b.WriteByte('x')
+		} else {
+			b.WriteByte('-')
+		}
+		b.WriteString(m.S2().String())
+		if m.Promo() != chess.NoPieceType {
+			b.WriteString("=" + strings.ToUpper(m.Promo().String()))
+		}
+	}
+	if m.HasTag(chess.Check) {
+		if next := pos.Update(m); next.Status() == chess.Checkmate {
+			b.WriteByte('#')
+		} else {
+			b.WriteByte('+')
+		}
+	}
+	return b.String(), nil
+}
+
+// legalMove finds uci among the legal moves from fenBefore. Moves from
+// ValidMoves carry the capture, castle and check tags the encoders need.
+func legalMove(fenBefore, uci string) (*chess.Position, *chess.Move, error) {
+	fenOpt, err := chess.FEN(fenBefore)
+	if err != nil {
+		return nil, nil, err
+	}
+	pos := chess.NewGame(fenOpt).Position()
+	for _, m := range pos.ValidMoves() {
+		if m.String() == uci {
+			return pos, m, nil
+		}
+	}
+	return nil, nil, ErrIllegalMove
+}
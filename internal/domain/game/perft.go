@@ -0,0 +1,38 @@
+package game
+
+import (
+	"errors"
+
+	"github.com/notnil/chess"
+)
+
+var ErrInvalidDepth = errors.New("invalid_depth")
+
+// Perft counts the leaf nodes of the legal move tree of depth plies from fen.
+// Depth 0 counts the position itself. The cost grows exponentially with
+// depth, so callers should cap it.
+func Perft(fen string, depth int) (uint64, error) {
+	if depth < 0 {
+		return 0, ErrInvalidDepth
+	}
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return 0, ErrInvalidFEN
+	}
+	return perft(chess.NewGame(fenOpt).Position(), depth), nil
+}
+
+func perft(pos *chess.Position, depth int) uint64 {
+	if depth == 0 {
+		return 1
+	}
+	moves := pos.ValidMoves()
+	if depth == 1 {
+		return uint64(len(moves))
+	}
+	var n uint64
+	for _, m := range moves {
+		n += perft(pos.Update(m), depth-1)
+	}
+	return n
+}
@@ -0,0 +1,39 @@
+package game_test
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+)
+
+func TestPerft(t *testing.T) {
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	// "Kiwipete", which exercises castling, en passant and promotions.
+	const kiwipete = "r3k2r/p1ppqpb1/bn2pnp1/3PN3/1p2P3/2N2Q1p/PPPBBPPP/R3K2R w KQkq - 0 1"
+	cases := []struct {
+		fen   string
+		depth int
+		want  uint64
+	}{
+		{start, 0, 1},
+		{start, 1, 20},
+		{start, 2, 400},
+		{start, 3, 8902},
+		{kiwipete, 1, 48},
+		{kiwipete, 2, 2039},
+	}
+	for _, tc := range cases {
+		got, err := game.Perft(tc.fen, tc.depth)
+		if err != nil {
+			t.Fatalf("Perft(depth %d): %v", tc.depth, err)
+		}
+		if got != tc.want {
+			t.Errorf("Perft(%s, %d) = %d, want %d", tc.fen, tc.depth, got, tc.want)
+		}
+	}
+
+	if _, err := game.Perft(start, -1); !errors.Is(err, game.ErrInvalidDepth) {
+		t.Fatalf("negative depth: want ErrInvalidDepth, got %v", err)
+	}
+}
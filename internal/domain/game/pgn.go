@@ -0,0 +1,43 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+// PGN renders the game in Portable Game Notation by replaying history from
+// the standard initial position, which every game starts from.
+func (g *Game) PGN(history []MoveHistoryItem) (string, error) {
+	cg := chess.NewGame(chess.UseNotation(chess.UCINotation{}))
+	for _, item := range history {
+		if err := cg.MoveStr(item.UCI); err != nil {
+			return "", fmt.Errorf("replay ply %d (%s): %w", item.Ply, item.UCI, err)
+		}
+	}
+	// Replay in UCI, export in SAN.
+	chess.UseNotation(chess.AlgebraicNotation{})(cg)
+
+	result := "*"
+	if g.Result != nil {
+		result = string(*g.Result)
+	}
+	cg.AddTagPair("Event", "Random Chess")
+	cg.AddTagPair("Site", "https://chess.randomtoy.dev")
+	cg.AddTagPair("Date", g.CreatedAt.UTC().Format("2006.01.02"))
+	cg.AddTagPair("White", "?")
+	cg.AddTagPair("Black", "?")
+	cg.AddTagPair("Result", result)
+	cg.AddTagPair("GameId", g.ID.String())
+	return cg.String(), nil
+}
+
+// SAN converts uci, played from fenBefore, to Standard Algebraic Notation.
+// It returns ErrIllegalMove if uci is not legal in that position.
+func SAN(fenBefore, uci string) (string, error) {
+	pos, m, err := legalMove(fenBefore, uci)
+	if err != nil {
+		return "", err
+	}
+	return chess.AlgebraicNotation{}.Encode(pos, m), nil
+}
@@ -0,0 +1,56 @@
+package game
+
+import "github.com/notnil/chess"
+
+// Game phases returned by Phase.
+const (
+	PhaseOpening    = "opening"
+	PhaseMiddlegame = "middlegame"
+	PhaseEndgame    = "endgame"
+)
+
+// Phase thresholds, in plies and in points of non-king material for both
+// sides together (pawn 1, knight and bishop 3, rook 5, queen 9; 78 at the
+// start).
+const (
+	openingMaxPly        = 20 // the first ten moves
+	openingMinMaterial   = 62 // at most a few minor pieces' worth traded
+	endgameMaxMaterial   = 13
+	queenlessMaxMaterial = 26
+)
+
+var phasePoints = map[chess.PieceType]int{
+	chess.Pawn:   1,
+	chess.Knight: 3,
+	chess.Bishop: 3,
+	chess.Rook:   5,
+	chess.Queen:  9,
+}
+
+// Phase classifies the position by material and move count:
+//   - endgame: at most 13 points of material left, or the queens are off
+//     and at most 26 points remain
+//   - opening: fewer than ten moves played with at least 62 points left
+//   - middlegame: everything else
+//
+// Unparsable positions count as the opening.
+func (g *Game) Phase() string {
+	fenOpt, err := chess.FEN(g.FEN)
+	if err != nil {
+		return PhaseOpening
+	}
+	material, queens := 0, 0
+	for _, p := range chess.NewGame(fenOpt).Position().Board().SquareMap() {
+		material += phasePoints[p.Type()]
+		if p.Type() == chess.Queen {
+			queens++
+		}
+	}
+	switch {
+	case material <= endgameMaxMaterial, queens == 0 && material <= queenlessMaxMaterial:
+		return PhaseEndgame
+	case g.PlyCount < openingMaxPly && material >= openingMinMaterial:
+		return PhaseOpening
+	}
+	return PhaseMiddlegame
+}
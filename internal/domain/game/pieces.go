@@ -0,0 +1,32 @@
+package game
+
+import (
+	"strings"
+
+	"github.com/notnil/chess"
+)
+
+// PieceCounts returns how many of each piece are on the board, keyed by
+// colour and piece letter ("wP", "bK", ...). All twelve keys are present, so
+// pieces that are gone count 0. Unparsable positions yield nil.
+func (g *Game) PieceCounts() map[string]int {
+	board, err := boardOf(g.FEN)
+	if err != nil {
+		return nil
+	}
+	counts := make(map[string]int, 12)
+	for _, c := range []chess.Color{chess.White, chess.Black} {
+		for _, pt := range []chess.PieceType{chess.King, chess.Queen, chess.Rook, chess.Bishop, chess.Knight, chess.Pawn} {
+			counts[pieceKey(chess.NewPiece(pt, c))] = 0
+		}
+	}
+	for _, p := range board.SquareMap() {
+		counts[pieceKey(p)]++
+	}
+	return counts
+}
+
+// pieceKey writes p as "w" or "b" followed by its uppercase piece letter.
+func pieceKey(p chess.Piece) string {
+	return p.Color().String() + strings.ToUpper(p.Type().String())
+}
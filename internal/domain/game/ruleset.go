@@ -0,0 +1,32 @@
+package game
+
+// Ruleset describes how ApplyMove and DrawClaimable treat the rules that
+// differ between chess implementations.
+type Ruleset struct {
+	// Underpromotion: a pawn may promote to a rook, bishop or knight.
+	Underpromotion bool
+	// AutoInsufficientMaterial, AutoSeventyFiveMove and AutoFivefold end the
+	// game as a draw without anyone claiming it.
+	AutoInsufficientMaterial bool
+	AutoSeventyFiveMove      bool
+	AutoFivefold             bool
+	// ClaimableThreefold and ClaimableFiftyMove are reported by
+	// DrawClaimable but never end the game on their own.
+	ClaimableThreefold bool
+	ClaimableFiftyMove bool
+}
+
+// Rules returns the ruleset in force. Moves are applied to a game rebuilt from
+// the stored FEN, which carries the halfmove clock but not earlier positions,
+// so fivefold repetition is never detected automatically and threefold
+// repetition only within the history DrawClaimable is given.
+func Rules() Ruleset {
+	return Ruleset{
+		Underpromotion:           true,
+		AutoInsufficientMaterial: true,
+		AutoSeventyFiveMove:      true,
+		AutoFivefold:             false,
+		ClaimableThreefold:       true,
+		ClaimableFiftyMove:       true,
+	}
+}
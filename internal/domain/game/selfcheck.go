@@ -0,0 +1,110 @@
+package game
+
+import (
+	"fmt"
+
+	"github.com/notnil/chess"
+)
+
+// ErrWouldBeInCheck is the ErrIllegalMove of a move the piece could make but
+// that leaves the mover's own king in check, e.g. moving a pinned piece.
+var ErrWouldBeInCheck = fmt.Errorf("%w: would_be_in_check", ErrIllegalMove)
+
+// leavesKingInCheck reports whether uci, already rejected by the library, is
+// pseudo-legal in pos (the piece moves that way and the target is not its own
+// side's) and would leave the mover's king attacked. Castling is never
+// reported: its rules are about the squares crossed, not the king's landing.
+func leavesKingInCheck(pos *chess.Position, uci string) bool {
+	board := pos.Board()
+	from, to := uciSquare(uci[0:2]), uciSquare(uci[2:4])
+	mover := board.Piece(from)
+	us := pos.Turn()
+	if mover == chess.NoPiece || mover.Color() != us {
+		return false
+	}
+	if target := board.Piece(to); target != chess.NoPiece && target.Color() == us {
+		return false
+	}
+
+	lastRank := chess.Rank8
+	if us == chess.Black {
+		lastRank = chess.Rank1
+	}
+	promotes := mover.Type() == chess.Pawn && to.Rank() == lastRank
+	if promotes != (len(uci) == 5) {
+		return false
+	}
+
+	squares := board.SquareMap()
+	enPassant := mover.Type() == chess.Pawn && to == pos.EnPassantSquare() && squares[to] == chess.NoPiece
+	if !pieceReaches(squares, from, to, mover, enPassant) {
+		return false
+	}
+
+	// Play the move on a copy of the board and look at our king.
+	delete(squares, from)
+	if enPassant {
+		delete(squares, chess.Square(int(from.Rank())*8+int(to.File())))
+	}
+	squares[to] = mover
+	if promotes {
+		squares[to] = chess.NewPiece(promotionType(uci[4]), us)
+	}
+	for sq, p := range squares {
+		if p.Type() == chess.King && p.Color() == us {
+			return attacked(chess.NewBoard(squares), sq, us.Other())
+		}
+	}
+	return false
+}
+
+// pieceReaches reports whether mover on from can move to to, ignoring king
+// safety. Captures and non-pawn moves reuse attacked on a board where mover
+// is the only piece of its colour, so everything else only blocks.
+func pieceReaches(squares map[chess.Square]chess.Piece, from, to chess.Square, mover chess.Piece, enPassant bool) bool {
+	us := mover.Color()
+	capture := enPassant || squares[to] != chess.NoPiece
+	if mover.Type() == chess.Pawn && !capture {
+		step := 1
+		startRank := chess.Rank2
+		if us == chess.Black {
+			step, startRank = -1, chess.Rank7
+		}
+		if from.File() != to.File() {
+			return false
+		}
+		one := chess.Square(int(from) + 8*step)
+		switch {
+		case to == one:
+			return true
+		case from.Rank() == startRank && int(to) == int(from)+16*step:
+			return squares[one] == chess.NoPiece
+		}
+		return false
+	}
+
+	blockers := make(map[chess.Square]chess.Piece, len(squares))
+	for sq, p := range squares {
+		if sq != from && p.Color() == us {
+			p = chess.NewPiece(p.Type(), us.Other())
+		}
+		blockers[sq] = p
+	}
+	return attacked(chess.NewBoard(blockers), to, us)
+}
+
+func uciSquare(s string) chess.Square {
+	return chess.Square(int(s[1]-'1')*8 + int(s[0]-'a'))
+}
+
+func promotionType(c byte) chess.PieceType {
+	switch c {
+	case 'q':
+		return chess.Queen
+	case 'r':
+		return chess.Rook
+	case 'b':
+		return chess.Bishop
+	}
+	return chess.Knight
+}
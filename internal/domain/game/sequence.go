@@ -0,0 +1,68 @@
+package game
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/notnil/chess"
+)
+
+// MoveSequenceError reports the first move of a sequence that could not be
+// played. It unwraps to ErrInvalidUCI, ErrIllegalMove, or ErrGameNotOngoing.
+type MoveSequenceError struct {
+	Index int
+	UCI   string
+	Err   error
+}
+
+func (e *MoveSequenceError) Error() string {
+	return fmt.Sprintf("move %d (%s): %v", e.Index, e.UCI, e.Err)
+}
+
+func (e *MoveSequenceError) Unwrap() error { return e.Err }
+
+// ApplyMoveSequence plays ucis in order from fen and returns the final game
+// with one MoveRecord per move. It is the primitive behind bulk moves and
+// imports: one chess.Game is threaded through the whole sequence instead of
+// being rebuilt from FEN per move. The game has a nil ID and counts only the
+// plies played here; callers assign identity. It fails with ErrInvalidFEN or
+// ErrIllegalPosition for the start, or a *MoveSequenceError for the first
+// move that cannot be played, including any move after the game has ended.
+func ApplyMoveSequence(fen string, ucis []string, now time.Time) (*Game, []MoveRecord, error) {
+	if err := ValidateLegalPosition(fen); err != nil {
+		return nil, nil, err
+	}
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", ErrInvalidFEN, err)
+	}
+	cg := chess.NewGame(fenOpt, chess.UseNotation(chess.UCINotation{}))
+
+	recs := make([]MoveRecord, 0, len(ucis))
+	for i, uci := range ucis {
+		fail := func(err error) (*Game, []MoveRecord, error) {
+			return nil, nil, &MoveSequenceError{Index: i, UCI: uci, Err: err}
+		}
+		if cg.Outcome() != chess.NoOutcome {
+			return fail(ErrGameNotOngoing)
+		}
+		if !isValidUCISyntax(uci) {
+			return fail(ErrInvalidUCI)
+		}
+		fenBefore := cg.Position().String()
+		if err := cg.MoveStr(uci); err != nil {
+			return fail(ErrIllegalMove)
+		}
+		recs = append(recs, lastMoveRecord(cg, uci, fenBefore, now))
+	}
+
+	g := fromChessGame(uuid.Nil, cg, now)
+	g.Status, g.Result = outcomeToStatus(cg.Outcome(), cg.Method())
+	if n := len(recs); n > 0 {
+		last := recs[n-1].UCI
+		g.LastMoveUCI = &last
+		g.LastMoveAt = &now
+	}
+	return g, recs, nil
+}
@@ -0,0 +1,200 @@
+package game
+
+import "github.com/notnil/chess"
+
+// Theoretical results, from the perspective of the side to move.
+const (
+	TheoreticalWin     = "win"
+	TheoreticalDraw    = "draw"
+	TheoreticalLoss    = "loss"
+	TheoreticalUnknown = "unknown"
+)
+
+// TheoreticalResult evaluates a handful of well-known endings without search
+// or tablebases. result is from the side to move's perspective; known is false
+// (and result is TheoreticalUnknown) for every position not covered below.
+//
+// Covered:
+//   - positions that are already checkmate (loss) or stalemate (draw)
+//   - K v K, KB v K, KN v K, KNN v K, and KB v KB with bishops on the same
+//     square colour: draw
+//   - KQ v K and KR v K: win for the side with the piece, unless the bare
+//     king is to move and can capture it undefended (draw)
+//   - KP v K: draw if the bare king is to move and can capture the undefended
+//     pawn, or stands on the promotion square of a rook pawn; win if the bare
+//     king is outside the square of the pawn and the stronger king does not
+//     block its path, or if a non-rook pawn on the 2nd to 6th rank is not
+//     hanging and the stronger king stands on one of its key squares
+func TheoreticalResult(fen string) (result string, known bool) {
+	fenOpt, err := chess.FEN(fen)
+	if err != nil {
+		return TheoreticalUnknown, false
+	}
+	pos := chess.NewGame(fenOpt).Position()
+	switch pos.Status() {
+	case chess.Checkmate:
+		return TheoreticalLoss, true
+	case chess.Stalemate:
+		return TheoreticalDraw, true
+	}
+
+	kings := map[chess.Color]chess.Square{}
+	extra := map[chess.Color][]placedPiece{}
+	for sq, p := range pos.Board().SquareMap() {
+		if p.Type() == chess.King {
+			kings[p.Color()] = sq
+			continue
+		}
+		extra[p.Color()] = append(extra[p.Color()], placedPiece{sq: sq, typ: p.Type()})
+	}
+
+	white, black := extra[chess.White], extra[chess.Black]
+	switch {
+	case len(white) == 0 && len(black) == 0:
+		return TheoreticalDraw, true
+	case len(white) > 0 && len(black) > 0:
+		if len(white) == 1 && len(black) == 1 &&
+			white[0].typ == chess.Bishop && black[0].typ == chess.Bishop &&
+			squareShade(white[0].sq) == squareShade(black[0].sq) {
+			return TheoreticalDraw, true
+		}
+		return TheoreticalUnknown, false
+	}
+
+	strong := chess.White
+	if len(white) == 0 {
+		strong = chess.Black
+	}
+	pieces := extra[strong]
+	e := endgame{
+		strongKing: kings[strong],
+		weakKing:   kings[strong.Other()],
+		weakToMove: pos.Turn() != strong,
+	}
+
+	var r string
+	switch {
+	case onlyMinorDraw(pieces):
+		return TheoreticalDraw, true
+	case len(pieces) == 1 && (pieces[0].typ == chess.Queen || pieces[0].typ == chess.Rook):
+		r = e.heavyPiece(pieces[0].sq)
+	case len(pieces) == 1 && pieces[0].typ == chess.Pawn:
+		r = e.kingAndPawn(pieces[0].sq, strong)
+	default:
+		return TheoreticalUnknown, false
+	}
+	if r == TheoreticalUnknown {
+		return r, false
+	}
+	if r == TheoreticalWin && e.weakToMove {
+		r = TheoreticalLoss
+	}
+	return r, true
+}
+
+type placedPiece struct {
+	sq  chess.Square
+	typ chess.PieceType
+}
+
+// endgame describes a position where one side has only its king. Results
+// are from the stronger side's perspective.
+type endgame struct {
+	strongKing chess.Square
+	weakKing   chess.Square
+	weakToMove bool
+}
+
+// onlyMinorDraw reports whether pieces are a lone minor piece or two knights,
+// neither of which can force mate against a bare king.
+func onlyMinorDraw(pieces []placedPiece) bool {
+	switch len(pieces) {
+	case 1:
+		return pieces[0].typ == chess.Bishop || pieces[0].typ == chess.Knight
+	case 2:
+		return pieces[0].typ == chess.Knight && pieces[1].typ == chess.Knight
+	}
+	return false
+}
+
+func (e endgame) heavyPiece(sq chess.Square) string {
+	if e.weakToMove && adjacent(e.weakKing, sq) && !adjacent(e.strongKing, sq) {
+		return TheoreticalDraw
+	}
+	return TheoreticalWin
+}
+
+func (e endgame) kingAndPawn(pawn chess.Square, strong chess.Color) string {
+	if e.weakToMove && adjacent(e.weakKing, pawn) && !adjacent(e.strongKing, pawn) {
+		return TheoreticalDraw
+	}
+
+	// Work in the stronger side's frame: the pawn promotes on rank 7.
+	pf, pr := relative(pawn, strong)
+	sf, sr := relative(e.strongKing, strong)
+	wf, wr := relative(e.weakKing, strong)
+	rookPawn := pf == 0 || pf == 7
+
+	if rookPawn && wf == pf && wr == 7 {
+		return TheoreticalDraw
+	}
+
+	// Rule of the square. A pawn on its starting rank may advance two
+	// squares, so its square is drawn from the next rank.
+	if !(sf == pf && sr > pr) {
+		movesToPromote := 7 - pr
+		if pr == 1 {
+			movesToPromote--
+		}
+		kingDistance := distance(wf, wr, pf, 7)
+		if e.weakToMove {
+			kingDistance--
+		}
+		if kingDistance > movesToPromote {
+			return TheoreticalWin
+		}
+	}
+
+	// Key squares: the three squares two ranks ahead of a pawn on the 2nd to
+	// 4th rank, or the six squares one and two ranks ahead of a pawn on the
+	// 5th or 6th.
+	if rookPawn || pr > 5 || (adjacent(e.weakKing, pawn) && !adjacent(e.strongKing, pawn)) {
+		return TheoreticalUnknown
+	}
+	if sf < pf-1 || sf > pf+1 {
+		return TheoreticalUnknown
+	}
+	if sr == pr+2 || (pr >= 4 && sr == pr+1) {
+		return TheoreticalWin
+	}
+	return TheoreticalUnknown
+}
+
+// relative returns sq's file and rank with rank 0 being color's back rank.
+func relative(sq chess.Square, color chess.Color) (file, rank int) {
+	file, rank = int(sq.File()), int(sq.Rank())
+	if color == chess.Black {
+		rank = 7 - rank
+	}
+	return file, rank
+}
+
+func distance(f1, r1, f2, r2 int) int {
+	return max(abs(f1-f2), abs(r1-r2))
+}
+
+func adjacent(a, b chess.Square) bool {
+	return distance(int(a.File()), int(a.Rank()), int(b.File()), int(b.Rank())) == 1
+}
+
+// squareShade returns 0 for dark squares and 1 for light squares.
+func squareShade(sq chess.Square) int {
+	return (int(sq.File()) + int(sq.Rank())) % 2
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
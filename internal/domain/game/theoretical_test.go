@@ -0,0 +1,40 @@
+package game_test
+
+import (
+	"testing"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+)
+
+func TestTheoreticalResult(t *testing.T) {
+	cases := []struct {
+		name   string
+		fen    string
+		result string
+		known  bool
+	}{
+		{"bare kings", "8/8/4k3/8/8/3K4/8/8 w - - 0 1", game.TheoreticalDraw, true},
+		{"lone knight", "8/8/4k3/8/8/3K4/5N2/8 b - - 0 1", game.TheoreticalDraw, true},
+		{"same-colour bishops", "8/8/4kb2/8/8/3KB3/8/8 w - - 0 1", game.TheoreticalDraw, true},
+		{"KR v K, rook side to move", "8/8/4k3/8/8/3K4/8/R7 w - - 0 1", game.TheoreticalWin, true},
+		{"KQ v K, bare king to move", "8/8/4k3/8/8/3K4/8/Q7 b - - 0 1", game.TheoreticalLoss, true},
+		{"KQ v K, hanging queen", "8/8/4k3/4Q3/8/8/8/K7 b - - 0 1", game.TheoreticalDraw, true},
+		{"checkmate", "rnb1kbnr/pppp1ppp/8/4p3/6Pq/5P2/PPPPP2P/RNBQKBNR w KQkq - 1 3", game.TheoreticalLoss, true},
+		{"stalemate", "k7/2Q5/1K6/8/8/8/8/8 b - - 0 1", game.TheoreticalDraw, true},
+		{"KP v K, outside the square", "8/8/8/k7/7P/8/8/K7 w - - 0 1", game.TheoreticalWin, true},
+		{"KP v K, black pawn outside the square", "k7/8/8/8/p7/8/8/7K w - - 0 1", game.TheoreticalLoss, true},
+		{"KP v K, key square", "8/4k3/8/4K3/8/4P3/8/8 b - - 0 1", game.TheoreticalLoss, true},
+		{"KP v K, rook pawn corner", "k7/8/8/P7/8/8/8/4K3 w - - 0 1", game.TheoreticalDraw, true},
+		{"KP v K, pawn hangs", "8/8/8/8/3kP3/8/8/K7 b - - 0 1", game.TheoreticalDraw, true},
+		{"KP v K, king behind the pawn", "8/8/4k3/8/4P3/4K3/8/8 w - - 0 1", game.TheoreticalUnknown, false},
+		{"opening position", "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1", game.TheoreticalUnknown, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, known := game.TheoreticalResult(tc.fen)
+			if result != tc.result || known != tc.known {
+				t.Fatalf("got (%s, %v), want (%s, %v)", result, known, tc.result, tc.known)
+			}
+		})
+	}
+}
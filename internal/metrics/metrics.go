@@ -0,0 +1,204 @@
+// Package metrics is a minimal, dependency-free metrics registry rendered in
+// the Prometheus text exposition format.
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// Counter is a monotonically increasing value.
+type Counter struct {
+	v atomic.Int64
+}
+
+func (c *Counter) Inc()         { c.v.Add(1) }
+func (c *Counter) Add(n int64)  { c.v.Add(n) }
+func (c *Counter) Value() int64 { return c.v.Load() }
+
+// Gauge is a value that can go up and down.
+type Gauge struct {
+	v atomic.Int64
+}
+
+func (g *Gauge) Set(n int64)  { g.v.Store(n) }
+func (g *Gauge) Inc()         { g.v.Add(1) }
+func (g *Gauge) Dec()         { g.v.Add(-1) }
+func (g *Gauge) Value() int64 { return g.v.Load() }
+
+// Histogram counts observations into cumulative buckets by upper bound.
+type Histogram struct {
+	bounds []int64
+	counts []atomic.Int64 // counts[i] holds observations <= bounds[i]
+	sum    atomic.Int64
+	count  atomic.Int64
+}
+
+// Observe records v.
+func (h *Histogram) Observe(v int64) {
+	for i, b := range h.bounds {
+		if v <= b {
+			h.counts[i].Add(1)
+		}
+	}
+	h.sum.Add(v)
+	h.count.Add(1)
+}
+
+// Count returns the number of observations.
+func (h *Histogram) Count() int64 { return h.count.Load() }
+
+// Sum returns the total of all observations.
+func (h *Histogram) Sum() int64 { return h.sum.Load() }
+
+func (h *Histogram) write(w io.Writer, name string) error {
+	for i, b := range h.bounds {
+		if _, err := fmt.Fprintf(w, "%s_bucket{le=\"%d\"} %d\n", name, b, h.counts[i].Load()); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n%s_sum %d\n%s_count %d\n",
+		name, h.count.Load(), name, h.sum.Load(), name, h.count.Load())
+	return err
+}
+
+type metric struct {
+	name  string
+	help  string
+	kind  string
+	value func() int64
+	hist  *Histogram // set instead of value for histograms
+}
+
+// Registry holds named metrics. Registering an existing name returns the
+// metric already registered under it. A name may carry a label set, as in
+// `requests{route="/a"}`; metrics sharing the part before the braces form one
+// family with a single HELP and TYPE.
+type Registry struct {
+	mu       sync.Mutex
+	metrics  map[string]*metric
+	counters map[string]*Counter
+	gauges   map[string]*Gauge
+	hists    map[string]*Histogram
+}
+
+// NewRegistry creates an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		metrics:  make(map[string]*metric),
+		counters: make(map[string]*Counter),
+		gauges:   make(map[string]*Gauge),
+		hists:    make(map[string]*Histogram),
+	}
+}
+
+// Counter returns the counter registered under name, creating it if needed.
+func (r *Registry) Counter(name, help string) *Counter {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if c, ok := r.counters[name]; ok {
+		return c
+	}
+	c := &Counter{}
+	r.counters[name] = c
+	r.metrics[name] = &metric{name: name, help: help, kind: "counter", value: c.Value}
+	return c
+}
+
+// Gauge returns the gauge registered under name, creating it if needed.
+func (r *Registry) Gauge(name, help string) *Gauge {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if g, ok := r.gauges[name]; ok {
+		return g
+	}
+	g := &Gauge{}
+	r.gauges[name] = g
+	r.metrics[name] = &metric{name: name, help: help, kind: "gauge", value: g.Value}
+	return g
+}
+
+// Histogram returns the histogram registered under name, creating it with
+// the given ascending bucket bounds if needed. name must not carry labels.
+func (r *Registry) Histogram(name, help string, bounds []int64) *Histogram {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if h, ok := r.hists[name]; ok {
+		return h
+	}
+	h := &Histogram{bounds: slices.Clone(bounds), counts: make([]atomic.Int64, len(bounds))}
+	r.hists[name] = h
+	r.metrics[name] = &metric{name: name, help: help, kind: "histogram", hist: h}
+	return h
+}
+
+// GaugeFunc registers a gauge whose value is read from fn at scrape time.
+// Registering an existing name is a no-op.
+func (r *Registry) GaugeFunc(name, help string, fn func() int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		return
+	}
+	r.metrics[name] = &metric{name: name, help: help, kind: "gauge", value: fn}
+}
+
+// CounterFunc registers a counter whose value is read from fn at scrape
+// time. fn must never decrease. Registering an existing name is a no-op.
+func (r *Registry) CounterFunc(name, help string, fn func() int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.metrics[name]; ok {
+		return
+	}
+	r.metrics[name] = &metric{name: name, help: help, kind: "counter", value: fn}
+}
+
+// WriteText writes every metric in the Prometheus text format, sorted by name.
+func (r *Registry) WriteText(w io.Writer) error {
+	r.mu.Lock()
+	ms := make([]*metric, 0, len(r.metrics))
+	for _, m := range r.metrics {
+		ms = append(ms, m)
+	}
+	r.mu.Unlock()
+
+	sort.Slice(ms, func(i, j int) bool {
+		if fi, fj := family(ms[i].name), family(ms[j].name); fi != fj {
+			return fi < fj
+		}
+		return ms[i].name < ms[j].name
+	})
+	last := ""
+	for _, m := range ms {
+		if f := family(m.name); f != last {
+			if _, err := fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", f, m.help, f, m.kind); err != nil {
+				return err
+			}
+			last = f
+		}
+		if m.hist != nil {
+			if err := m.hist.write(w, m.name); err != nil {
+				return err
+			}
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s %d\n", m.name, m.value()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// family strips the label set, if any, from a metric name.
+func family(name string) string {
+	if i := strings.IndexByte(name, '{'); i >= 0 {
+		return name[:i]
+	}
+	return name
+}
@@ -3,6 +3,7 @@ package ports
 import (
 	"context"
 	"errors"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -16,34 +17,220 @@ var (
 	ErrNoGamesAvailable = errors.New("no games available")
 	ErrAlreadyMoved     = errors.New("already moved in this game")
 	ErrNotAssigned      = errors.New("not assigned to this game")
+	ErrGameHasMoves     = errors.New("game has recorded moves")
+	// ErrClaimHeld means another claimant's exclusive window still holds the
+	// game; the move may succeed once that claimant moves or the window ends.
+	ErrClaimHeld = errors.New("game held by another claimant")
+	// ErrStoreBusy means the store could not get a connection in time; the
+	// request may succeed if retried shortly.
+	ErrStoreBusy = errors.New("store busy")
+	// ErrVersionNotIncremented is a bug, not a conflict: a save must write
+	// exactly expectedVersion+1, so a stale game object is never persisted.
+	ErrVersionNotIncremented = errors.New("state_version must advance by exactly one")
 )
 
+// ClaimStrategy selects which eligible game ClaimNextGame hands out.
+type ClaimStrategy string
+
+const (
+	// ClaimOldest picks the game created first.
+	ClaimOldest ClaimStrategy = "oldest"
+	// ClaimRandom picks uniformly among the 100 oldest eligible games, so the
+	// pick stays cheap on a large backlog. Games outside that window are
+	// never picked until older ones are claimed or finish.
+	ClaimRandom ClaimStrategy = "random"
+	// ClaimMostActive picks the game with the most recent activity, keeping
+	// lively games moving before waiting ones are opened.
+	ClaimMostActive ClaimStrategy = "most_active"
+)
+
+// MetadataPatch is a partial update of a game's operator metadata. Fields
+// whose *Set flag is false are left unchanged.
+type MetadataPatch struct {
+	TitleSet bool
+	Title    *string // nil clears the title
+	TagsSet  bool
+	Tags     []string
+}
+
+// GameFilter narrows ListGames. Empty fields match any value; a Result
+// filter never matches a game that has no result yet.
+type GameFilter struct {
+	Status game.Status
+	Result game.Result
+}
+
+// Matches reports whether g passes the filter.
+func (f GameFilter) Matches(g *game.Game) bool {
+	if f.Status != "" && g.Status != f.Status {
+		return false
+	}
+	if f.Result != "" && (g.Result == nil || *g.Result != f.Result) {
+		return false
+	}
+	return true
+}
+
+// Contributor is a client that moved in a game, with the ply of its first
+// move there.
+type Contributor struct {
+	ClientID uuid.UUID
+	FirstPly int
+}
+
+// ClientClaim is a game a client claimed, with the move it made there if it
+// has moved.
+type ClientClaim struct {
+	GameID    uuid.UUID
+	ClaimedAt time.Time
+	HasMoved  bool
+	Move      *game.MoveHistoryItem
+}
+
+// GameEventKind names a lifecycle event in a game's event log.
+type GameEventKind string
+
+const (
+	EventCreated       GameEventKind = "created"
+	EventClaimed       GameEventKind = "claimed"
+	EventMove          GameEventKind = "move"
+	EventStatusChange  GameEventKind = "status_change"
+	EventClaimReverted GameEventKind = "claim_reverted"
+)
+
+// GameEvent is one entry of a game's append-only event log. Actor is nil for
+// system events; Detail holds the move's UCI or the new status.
+type GameEvent struct {
+	Kind      GameEventKind
+	Actor     *uuid.UUID
+	Detail    string
+	CreatedAt time.Time
+}
+
+// FailedMove is a dead-letter record of a validated move that could not be
+// persisted because of an unexpected store error.
+type FailedMove struct {
+	ID        uuid.UUID
+	GameID    uuid.UUID
+	ClientID  uuid.UUID
+	Ply       int
+	UCI       string
+	FENBefore string
+	FENAfter  string
+	Error     string
+	CreatedAt time.Time
+}
+
+// GameCompletionNotifier is told about games that have just finished.
+// Implementations must not block the caller.
+type GameCompletionNotifier interface {
+	GameCompleted(g *game.Game, history []game.MoveHistoryItem)
+}
+
 // GameStore is the persistence interface for games.
 type GameStore interface {
+	// InTx runs fn against a transactional view of the store: every call made
+	// through txStore commits together when fn returns nil and is rolled back
+	// when it returns an error. fn must not call the outer store.
+	InTx(ctx context.Context, fn func(txStore GameStore) error) error
+
 	GetByID(ctx context.Context, id uuid.UUID) (*game.Game, error)
 	ListOngoing(ctx context.Context) ([]*game.Game, error)
+	// ListChangedSince returns up to limit games ordered by (UpdatedAt, ID)
+	// that come strictly after the cursor (since, afterID). Passing uuid.Nil
+	// as afterID includes games updated exactly at since.
+	ListChangedSince(ctx context.Context, since time.Time, afterID uuid.UUID, limit int) ([]*game.Game, error)
+	// ListGames returns up to limit games matching filter, ordered by ID and
+	// strictly after afterID.
+	ListGames(ctx context.Context, filter GameFilter, afterID uuid.UUID, limit int) ([]*game.Game, error)
+	// SampleForAudit returns up to n games chosen at random, for read-only
+	// consistency checks.
+	SampleForAudit(ctx context.Context, n int) ([]*game.Game, error)
+	// RandomOngoing returns one ongoing game chosen at random, for
+	// spectators. Returns ErrNotFound when no game is ongoing.
+	RandomOngoing(ctx context.Context) (*game.Game, error)
 	// SaveIfVersion overwrites the game only when the stored StateVersion
-	// equals expectedVersion. Returns ErrVersionConflict otherwise.
+	// equals expectedVersion. Returns ErrVersionConflict otherwise, and
+	// ErrVersionNotIncremented without writing unless g.StateVersion is
+	// expectedVersion+1.
 	SaveIfVersion(ctx context.Context, g *game.Game, expectedVersion int) error
 
 	// HasActiveGames returns true if any game is in waiting or ongoing status.
 	HasActiveGames(ctx context.Context) (bool, error)
 
-	// CreateWaitingBatch inserts count new games in 'waiting' status.
-	CreateWaitingBatch(ctx context.Context, count int) error
+	// CountWaiting returns the number of games in waiting status.
+	CountWaiting(ctx context.Context) (int, error)
+
+	// TotalMoves returns the number of moves played across all games, as the
+	// sum of their ply counts, which avoids counting the moves table.
+	TotalMoves(ctx context.Context) (int64, error)
+
+	// CreateWaitingBatch inserts up to count new games in 'waiting' status
+	// and returns how many were actually created. ID collisions are skipped,
+	// so the result can be lower than count.
+	CreateWaitingBatch(ctx context.Context, count int) (int, error)
 
 	// ClaimNextGame finds a game in waiting/ongoing status that clientID has not
 	// played, atomically inserts a game_players row, and returns the game with its
 	// current move history. Returns ErrNoGamesAvailable if nothing is found.
 	ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Game, []game.MoveHistoryItem, error)
 
+	// PeekNextGameID returns the ID of the game ClaimNextGame would hand
+	// clientID, without claiming it. Returns ErrNoGamesAvailable if there is
+	// none.
+	PeekNextGameID(ctx context.Context, clientID uuid.UUID) (uuid.UUID, error)
+
+	// CountAvailableForClient returns how many waiting/ongoing games clientID
+	// has not claimed yet and nobody else holds exclusively.
+	CountAvailableForClient(ctx context.Context, clientID uuid.UUID) (int, error)
+
+	// FindActiveClaim returns the ID of a waiting/ongoing game that clientID has
+	// claimed but not yet moved in. found is false when there is none.
+	FindActiveClaim(ctx context.Context, clientID uuid.UUID) (gameID uuid.UUID, found bool, err error)
+
+	// LockClient serializes claims by clientID: inside InTx it blocks until
+	// no other transaction holds the client's lock, and keeps it until the
+	// transaction ends. Outside InTx it has no lasting effect.
+	LockClient(ctx context.Context, clientID uuid.UUID) error
+
 	// GetGameWithHistory returns a game and its ordered move history.
 	GetGameWithHistory(ctx context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error)
 
+	// GetMovesSince returns the game's moves with ply >= fromPly in ply order.
+	GetMovesSince(ctx context.Context, gameID uuid.UUID, fromPly int) ([]game.MoveHistoryItem, error)
+	// GetMovesPage returns up to limit of the game's moves with ply >= fromPly
+	// in ply order.
+	GetMovesPage(ctx context.Context, gameID uuid.UUID, fromPly, limit int) ([]game.MoveHistoryItem, error)
+
+	// ListClientClaims returns up to limit of the client's claims ordered by
+	// (ClaimedAt, GameID) that come strictly after the cursor (since,
+	// afterGameID), like ListChangedSince.
+	ListClientClaims(ctx context.Context, clientID uuid.UUID, since time.Time, afterGameID uuid.UUID, limit int) ([]ClientClaim, error)
+
+	// ListGameEvents returns the game's event log, oldest first. Events are
+	// written by the store operations that create, claim and move in games,
+	// atomically with the change they record.
+	ListGameEvents(ctx context.Context, gameID uuid.UUID) ([]GameEvent, error)
+
+	// IsAssigned reports whether clientID holds an assignment for gameID and,
+	// if so, whether it has already moved. It takes no locks, so it suits
+	// read-only authorization checks but not the move write path. Returns
+	// (false, false, nil) when there is no assignment.
+	IsAssigned(ctx context.Context, gameID, clientID uuid.UUID) (assigned, hasMoved bool, err error)
+
+	// ListContributors returns the distinct clients that moved in the game,
+	// ordered by their first ply. Empty for games without moves.
+	ListContributors(ctx context.Context, gameID uuid.UUID) ([]Contributor, error)
+
+	// LastMoveAt returns when clientID last had a move accepted in any game,
+	// or nil if it never moved.
+	LastMoveAt(ctx context.Context, clientID uuid.UUID) (*time.Time, error)
+
 	// PersistMove atomically verifies that clientID is assigned and has not moved,
 	// inserts the move record, updates the game row (CAS on state_version), marks
 	// the player as moved, and returns the full ordered move history.
-	// Returns ErrNotAssigned, ErrAlreadyMoved, or ErrVersionConflict on failure.
+	// Returns ErrNotAssigned, ErrAlreadyMoved, ErrClaimHeld, or
+	// ErrVersionConflict on failure.
 	PersistMove(
 		ctx context.Context,
 		gameID, clientID uuid.UUID,
@@ -51,6 +238,65 @@ type GameStore interface {
 		rec game.MoveRecord,
 		ply int,
 	) ([]game.MoveHistoryItem, error)
+
+	// PersistHouseMove records a server-made move like PersistMove, but for a
+	// client that needs no assignment and may move any number of times.
+	PersistHouseMove(ctx context.Context, gameID, clientID uuid.UUID, newGame *game.Game, rec game.MoveRecord, ply int) error
+
+	// RevertUnmovedClaim drops clientID's claim on a game nobody has moved in
+	// yet and, when no other claimant remains, returns the game to waiting.
+	// Both are logged as events. Returns ErrNotAssigned, ErrAlreadyMoved, or
+	// ErrGameHasMoves when the game has any recorded moves.
+	RevertUnmovedClaim(ctx context.Context, gameID, clientID uuid.UUID) error
+
+	// RecordFailedMove stores a dead-letter record for later inspection.
+	RecordFailedMove(ctx context.Context, fm FailedMove) error
+
+	// PurgeFinished deletes games in a terminal status last updated before
+	// olderThan, together with their moves, players and dead-letter records.
+	// Returns the number of games deleted.
+	PurgeFinished(ctx context.Context, olderThan time.Time) (int, error)
+
+	// UpdateMetadata applies patch to the game's metadata without touching its
+	// chess state or state_version. It advances updated_at so the edit reaches
+	// ListChangedSince. Returns ErrNotFound for unknown games.
+	UpdateMetadata(ctx context.Context, id uuid.UUID, patch MetadataPatch) (*game.Game, error)
+
+	// ListBlockedClients returns every blocked client ID.
+	ListBlockedClients(ctx context.Context) ([]uuid.UUID, error)
+
+	// BlockClient adds clientID to the block list. Blocking twice is a no-op.
+	BlockClient(ctx context.Context, clientID uuid.UUID) error
+
+	// UnblockClient removes clientID from the block list. Unblocking a client
+	// that is not blocked is a no-op.
+	UnblockClient(ctx context.Context, clientID uuid.UUID) error
+}
+
+// ClaimCounter counts game claims per key within a window. Keys are opaque;
+// callers pass hashed IPs.
+type ClaimCounter interface {
+	// Reserve records one claim for key if fewer than limit are recorded in
+	// the current window, and reports whether it did. Checking and recording
+	// are one step, so concurrent callers cannot both take the last claim.
+	Reserve(key string, limit int) bool
+	// Release gives back a claim Reserve recorded, for a claim that failed.
+	Release(key string)
+}
+
+// PoolStats is a snapshot of a store's connection pool.
+type PoolStats struct {
+	TotalConns      int32
+	IdleConns       int32
+	InUseConns      int32
+	AcquireCount    int64
+	AcquireDuration time.Duration
+}
+
+// PoolStatsProvider is optionally implemented by a GameStore that pools
+// connections.
+type PoolStatsProvider interface {
+	Stats() PoolStats
 }
 
 // RateLimiter gates requests by IP and optional client token.
@@ -0,0 +1,393 @@
+package http
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+// requireAdminToken rejects requests whose Authorization header does not carry
+// "Bearer <token>".
+func requireAdminToken(token string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			got, ok := strings.CutPrefix(c.Request().Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+				return c.JSON(http.StatusUnauthorized, Problem{
+					Type:   errBase + "/unauthorized",
+					Title:  "Unauthorized",
+					Status: http.StatusUnauthorized,
+					Detail: "A valid admin token is required.",
+					Code:   "unauthorized",
+				})
+			}
+			return next(c)
+		}
+	}
+}
+
+// defaultAdminSignatureSkew is how far X-Admin-Timestamp may be from the
+// server clock when Options.AdminSignatureSkew is zero.
+const defaultAdminSignatureSkew = 5 * time.Minute
+
+// requireAdminSignature rejects requests that do not carry a fresh, unused
+// HMAC-SHA256 signature made with secret. X-Admin-Timestamp holds Unix seconds
+// and X-Admin-Signature the hex HMAC of
+//
+//	METHOD "\n" request URI "\n" timestamp "\n" body
+//
+// where the request URI is the path plus any query string. Timestamps more
+// than skew away from the server clock are refused, and each signature is
+// accepted once: it is remembered until its timestamp leaves the window, so a
+// leaked request cannot be replayed.
+func requireAdminSignature(secret string, skew time.Duration) echo.MiddlewareFunc {
+	if skew <= 0 {
+		skew = defaultAdminSignatureSkew
+	}
+	seen := &signatureSet{seen: make(map[string]time.Time)}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			ts := req.Header.Get("X-Admin-Timestamp")
+			unix, err := strconv.ParseInt(ts, 10, 64)
+			if err != nil {
+				return unauthorized(c, "X-Admin-Timestamp and X-Admin-Signature are required.", "signature_missing")
+			}
+			signedAt := time.Unix(unix, 0)
+			now := time.Now()
+			if signedAt.Before(now.Add(-skew)) || signedAt.After(now.Add(skew)) {
+				return unauthorized(c, "X-Admin-Timestamp is outside the accepted window.", "signature_expired")
+			}
+
+			body, err := io.ReadAll(req.Body)
+			if err != nil {
+				return err
+			}
+			req.Body = io.NopCloser(bytes.NewReader(body))
+
+			mac := hmac.New(sha256.New, []byte(secret))
+			mac.Write([]byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + ts + "\n"))
+			mac.Write(body)
+			got, err := hex.DecodeString(req.Header.Get("X-Admin-Signature"))
+			if err != nil || !hmac.Equal(got, mac.Sum(nil)) {
+				return unauthorized(c, "X-Admin-Signature does not match the request.", "signature_invalid")
+			}
+			// Key on the MAC, not the header: hex decoding ignores case, so
+			// the raw header could be replayed with its letters recased.
+			if !seen.add(hex.EncodeToString(got), signedAt.Add(skew), now) {
+				return unauthorized(c, "This signature has already been used.", "signature_replayed")
+			}
+			return next(c)
+		}
+	}
+}
+
+// signatureSet remembers accepted signatures until they expire.
+type signatureSet struct {
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// add records sig, valid until expires, and reports whether it was new.
+// Expired entries are dropped on the way.
+func (s *signatureSet) add(sig string, expires, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, exp := range s.seen {
+		if now.After(exp) {
+			delete(s.seen, k)
+		}
+	}
+	if _, ok := s.seen[sig]; ok {
+		return false
+	}
+	s.seen[sig] = expires
+	return true
+}
+
+func unauthorized(c echo.Context, detail, code string) error {
+	return c.JSON(http.StatusUnauthorized, Problem{
+		Type:   errBase + "/unauthorized",
+		Title:  "Unauthorized",
+		Status: http.StatusUnauthorized,
+		Detail: detail,
+		Code:   code,
+	})
+}
+
+// parseClientIDParam reads the :client_id path parameter, writing a 400 when it
+// is not a UUID.
+func parseClientIDParam(c echo.Context) (uuid.UUID, error) {
+	id, err := uuid.Parse(c.Param("client_id"))
+	if err != nil {
+		return uuid.Nil, rejected(c, Problem{
+			Type:   errBase + "/invalid-client-id",
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: "client_id must be a valid UUID.",
+			Code:   "invalid_client_id",
+		})
+	}
+	return id, nil
+}
+
+func (h *Handlers) handleListBlockedClients(c echo.Context) error {
+	ids, err := h.admin.ListBlockedClients(c.Request().Context())
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{"client_ids": out})
+}
+
+func (h *Handlers) handleBlockClient(c echo.Context) error {
+	clientID, err := parseClientIDParam(c)
+	if err != nil {
+		return nil // response already written
+	}
+	if err := h.admin.BlockClient(c.Request().Context(), clientID); err != nil {
+		return h.writeErr(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+func (h *Handlers) handleUnblockClient(c echo.Context) error {
+	clientID, err := parseClientIDParam(c)
+	if err != nil {
+		return nil // response already written
+	}
+	if err := h.admin.UnblockClient(c.Request().Context(), clientID); err != nil {
+		return h.writeErr(c, err)
+	}
+	return c.NoContent(http.StatusNoContent)
+}
+
+// adminMoveJSON extends the public move history item with admin-only fields.
+type adminMoveJSON struct {
+	moveHistoryJSON
+	UserAgent *string `json:"user_agent"`
+}
+
+type adminGameJSON struct {
+	gameStateJSON
+	MoveHistory []adminMoveJSON `json:"move_history"`
+}
+
+type gameEventJSON struct {
+	Kind      string    `json:"kind"`
+	Actor     *string   `json:"actor"`
+	Detail    string    `json:"detail,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// handleGameEvents returns a game's lifecycle event log.
+func (h *Handlers) handleGameEvents(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	events, err := h.admin.GameEvents(c.Request().Context(), id)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	items := make([]gameEventJSON, len(events))
+	for i, ev := range events {
+		items[i] = gameEventJSON{Kind: string(ev.Kind), Detail: ev.Detail, CreatedAt: ev.CreatedAt}
+		if ev.Actor != nil {
+			actor := ev.Actor.String()
+			items[i].Actor = &actor
+		}
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{"game_id": id.String(), "events": items})
+}
+
+type clientClaimJSON struct {
+	GameID    string         `json:"game_id"`
+	ClaimedAt time.Time      `json:"claimed_at"`
+	HasMoved  bool           `json:"has_moved"`
+	Move      *adminMoveJSON `json:"move"`
+}
+
+// handleClientActivity lists the games a client claimed and the moves it made,
+// paged with the same signed cursors as the changed-games feed.
+func (h *Handlers) handleClientActivity(c echo.Context) error {
+	clientID, err := parseClientIDParam(c)
+	if err != nil {
+		return nil // response already written
+	}
+
+	var (
+		since   time.Time
+		afterID = uuid.Nil
+	)
+	if v := c.QueryParam("cursor"); v != "" {
+		if since, afterID, err = h.cursors.decode(v); err != nil {
+			return c.JSON(http.StatusBadRequest, Problem{
+				Type:   errBase + "/invalid-cursor",
+				Title:  "Bad Request",
+				Status: http.StatusBadRequest,
+				Detail: "cursor was not issued by this server.",
+				Code:   "invalid_cursor",
+			})
+		}
+	}
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return badQuery(c, "limit must be a positive integer.")
+		}
+	}
+
+	claims, err := h.admin.ClientActivity(c.Request().Context(), clientID, since, afterID, limit)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	items := make([]clientClaimJSON, len(claims))
+	for i, claim := range claims {
+		items[i] = clientClaimJSON{
+			GameID:    claim.GameID.String(),
+			ClaimedAt: claim.ClaimedAt,
+			HasMoved:  claim.HasMoved,
+		}
+		if claim.Move != nil {
+			items[i].Move = &adminMoveJSON{
+				moveHistoryJSON: toMoveHistoryJSON([]game.MoveHistoryItem{*claim.Move})[0],
+				UserAgent:       claim.Move.UserAgent,
+			}
+		}
+	}
+	if len(claims) > 0 {
+		last := claims[len(claims)-1]
+		since, afterID = last.ClaimedAt, last.GameID
+	}
+	nextCursor := h.cursors.encode(since, afterID)
+
+	first := c.Request().URL.Query()
+	first.Del("cursor")
+	var next string
+	if pageFull(len(claims), limit, usecase.DefaultActivityLimit, usecase.MaxActivityLimit) {
+		next = nextCursor
+	}
+	setPageLinks(c, first, next)
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{
+		"client_id":   clientID.String(),
+		"claims":      items,
+		"next_cursor": nextCursor,
+	})
+}
+
+// handleAdminGetGame returns a game with admin-only move fields.
+func (h *Handlers) handleAdminGetGame(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	g, hist, err := h.admin.GetGame(c.Request().Context(), id)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	public := toMoveHistoryJSON(hist)
+	moves := make([]adminMoveJSON, len(hist))
+	for i, item := range hist {
+		moves[i] = adminMoveJSON{moveHistoryJSON: public[i], UserAgent: item.UserAgent}
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, adminGameJSON{gameStateJSON: toGameStateJSON(g), MoveHistory: moves})
+}
+
+// handlePatchGameMetadata applies a JSON merge patch of the game's metadata.
+// Only "title" (string or null) and "tags" (array of strings or null) are
+// accepted; any other key is rejected.
+func (h *Handlers) handlePatchGameMetadata(c echo.Context) error {
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	var raw map[string]json.RawMessage
+	if err := json.NewDecoder(c.Request().Body).Decode(&raw); err != nil {
+		return badMetadataPatch(c, "Body must be a JSON object.")
+	}
+
+	var patch ports.MetadataPatch
+	for key, val := range raw {
+		switch key {
+		case "title":
+			patch.TitleSet = true
+			if err := json.Unmarshal(val, &patch.Title); err != nil {
+				return badMetadataPatch(c, "title must be a string or null.")
+			}
+		case "tags":
+			patch.TagsSet = true
+			if err := json.Unmarshal(val, &patch.Tags); err != nil {
+				return badMetadataPatch(c, "tags must be an array of strings or null.")
+			}
+		default:
+			return badMetadataPatch(c, "Unknown field "+key+"; only title and tags may be patched.")
+		}
+	}
+
+	g, hist, err := h.admin.PatchGameMetadata(c.Request().Context(), id, patch)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, toGameJSON(g, hist))
+}
+
+func badMetadataPatch(c echo.Context, detail string) error {
+	return c.JSON(http.StatusBadRequest, Problem{
+		Type:   errBase + "/invalid-metadata",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Code:   "invalid_metadata",
+	})
+}
+
+type poolStatsJSON struct {
+	TotalConns        int32 `json:"total_conns"`
+	IdleConns         int32 `json:"idle_conns"`
+	InUseConns        int32 `json:"in_use_conns"`
+	AcquireCount      int64 `json:"acquire_count"`
+	AcquireDurationMS int64 `json:"acquire_duration_ms"`
+}
+
+func (h *Handlers) handlePoolStats(c echo.Context) error {
+	st := h.admin.PoolStats()
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, poolStatsJSON{
+		TotalConns:        st.TotalConns,
+		IdleConns:         st.IdleConns,
+		InUseConns:        st.InUseConns,
+		AcquireCount:      st.AcquireCount,
+		AcquireDurationMS: st.AcquireDuration.Milliseconds(),
+	})
+}
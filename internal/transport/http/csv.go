@@ -0,0 +1,90 @@
+package http
+
+import (
+	"encoding/csv"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+var movesCSVHeader = []string{
+	"ply", "uci", "san", "from", "to", "promotion", "client_id", "created_at", "fen_before", "fen_after",
+}
+
+// handleMovesCSV streams a game's full move history as CSV, flushing after
+// each page of moves read from the store.
+func (h *Handlers) handleMovesCSV(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	ctx := c.Request().Context()
+	res := c.Response()
+	w := csv.NewWriter(res)
+	started := false
+	err = h.getter.StreamMoves(ctx, ip, token, id, func(g *game.Game) error {
+		res.Header().Set(echo.HeaderContentType, "text/csv; charset=utf-8")
+		res.Header().Set(echo.HeaderContentDisposition, fmt.Sprintf(`attachment; filename="game-%s-moves.csv"`, id))
+		h.setArtifactCache(c, g)
+		res.WriteHeader(http.StatusOK)
+		started = true
+		return w.Write(movesCSVHeader)
+	}, func(moves []game.MoveHistoryItem) error {
+		for _, item := range moves {
+			if err := w.Write(moveCSVRow(item)); err != nil {
+				return err
+			}
+		}
+		w.Flush()
+		res.Flush()
+		return w.Error()
+	})
+	switch {
+	case err == nil:
+		w.Flush()
+		return w.Error()
+	case ctx.Err() != nil:
+		// The client went away; nobody is left to tell.
+	case started:
+		// The status line is already out, so the error can only be logged.
+		log.Printf("moves csv for game %s: %v", id, err)
+	default:
+		return h.writeErr(c, err)
+	}
+	return nil
+}
+
+func moveCSVRow(item game.MoveHistoryItem) []string {
+	san, err := game.SAN(item.FENBefore, item.UCI)
+	if err != nil {
+		san = ""
+	}
+	promotion := ""
+	if item.Promotion != nil {
+		promotion = *item.Promotion
+	}
+	return []string{
+		strconv.Itoa(item.Ply),
+		item.UCI,
+		san,
+		item.FromSq,
+		item.ToSq,
+		promotion,
+		item.ClientID.String(),
+		item.CreatedAt.UTC().Format(time.RFC3339Nano),
+		item.FENBefore,
+		item.FENAfter,
+	}
+}
@@ -1,8 +1,14 @@
 package http
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
 	"net/http"
+	"strconv"
+	"time"
 
 	"github.com/labstack/echo/v4"
 
@@ -19,24 +25,115 @@ type Problem struct {
 	Title  string `json:"title"`
 	Status int    `json:"status"`
 	Detail string `json:"detail"`
+	// Code is a stable machine-readable reason, set on every rejection, so
+	// a client can switch on one field regardless of status.
+	Code string `json:"code,omitempty"`
 }
 
 // IllegalMoveProblem matches the contract IllegalMoveProblem schema.
 type IllegalMoveProblem struct {
 	Problem
-	Code string    `json:"code"`
 	Game *gameJSON `json:"game,omitempty"`
 }
 
+// ConflictProblem is a version conflict carrying the game's current state.
+type ConflictProblem struct {
+	Problem
+	Game *gameJSON `json:"game"`
+}
+
+// FieldProblem is a 400 that names the offending request field.
+type FieldProblem struct {
+	Problem
+	Field string `json:"field"`
+}
+
+// ActiveClaimProblem is returned when a client must finish its current game
+// before claiming another one.
+type ActiveClaimProblem struct {
+	Problem
+	CurrentGameID string `json:"current_game_id"`
+}
+
+// RetryProblem is returned with 429 and 503 responses. RetryAfterMS mirrors
+// the Retry-After header for clients that do not read headers.
+type RetryProblem struct {
+	Problem
+	RetryAfterMS int64 `json:"retry_after_ms"`
+}
+
+// retryPolicy picks the retry hint for 429/503 responses: base plus a random
+// jitter in [0, jitter), so throttled clients do not retry in lockstep.
+type retryPolicy struct {
+	base   time.Duration
+	jitter time.Duration
+}
+
+func (p retryPolicy) delay() time.Duration {
+	if p.jitter <= 0 {
+		return p.base
+	}
+	return p.base + rand.N(p.jitter)
+}
+
+// writeRetry sends p with a jittered Retry-After header and retry_after_ms.
+func (h *Handlers) writeRetry(c echo.Context, p Problem) error {
+	d := h.retry.delay()
+	c.Response().Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(d.Seconds()))))
+	return c.JSON(p.Status, RetryProblem{Problem: p, RetryAfterMS: d.Milliseconds()})
+}
+
 // writeErr maps a domain/usecase error to the correct HTTP response.
-func writeErr(c echo.Context, err error) error {
+func (h *Handlers) writeErr(c echo.Context, err error) error {
+	var activeClaim *usecase.ActiveClaimError
+	var cooldown *usecase.CooldownError
+	var conflict *usecase.VersionConflictError
 	switch {
+	case errors.As(err, &activeClaim):
+		return c.JSON(http.StatusConflict, ActiveClaimProblem{
+			Problem: Problem{
+				Type:   errBase + "/active-claim",
+				Title:  "Conflict",
+				Status: http.StatusConflict,
+				Detail: "Finish your move in the current game before claiming another.",
+				Code:   "active_claim",
+			},
+			CurrentGameID: activeClaim.GameID.String(),
+		})
+	case errors.Is(err, usecase.ErrHistoryDisabled):
+		return c.JSON(http.StatusNotFound, Problem{
+			Type:   errBase + "/history-disabled",
+			Title:  "Not Found",
+			Status: http.StatusNotFound,
+			Detail: "This server does not record move history.",
+			Code:   "history_disabled",
+		})
+	case errors.Is(err, usecase.ErrHistoryUnavailable):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/history-unavailable",
+			Title:  "Service Unavailable",
+			Status: http.StatusServiceUnavailable,
+			Detail: "Move history is temporarily unavailable.",
+			Code:   "history_unavailable",
+		})
 	case errors.Is(err, ports.ErrNotFound):
 		return c.JSON(http.StatusNotFound, Problem{
 			Type:   errBase + "/not-found",
 			Title:  "Not Found",
 			Status: http.StatusNotFound,
 			Detail: "Resource not found.",
+			Code:   "not_found",
+		})
+	case errors.As(err, &conflict):
+		return c.JSON(http.StatusConflict, ConflictProblem{
+			Problem: Problem{
+				Type:   errBase + "/conflict",
+				Title:  "Conflict",
+				Status: http.StatusConflict,
+				Detail: "Game state changed; retry with the current state_version.",
+				Code:   "version_conflict",
+			},
+			Game: toGameJSON(conflict.Game, conflict.History),
 		})
 	case errors.Is(err, ports.ErrVersionConflict):
 		return c.JSON(http.StatusConflict, Problem{
@@ -44,6 +141,7 @@ func writeErr(c echo.Context, err error) error {
 			Title:  "Conflict",
 			Status: http.StatusConflict,
 			Detail: "Game state changed; refresh and retry with new expected_version.",
+			Code:   "version_conflict",
 		})
 	case errors.Is(err, ports.ErrAlreadyMoved):
 		return c.JSON(http.StatusConflict, IllegalMoveProblem{
@@ -52,8 +150,40 @@ func writeErr(c echo.Context, err error) error {
 				Title:  "Conflict",
 				Status: http.StatusConflict,
 				Detail: "You have already made a move in this game.",
+				Code:   "one_move_limit",
 			},
-			Code: "one_move_limit",
+		})
+	case errors.Is(err, ports.ErrClaimHeld):
+		return c.JSON(http.StatusConflict, Problem{
+			Type:   errBase + "/claim-held",
+			Title:  "Conflict",
+			Status: http.StatusConflict,
+			Detail: "Another client holds this game for now. Claim another with GET /api/v1/games/next.",
+			Code:   "claim_held",
+		})
+	case errors.Is(err, usecase.ErrMissingExpectedVersion):
+		return c.JSON(http.StatusBadRequest, Problem{
+			Type:   errBase + "/missing-expected-version",
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: "expected_version is required once the game has moves.",
+			Code:   "missing_expected_version",
+		})
+	case errors.Is(err, usecase.ErrInvalidMetadata):
+		return c.JSON(http.StatusBadRequest, Problem{
+			Type:   errBase + "/invalid-metadata",
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: "Title must be at most 200 characters; at most 20 non-empty tags of up to 50 characters.",
+			Code:   "invalid_metadata",
+		})
+	case errors.Is(err, usecase.ErrClientBlocked):
+		return c.JSON(http.StatusForbidden, Problem{
+			Type:   errBase + "/client-blocked",
+			Title:  "Forbidden",
+			Status: http.StatusForbidden,
+			Detail: "This client has been blocked.",
+			Code:   "client_blocked",
 		})
 	case errors.Is(err, ports.ErrNotAssigned):
 		return c.JSON(http.StatusForbidden, Problem{
@@ -61,21 +191,77 @@ func writeErr(c echo.Context, err error) error {
 			Title:  "Forbidden",
 			Status: http.StatusForbidden,
 			Detail: "You are not assigned to this game. Use GET /api/v1/games/next first.",
+			Code:   "not_assigned",
 		})
 	case errors.Is(err, ports.ErrNoGamesAvailable):
-		return c.JSON(http.StatusServiceUnavailable, Problem{
+		return h.writeRetry(c, Problem{
 			Type:   errBase + "/no-games",
 			Title:  "Service Unavailable",
 			Status: http.StatusServiceUnavailable,
 			Detail: "No games available. Try again shortly.",
+			Code:   "no_games_available",
+		})
+	case errors.Is(err, context.DeadlineExceeded):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/timeout",
+			Title:  "Service Unavailable",
+			Status: http.StatusServiceUnavailable,
+			Detail: "The request took too long. Try again shortly.",
+			Code:   "request_timeout",
+		})
+	case errors.Is(err, ports.ErrStoreBusy):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/service-busy",
+			Title:  "Service Unavailable",
+			Status: http.StatusServiceUnavailable,
+			Detail: "The server is busy. Try again shortly.",
+			Code:   "service_busy",
+		})
+	case errors.As(err, &cooldown):
+		secs := int(math.Ceil(cooldown.RetryAfter.Seconds()))
+		c.Response().Header().Set("Retry-After", strconv.Itoa(secs))
+		// The wait is the cooldown's own remainder, so no jitter is added.
+		return c.JSON(http.StatusTooManyRequests, RetryProblem{
+			Problem: Problem{
+				Type:   errBase + "/move-cooldown",
+				Title:  "Too Many Requests",
+				Status: http.StatusTooManyRequests,
+				Detail: "You moved recently. Wait before moving again.",
+				Code:   "move_cooldown",
+			},
+			RetryAfterMS: cooldown.RetryAfter.Milliseconds(),
+		})
+	case errors.Is(err, usecase.ErrIPClaimLimit):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/ip-claim-limit",
+			Title:  "Too Many Requests",
+			Status: http.StatusTooManyRequests,
+			Detail: "Too many games claimed from this address. Try again later.",
+			Code:   "ip_claim_limit",
+		})
+	case errors.Is(err, usecase.ErrClaimsSaturated):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/claims-saturated",
+			Title:  "Too Many Requests",
+			Status: http.StatusTooManyRequests,
+			Detail: "Too many games are being claimed right now. Try again shortly.",
+			Code:   "claims_saturated",
+		})
+	case errors.Is(err, usecase.ErrClaimQueueTimeout):
+		return h.writeRetry(c, Problem{
+			Type:   errBase + "/claim-queue-timeout",
+			Title:  "Service Unavailable",
+			Status: http.StatusServiceUnavailable,
+			Detail: "Timed out waiting to claim a game. Try again shortly.",
+			Code:   "claim_queue_timeout",
 		})
 	case errors.Is(err, usecase.ErrRateLimited):
-		c.Response().Header().Set("Retry-After", "2")
-		return c.JSON(http.StatusTooManyRequests, Problem{
+		return h.writeRetry(c, Problem{
 			Type:   errBase + "/rate-limited",
 			Title:  "Too Many Requests",
 			Status: http.StatusTooManyRequests,
 			Detail: "Rate limit exceeded. Try again later.",
+			Code:   "rate_limited",
 		})
 	case errors.Is(err, game.ErrGameNotOngoing):
 		return c.JSON(http.StatusUnprocessableEntity, IllegalMoveProblem{
@@ -84,8 +270,16 @@ func writeErr(c echo.Context, err error) error {
 				Title:  "Unprocessable Entity",
 				Status: http.StatusUnprocessableEntity,
 				Detail: "Game is not ongoing.",
+				Code:   "game_not_ongoing",
 			},
-			Code: "game_not_ongoing",
+		})
+	case errors.Is(err, game.ErrInvalidDepth):
+		return c.JSON(http.StatusBadRequest, Problem{
+			Type:   errBase + "/invalid-depth",
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: fmt.Sprintf("depth must be between 0 and %d.", usecase.MaxPerftDepth),
+			Code:   "invalid_depth",
 		})
 	case errors.Is(err, game.ErrInvalidUCI):
 		return c.JSON(http.StatusUnprocessableEntity, IllegalMoveProblem{
@@ -94,8 +288,18 @@ func writeErr(c echo.Context, err error) error {
 				Title:  "Unprocessable Entity",
 				Status: http.StatusUnprocessableEntity,
 				Detail: "Move string is not valid UCI notation.",
+				Code:   "invalid_uci",
+			},
+		})
+	case errors.Is(err, game.ErrWouldBeInCheck):
+		return c.JSON(http.StatusUnprocessableEntity, IllegalMoveProblem{
+			Problem: Problem{
+				Type:   errBase + "/illegal-move",
+				Title:  "Unprocessable Entity",
+				Status: http.StatusUnprocessableEntity,
+				Detail: "Move would leave your own king in check.",
+				Code:   "would_be_in_check",
 			},
-			Code: "invalid_uci",
 		})
 	case errors.Is(err, game.ErrIllegalMove):
 		return c.JSON(http.StatusUnprocessableEntity, IllegalMoveProblem{
@@ -104,8 +308,8 @@ func writeErr(c echo.Context, err error) error {
 				Title:  "Unprocessable Entity",
 				Status: http.StatusUnprocessableEntity,
 				Detail: "Move is not legal in the current position.",
+				Code:   "illegal_move",
 			},
-			Code: "illegal_move",
 		})
 	default:
 		return c.JSON(http.StatusInternalServerError, Problem{
@@ -113,6 +317,7 @@ func writeErr(c echo.Context, err error) error {
 			Title:  "Internal Server Error",
 			Status: http.StatusInternalServerError,
 			Detail: "Unexpected error.",
+			Code:   "internal_error",
 		})
 	}
 }
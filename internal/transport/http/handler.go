@@ -1,7 +1,15 @@
 package http
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
+	"runtime/debug"
+	"slices"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,59 +22,124 @@ import (
 
 // moveHistoryJSON is the wire representation of a single move in history.
 type moveHistoryJSON struct {
-	Ply       int        `json:"ply"`
-	UCI       string     `json:"uci"`
-	From      string     `json:"from"`
-	To        string     `json:"to"`
-	Promotion *string    `json:"promotion,omitempty"`
-	ClientID  string     `json:"client_id"`
-	FENBefore string     `json:"fen_before"`
-	FENAfter  string     `json:"fen_after"`
-	CreatedAt time.Time  `json:"created_at"`
+	Ply         int       `json:"ply"`
+	UCI         string    `json:"uci"`
+	From        string    `json:"from"`
+	To          string    `json:"to"`
+	Promotion   *string   `json:"promotion,omitempty"`
+	ClientID    string    `json:"client_id"`
+	FENBefore   string    `json:"fen_before"`
+	FENAfter    string    `json:"fen_after"`
+	IsCapture   bool      `json:"is_capture"`
+	IsEnPassant bool      `json:"is_en_passant"`
+	IsCastle    bool      `json:"is_castle"`
+	IsBlunder   bool      `json:"is_blunder"`
+	CreatedAt   time.Time `json:"created_at"`
+	// Notations is only set when the caller asks for ?notation=.
+	Notations map[string]string `json:"notations,omitempty"`
+}
+
+// gameStateJSON is a game without its move history.
+type gameStateJSON struct {
+	GameID       string     `json:"game_id"`
+	Status       string     `json:"status"`
+	Result       *string    `json:"result"`
+	FEN          string     `json:"fen"`
+	SideToMove   string     `json:"side_to_move"`
+	PlyCount     int        `json:"ply_count"`
+	LastMoveUCI  *string    `json:"last_move_uci"`
+	LastMoveAt   *time.Time `json:"last_move_at"`
+	StateVersion int        `json:"state_version"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+	Title        *string    `json:"title"`
+	Tags         []string   `json:"tags"`
+	// EvalCP is an approximate heuristic evaluation, only set on request.
+	EvalCP *int `json:"eval_cp,omitempty"`
+	// PieceCounts maps "wP", "bK", ... to their count, only set on request.
+	PieceCounts map[string]int `json:"piece_counts,omitempty"`
 }
 
 // gameJSON is the wire representation of domain/game.Game (matches contract,
 // extended with move_history).
 type gameJSON struct {
-	GameID       string            `json:"game_id"`
-	Status       string            `json:"status"`
-	Result       *string           `json:"result"`
-	FEN          string            `json:"fen"`
-	SideToMove   string            `json:"side_to_move"`
-	PlyCount     int               `json:"ply_count"`
-	LastMoveUCI  *string           `json:"last_move_uci"`
-	LastMoveAt   *time.Time        `json:"last_move_at"`
-	StateVersion int               `json:"state_version"`
-	CreatedAt    time.Time         `json:"created_at"`
-	UpdatedAt    time.Time         `json:"updated_at"`
-	MoveHistory  []moveHistoryJSON `json:"move_history"`
+	gameStateJSON
+	MoveHistory     []moveHistoryJSON `json:"move_history"`
+	DrawClaimable   bool              `json:"draw_claimable"`
+	DrawClaimReason string            `json:"draw_claim_reason,omitempty"`
+	Phase           string            `json:"phase"`
+}
+
+// lastMoveJSON is the claim response's summary of the move to highlight. SAN
+// is empty when move history is not persisted.
+type lastMoveJSON struct {
+	UCI      string     `json:"uci"`
+	From     string     `json:"from"`
+	To       string     `json:"to"`
+	SAN      string     `json:"san"`
+	PlayedAt *time.Time `json:"played_at"`
+}
+
+func toLastMoveJSON(m *usecase.LastMove) *lastMoveJSON {
+	if m == nil {
+		return nil
+	}
+	return &lastMoveJSON{UCI: m.UCI, From: m.From, To: m.To, SAN: m.SAN, PlayedAt: m.PlayedAt}
+}
+
+// gameDetailJSON is the single-game view, whose history may be windowed.
+type gameDetailJSON struct {
+	*gameJSON
+	HistoryTruncated   bool `json:"history_truncated"`
+	HistoryUnavailable bool `json:"history_unavailable,omitempty"`
+	HistoryDisabled    bool `json:"history_disabled,omitempty"`
+	TotalPlies         int  `json:"total_plies"`
 }
 
 func toMoveHistoryJSON(items []game.MoveHistoryItem) []moveHistoryJSON {
 	out := make([]moveHistoryJSON, len(items))
 	for i, item := range items {
 		out[i] = moveHistoryJSON{
-			Ply:       item.Ply,
-			UCI:       item.UCI,
-			From:      item.FromSq,
-			To:        item.ToSq,
-			Promotion: item.Promotion,
-			ClientID:  item.ClientID.String(),
-			FENBefore: item.FENBefore,
-			FENAfter:  item.FENAfter,
-			CreatedAt: item.CreatedAt,
+			Ply:         item.Ply,
+			UCI:         item.UCI,
+			From:        item.FromSq,
+			To:          item.ToSq,
+			Promotion:   item.Promotion,
+			ClientID:    item.ClientID.String(),
+			FENBefore:   item.FENBefore,
+			FENAfter:    item.FENAfter,
+			IsCapture:   item.IsCapture,
+			IsEnPassant: item.IsEnPassant,
+			IsCastle:    item.IsCastle,
+			IsBlunder:   item.IsBlunder,
+			CreatedAt:   item.CreatedAt,
 		}
 	}
 	return out
 }
 
 func toGameJSON(g *game.Game, history []game.MoveHistoryItem) *gameJSON {
+	claimable, reason := g.DrawClaimable(history)
+	return &gameJSON{
+		gameStateJSON:   toGameStateJSON(g),
+		MoveHistory:     toMoveHistoryJSON(history),
+		DrawClaimable:   claimable,
+		DrawClaimReason: reason,
+		Phase:           g.Phase(),
+	}
+}
+
+func toGameStateJSON(g *game.Game) gameStateJSON {
 	var result *string
 	if g.Result != nil {
 		s := string(*g.Result)
 		result = &s
 	}
-	return &gameJSON{
+	tags := g.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	return gameStateJSON{
 		GameID:       g.ID.String(),
 		Status:       string(g.Status),
 		Result:       result,
@@ -78,44 +151,92 @@ func toGameJSON(g *game.Game, history []game.MoveHistoryItem) *gameJSON {
 		StateVersion: g.StateVersion,
 		CreatedAt:    g.CreatedAt,
 		UpdatedAt:    g.UpdatedAt,
-		MoveHistory:  toMoveHistoryJSON(history),
+		Title:        g.Title,
+		Tags:         tags,
 	}
 }
 
+// errResponded is returned by request parsers that have already written the
+// rejection; the handler returns nil without writing anything else.
+var errResponded = errors.New("response already written")
+
+// rejected writes p and returns errResponded.
+func rejected(c echo.Context, p Problem) error {
+	_ = c.JSON(p.Status, p)
+	return errResponded
+}
+
 // parseClientID reads and validates the client identity header.
 // It prefers X-Client-Id; falls back to X-Client-Token for backward compat
-// with older frontends that do not yet send X-Client-Id.
-func parseClientID(c echo.Context) (uuid.UUID, error) {
+// with older frontends that do not yet send X-Client-Id. The nil UUID and
+// configured placeholder IDs are rejected, since every client sending them
+// would share one identity.
+func (h *Handlers) parseClientID(c echo.Context) (uuid.UUID, error) {
 	raw := c.Request().Header.Get("X-Client-Id")
 	if raw == "" {
 		raw = c.Request().Header.Get("X-Client-Token")
 	}
 	if raw == "" {
-		return uuid.Nil, c.JSON(http.StatusBadRequest, Problem{
+		return uuid.Nil, rejected(c, Problem{
 			Type:   errBase + "/missing-client-id",
 			Title:  "Bad Request",
 			Status: http.StatusBadRequest,
 			Detail: "X-Client-Id header is required (UUID).",
+			Code:   "missing_client_id",
 		})
 	}
 	id, err := uuid.Parse(raw)
 	if err != nil {
-		return uuid.Nil, c.JSON(http.StatusBadRequest, Problem{
+		return uuid.Nil, rejected(c, Problem{
 			Type:   errBase + "/invalid-client-id",
 			Title:  "Bad Request",
 			Status: http.StatusBadRequest,
 			Detail: "X-Client-Id must be a valid UUID.",
+			Code:   "invalid_client_id",
 		})
 	}
+	if h.isPlaceholder(id) {
+		return uuid.Nil, rejected(c, placeholderClientID("X-Client-Id"))
+	}
 	return id, nil
 }
 
+// isPlaceholder reports whether id is the nil UUID or a configured
+// placeholder, which every client sending it would share.
+func (h *Handlers) isPlaceholder(id uuid.UUID) bool {
+	_, placeholder := h.placeholderIDs[id]
+	return placeholder || id == uuid.Nil
+}
+
+// placeholderClientID is the rejection for a placeholder ID sent in header.
+func placeholderClientID(header string) Problem {
+	return Problem{
+		Type:   errBase + "/placeholder-client-id",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: header + " must be a unique random UUID per client; the nil UUID and known placeholder IDs are rejected.",
+		Code:   "placeholder_client_id",
+	}
+}
+
 // Handlers holds all usecase dependencies.
 type Handlers struct {
 	assigner  *usecase.Assigner
 	nextGame  *usecase.NextGame
 	getter    *usecase.GameGetter
 	submitter *usecase.MoveSubmitter
+	admin     *usecase.Admin
+
+	// placeholderIDs are client IDs rejected by parseClientID; set by New.
+	placeholderIDs map[uuid.UUID]struct{}
+	// cursors signs pagination cursors; set by New.
+	cursors cursorCodec
+	// retry sets the retry hint on 429/503 responses; set by New.
+	retry retryPolicy
+	// strictMoveInput rejects moves sending both uci and from/to; set by New.
+	strictMoveInput bool
+	// finishedMaxAge lets caches keep finished-game artifacts; set by New.
+	finishedMaxAge time.Duration
 }
 
 func NewHandlers(
@@ -123,27 +244,66 @@ func NewHandlers(
 	nextGame *usecase.NextGame,
 	getter *usecase.GameGetter,
 	submitter *usecase.MoveSubmitter,
+	admin *usecase.Admin,
 ) *Handlers {
-	return &Handlers{assigner: assigner, nextGame: nextGame, getter: getter, submitter: submitter}
+	return &Handlers{assigner: assigner, nextGame: nextGame, getter: getter, submitter: submitter, admin: admin}
 }
 
 func (h *Handlers) handleHealthz(c echo.Context) error {
 	return c.JSON(http.StatusOK, map[string]bool{"ok": true})
 }
 
+// chessModule is the chess library whose version handleVersion reports.
+const chessModule = "github.com/notnil/chess"
+
+// handleVersion reports the build, the chess library it was built with and
+// the ruleset that library is used to enforce.
+func (h *Handlers) handleVersion(c echo.Context) error {
+	version, chessVersion := "unknown", "unknown"
+	if info, ok := debug.ReadBuildInfo(); ok {
+		version = info.Main.Version
+		for _, dep := range info.Deps {
+			if dep.Path == chessModule {
+				chessVersion = dep.Version
+			}
+		}
+	}
+	rules := game.Rules()
+	return c.JSON(http.StatusOK, map[string]any{
+		"version": version,
+		"chess_library": map[string]string{
+			"module":  chessModule,
+			"version": chessVersion,
+		},
+		"ruleset": map[string]bool{
+			"underpromotion":                 rules.Underpromotion,
+			"auto_insufficient_material":     rules.AutoInsufficientMaterial,
+			"auto_seventy_five_move_rule":    rules.AutoSeventyFiveMove,
+			"auto_fivefold_repetition":       rules.AutoFivefold,
+			"claimable_threefold_repetition": rules.ClaimableThreefold,
+			"claimable_fifty_move_rule":      rules.ClaimableFiftyMove,
+		},
+	})
+}
+
 // handleGetAssigned is the legacy endpoint.
 // When the X-Client-Token is a valid UUID it delegates to the NextGame usecase
 // so the client gets registered in game_players and can subsequently submit moves.
+// Placeholder UUIDs are rejected as in parseClientID.
 func (h *Handlers) handleGetAssigned(c echo.Context) error {
 	ip := c.RealIP()
 	token := c.Request().Header.Get("X-Client-Token")
 
 	if clientID, err := uuid.Parse(token); err == nil {
+		if h.isPlaceholder(clientID) {
+			return c.JSON(http.StatusBadRequest, placeholderClientID("X-Client-Token"))
+		}
 		res, err := h.nextGame.GetNext(c.Request().Context(), ip, token, clientID)
 		if err != nil {
-			return writeErr(c, err)
+			return h.writeErr(c, err)
 		}
 		c.Response().Header().Set("Cache-Control", "no-store")
+		setGameHeaders(c, res.Game)
 		return c.JSON(http.StatusOK, map[string]any{
 			"game": toGameJSON(res.Game, res.History),
 			"assignment": map[string]any{
@@ -156,9 +316,10 @@ func (h *Handlers) handleGetAssigned(c echo.Context) error {
 	// Fallback: non-UUID token — legacy path, no client tracking.
 	res, err := h.assigner.Assign(c.Request().Context(), ip, token)
 	if err != nil {
-		return writeErr(c, err)
+		return h.writeErr(c, err)
 	}
 	c.Response().Header().Set("Cache-Control", "no-store")
+	setGameHeaders(c, res.Game)
 	return c.JSON(http.StatusOK, map[string]any{
 		"game": toGameJSON(res.Game, []game.MoveHistoryItem{}),
 		"assignment": map[string]any{
@@ -171,9 +332,9 @@ func (h *Handlers) handleGetAssigned(c echo.Context) error {
 // handleGetNext returns a game that this client has not yet played, claiming it
 // for the client. Requires X-Client-Id header.
 func (h *Handlers) handleGetNext(c echo.Context) error {
-	clientID, err := parseClientID(c)
+	clientID, err := h.parseClientID(c)
 	if err != nil {
-		return err // response already written
+		return nil // response already written
 	}
 
 	ip := c.RealIP()
@@ -181,45 +342,365 @@ func (h *Handlers) handleGetNext(c echo.Context) error {
 
 	res, err := h.nextGame.GetNext(c.Request().Context(), ip, token, clientID)
 	if err != nil {
-		return writeErr(c, err)
+		return h.writeErr(c, err)
 	}
 
 	c.Response().Header().Set("Cache-Control", "no-store")
+	setGameHeaders(c, res.Game)
 	return c.JSON(http.StatusOK, map[string]any{
-		"game": toGameJSON(res.Game, res.History),
+		"game":      toGameJSON(res.Game, res.History),
+		"last_move": toLastMoveJSON(res.LastMove),
 	})
 }
 
+// handleGetGame returns the game as JSON, or as PGN or its FEN when the
+// Accept header asks for application/x-chess-pgn or text/plain.
 func (h *Handlers) handleGetGame(c echo.Context) error {
 	ip := c.RealIP()
 	token := c.Request().Header.Get("X-Client-Token")
 
 	id, err := uuid.Parse(c.Param("game_id"))
 	if err != nil {
-		return writeErr(c, ports.ErrNotFound)
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	format, ok := negotiateGameFormat(c.Request().Header.Get(echo.HeaderAccept))
+	if !ok {
+		return notAcceptable(c)
+	}
+
+	// PGN replays the game from the start, so it needs every move.
+	fullHistory, _ := strconv.ParseBool(c.QueryParam("full_history"))
+	detail, err := h.getter.GetGame(c.Request().Context(), ip, token, id, fullHistory || format == formatPGN)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	g, hist := detail.Game, detail.History
+
+	c.Response().Header().Add(echo.HeaderVary, echo.HeaderAccept)
+	switch format {
+	case formatPGN:
+		if err := detail.HistoryErr(); err != nil {
+			return h.writeErr(c, err)
+		}
+		pgn, err := g.PGN(hist)
+		if err != nil {
+			return h.writeErr(c, err)
+		}
+		setGameHeaders(c, g)
+		h.setArtifactCache(c, g)
+		return c.Blob(http.StatusOK, mimePGN, []byte(pgn))
+	case formatFEN:
+		setGameHeaders(c, g)
+		h.setArtifactCache(c, g)
+		return c.String(http.StatusOK, g.FEN)
+	}
+
+	resp := gameDetailJSON{
+		gameJSON:           toGameJSON(g, hist),
+		HistoryTruncated:   !detail.HistoryUnavailable && !detail.HistoryDisabled && len(hist) < g.PlyCount,
+		HistoryUnavailable: detail.HistoryUnavailable,
+		HistoryDisabled:    detail.HistoryDisabled,
+		TotalPlies:         g.PlyCount,
+	}
+	if includeEval, _ := strconv.ParseBool(c.QueryParam("include_eval")); includeEval {
+		eval := g.HeuristicEval()
+		resp.EvalCP = &eval
+	}
+	if includeCounts, _ := strconv.ParseBool(c.QueryParam("include_piece_counts")); includeCounts {
+		resp.PieceCounts = g.PieceCounts()
+	}
+
+	setGameHeaders(c, g)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, resp)
+}
+
+// handleRandomGame serves one random ongoing game for a "watch a random
+// game" button. Nothing is claimed.
+func (h *Handlers) handleRandomGame(c echo.Context) error {
+	g, err := h.getter.RandomOngoing(c.Request().Context(), c.RealIP(), c.Request().Header.Get("X-Client-Token"))
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	setGameHeaders(c, g)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, toGameStateJSON(g))
+}
+
+// handleStreamGames writes every game matching ?status= and ?result= as
+// NDJSON, one gameStateJSON per line, flushing each line so consumers can
+// process a bulk export as it arrives.
+func (h *Handlers) handleStreamGames(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+	status := game.Status(c.QueryParam("status"))
+	if status != "" && !status.Valid() {
+		return badQuery(c, "status must be one of waiting, ongoing, checkmate, stalemate, draw, resigned.")
+	}
+	result := game.Result(c.QueryParam("result"))
+	if result != "" && !result.Valid() {
+		return badQuery(c, "result must be one of 1-0, 0-1, 1/2-1/2.")
+	}
+	filter := ports.GameFilter{Status: status, Result: result}
+
+	ctx := c.Request().Context()
+	res := c.Response()
+	enc := json.NewEncoder(res)
+	started := false
+	start := func() {
+		if !started {
+			res.Header().Set(echo.HeaderContentType, "application/x-ndjson")
+			res.Header().Set("Cache-Control", "no-store")
+			res.WriteHeader(http.StatusOK)
+			started = true
+		}
+	}
+	err := h.getter.StreamGames(ctx, ip, token, filter, func(g *game.Game) error {
+		start()
+		if err := enc.Encode(toGameStateJSON(g)); err != nil {
+			return err
+		}
+		res.Flush()
+		return nil
+	})
+	switch {
+	case err == nil:
+		start()
+	case ctx.Err() != nil:
+		// The client went away; nobody is left to tell.
+	case started:
+		// The status line is already out, so the error can only be logged.
+		log.Printf("stream games: %v", err)
+	default:
+		return h.writeErr(c, err)
+	}
+	return nil
+}
+
+// handleListChanged serves the incremental sync feed. The first request
+// passes since; later pages pass back next_cursor, a signed (updated_at, id)
+// position, so ties on updated_at never skip a game.
+func (h *Handlers) handleListChanged(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	var (
+		since   time.Time
+		afterID = uuid.Nil
+		err     error
+	)
+	if v := c.QueryParam("cursor"); v != "" {
+		if since, afterID, err = h.cursors.decode(v); err != nil {
+			return c.JSON(http.StatusBadRequest, Problem{
+				Type:   errBase + "/invalid-cursor",
+				Title:  "Bad Request",
+				Status: http.StatusBadRequest,
+				Detail: "cursor was not issued by this server.",
+				Code:   "invalid_cursor",
+			})
+		}
+	} else if since, err = time.Parse(time.RFC3339Nano, c.QueryParam("since")); err != nil {
+		return badQuery(c, "since must be an RFC 3339 timestamp, or pass cursor.")
+	}
+	var limit int
+	if v := c.QueryParam("limit"); v != "" {
+		if limit, err = strconv.Atoi(v); err != nil || limit < 1 {
+			return badQuery(c, "limit must be a positive integer.")
+		}
+	}
+
+	games, err := h.getter.ListChanged(c.Request().Context(), ip, token, since, afterID, limit)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	items := make([]gameStateJSON, len(games))
+	for i, g := range games {
+		items[i] = toGameStateJSON(g)
+	}
+	// An empty page keeps the caller's position.
+	if len(games) > 0 {
+		last := games[len(games)-1]
+		since, afterID = last.UpdatedAt, last.ID
+	}
+	nextCursor := h.cursors.encode(since, afterID)
+
+	// The first page of the feed starts at the caller's since, or at the
+	// beginning of time when paging by cursor alone.
+	first := c.Request().URL.Query()
+	first.Del("cursor")
+	if first.Get("since") == "" {
+		first.Set("since", time.Unix(0, 0).UTC().Format(time.RFC3339))
+	}
+	var next string
+	if pageFull(len(games), limit, usecase.DefaultChangedLimit, usecase.MaxChangedLimit) {
+		next = nextCursor
+	}
+	setPageLinks(c, first, next)
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{
+		"games":       items,
+		"next_cursor": nextCursor,
+	})
+}
+
+// badQuery writes a 400 Problem for a malformed query parameter.
+func badQuery(c echo.Context, detail string) error {
+	return c.JSON(http.StatusBadRequest, Problem{
+		Type:   errBase + "/invalid-query",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Code:   "invalid_query",
+	})
+}
+
+// handleContributors lists the clients that moved in a game, in order of
+// their first move.
+func (h *Handlers) handleContributors(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
 	}
 
-	g, hist, err := h.getter.GetGame(c.Request().Context(), ip, token, id)
+	contributors, err := h.getter.Contributors(c.Request().Context(), ip, token, id)
 	if err != nil {
-		return writeErr(c, err)
+		return h.writeErr(c, err)
 	}
 
+	out := make([]map[string]any, len(contributors))
+	for i, ct := range contributors {
+		out[i] = map[string]any{
+			"client_id": ct.ClientID.String(),
+			"first_ply": ct.FirstPly,
+		}
+	}
 	c.Response().Header().Set("Cache-Control", "no-store")
-	return c.JSON(http.StatusOK, toGameJSON(g, hist))
+	return c.JSON(http.StatusOK, map[string]any{
+		"game_id":      id.String(),
+		"contributors": out,
+	})
+}
+
+// handleGameSummary returns the starting position and the moves as UCI, for
+// clients that rebuild the board themselves.
+func (h *Handlers) handleGameSummary(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	detail, err := h.getter.GetGame(c.Request().Context(), ip, token, id, true)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	if err := detail.HistoryErr(); err != nil {
+		return h.writeErr(c, err)
+	}
+	g, hist := detail.Game, detail.History
+
+	startingFEN := game.StartingFEN
+	if len(hist) > 0 {
+		startingFEN = hist[0].FENBefore
+	}
+	moves := make([]string, len(hist))
+	for i, item := range hist {
+		moves[i] = item.UCI
+	}
+	var result *string
+	if g.Result != nil {
+		s := string(*g.Result)
+		result = &s
+	}
+
+	setGameHeaders(c, g)
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{
+		"game_id":      g.ID.String(),
+		"status":       string(g.Status),
+		"result":       result,
+		"starting_fen": startingFEN,
+		"moves":        moves,
+		"current_fen":  g.FEN,
+		"ply_count":    g.PlyCount,
+	})
+}
+
+// handleTheoretical reports the known theoretical result of the current
+// position, from the side to move's perspective.
+func (h *Handlers) handleTheoretical(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	g, result, known, err := h.getter.TheoreticalResult(c.Request().Context(), ip, token, id)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	h.setArtifactCache(c, g)
+	return c.JSON(http.StatusOK, map[string]any{
+		"game_id":      g.ID.String(),
+		"fen":          g.FEN,
+		"side_to_move": g.SideToMove,
+		"result":       result,
+		"known":        known,
+	})
+}
+
+// handlePerft counts the leaf nodes of the move tree below the current
+// position, to a small capped depth.
+func (h *Handlers) handlePerft(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+	depth, err := strconv.Atoi(c.QueryParam("depth"))
+	if err != nil {
+		return badQuery(c, "depth must be an integer.")
+	}
+
+	g, nodes, err := h.getter.Perft(c.Request().Context(), ip, token, id, depth)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	h.setArtifactCache(c, g)
+	return c.JSON(http.StatusOK, map[string]any{
+		"game_id": g.ID.String(),
+		"fen":     g.FEN,
+		"depth":   depth,
+		"nodes":   nodes,
+	})
 }
 
 func (h *Handlers) handleSubmitMove(c echo.Context) error {
 	ip := c.RealIP()
 	token := c.Request().Header.Get("X-Client-Token")
 
-	clientID, err := parseClientID(c)
+	clientID, err := h.parseClientID(c)
 	if err != nil {
-		return err // response already written
+		return nil // response already written
 	}
 
 	id, err := uuid.Parse(c.Param("game_id"))
 	if err != nil {
-		return writeErr(c, ports.ErrNotFound)
+		return h.writeErr(c, ports.ErrNotFound)
 	}
 
 	var body struct {
@@ -230,15 +711,39 @@ func (h *Handlers) handleSubmitMove(c echo.Context) error {
 		To        string  `json:"to"`
 		Promotion *string `json:"promotion"`
 		// Optimistic concurrency.
-		ExpectedVersion int     `json:"expected_version"`
+		ExpectedVersion *int    `json:"expected_version"`
 		ClientNonce     *string `json:"client_nonce"`
 	}
 	if bindErr := c.Bind(&body); bindErr != nil {
-		return writeErr(c, bindErr)
+		return h.writeErr(c, bindErr)
 	}
 
-	// Resolve UCI: prefer from/to over the uci field.
+	// Resolve UCI: prefer from/to over the uci field, unless strict input
+	// asks for the ambiguity to be reported.
+	if h.strictMoveInput && body.UCI != "" && (body.From != "" || body.To != "") {
+		return c.JSON(http.StatusBadRequest, Problem{
+			Type:   errBase + "/ambiguous-move-input",
+			Title:  "Bad Request",
+			Status: http.StatusBadRequest,
+			Detail: "Send either uci or from/to, not both.",
+			Code:   "ambiguous_move_input",
+		})
+	}
 	uci := body.UCI
+	if body.From != "" || body.To != "" {
+		if field, ok := invalidMoveField(body.From, body.To, body.Promotion); !ok {
+			return c.JSON(http.StatusBadRequest, FieldProblem{
+				Problem: Problem{
+					Type:   errBase + "/invalid-move-field",
+					Title:  "Bad Request",
+					Status: http.StatusBadRequest,
+					Detail: invalidMoveFieldDetail[field],
+					Code:   "invalid_move_field",
+				},
+				Field: field,
+			})
+		}
+	}
 	if body.From != "" && body.To != "" {
 		uci = body.From + body.To
 		if body.Promotion != nil {
@@ -246,18 +751,45 @@ func (h *Handlers) handleSubmitMove(c echo.Context) error {
 		}
 	}
 	if uci == "" {
-		return writeErr(c, game.ErrInvalidUCI)
+		return h.writeErr(c, game.ErrInvalidUCI)
+	}
+
+	includeLegal, _ := strconv.ParseBool(c.QueryParam("include_legal"))
+	includeChanges, _ := strconv.ParseBool(c.QueryParam("include_changes"))
+	groupBy := c.QueryParam("group_by")
+	if groupBy != "" && groupBy != "from" {
+		return badQuery(c, "group_by must be from.")
+	}
+
+	var notations []game.Notation
+	if v := c.QueryParam("notation"); v != "" {
+		if notations, err = game.ParseNotations(v); err != nil {
+			return badQuery(c, "notation must be a comma-separated list of uci, san, lan.")
+		}
 	}
 
 	req := usecase.SubmitMoveRequest{
 		UCI:             uci,
 		ExpectedVersion: body.ExpectedVersion,
 		ClientNonce:     body.ClientNonce,
+		IncludeLegal:    includeLegal,
+		UserAgent:       c.Request().UserAgent(),
 	}
 
 	res, err := h.submitter.SubmitMove(c.Request().Context(), ip, token, id, clientID, req)
 	if err != nil {
-		return writeErr(c, err)
+		return h.writeErr(c, err)
+	}
+
+	c.Response().Header().Add("Vary", "Prefer")
+	setGameHeaders(c, res.Game)
+	if prefersMinimal(c.Request().Header.Values("Prefer")) {
+		c.Response().Header().Set("Preference-Applied", "return=minimal")
+		return c.JSON(http.StatusOK, map[string]any{
+			"accepted":      true,
+			"state_version": res.Game.StateVersion,
+			"fen":           res.Game.FEN,
+		})
 	}
 
 	var nextHint any
@@ -265,17 +797,280 @@ func (h *Handlers) handleSubmitMove(c echo.Context) error {
 		nextHint = map[string]any{"should_fetch_next": true}
 	}
 
+	move := map[string]any{
+		"move_id":        res.Move.ID.String(),
+		"uci":            res.Move.UCI,
+		"fen_before":     res.Move.FENBefore,
+		"fen_after":      res.Move.FENAfter,
+		"is_capture":     res.Move.IsCapture,
+		"is_en_passant":  res.Move.IsEnPassant,
+		"is_castle":      res.Move.IsCastle,
+		"gave_check":     res.Move.GaveCheck,
+		"is_blunder":     res.Move.IsBlunder,
+		"was_first_move": res.WasFirstMove,
+		"created_at":     res.Move.CreatedAt,
+	}
+	// Echo the nonce so fire-and-forget clients can match retries to
+	// responses.
+	if body.ClientNonce != nil {
+		move["client_nonce"] = *body.ClientNonce
+	}
+	if includeChanges {
+		move["changes"] = toSquareChangesJSON(game.BoardDiff(res.Move.FENBefore, res.Move.FENAfter))
+	}
+	gameBody := toGameJSON(res.Game, res.History)
+	if notations != nil {
+		if move["notations"], err = notate(res.Move.FENBefore, res.Move.UCI, notations); err != nil {
+			return h.writeErr(c, err)
+		}
+		for i := range gameBody.MoveHistory {
+			item := &gameBody.MoveHistory[i]
+			if item.Notations, err = notate(item.FENBefore, item.UCI, notations); err != nil {
+				return h.writeErr(c, err)
+			}
+		}
+	}
+
+	resp := map[string]any{
+		"accepted":             true,
+		"move":                 move,
+		"game":                 gameBody,
+		"next_assignment_hint": nextHint,
+	}
+	if includeLegal {
+		resp["legal_moves"] = res.LegalMoves
+		if groupBy == "from" {
+			resp["legal_moves"] = groupLegalByFrom(res.LegalMoves)
+		}
+	}
+
 	c.Response().Header().Set("Cache-Control", "no-store")
-	return c.JSON(http.StatusOK, map[string]any{
-		"accepted": true,
-		"move": map[string]any{
-			"move_id":    res.Move.ID.String(),
-			"uci":        res.Move.UCI,
-			"fen_before": res.Move.FENBefore,
-			"fen_after":  res.Move.FENAfter,
-			"created_at": res.Move.CreatedAt,
+	return c.JSON(http.StatusOK, resp)
+}
+
+// groupLegalByFrom maps each origin square of moves to the rest of the UCI
+// string: the destination, followed by the promotion piece when there is one,
+// so a pawn reaching the last rank lists e8q, e8r, e8b and e8n.
+func groupLegalByFrom(moves []string) map[string][]string {
+	out := make(map[string][]string)
+	for _, uci := range moves {
+		out[uci[:2]] = append(out[uci[:2]], uci[2:])
+	}
+	return out
+}
+
+// squareChangeJSON is one entry of a move's changes array.
+type squareChangeJSON struct {
+	Square      string `json:"square"`
+	BeforePiece string `json:"before_piece"`
+	AfterPiece  string `json:"after_piece"`
+}
+
+func toSquareChangesJSON(changes []game.SquareChange) []squareChangeJSON {
+	out := make([]squareChangeJSON, len(changes))
+	for i, ch := range changes {
+		out[i] = squareChangeJSON(ch)
+	}
+	return out
+}
+
+// notate writes uci, played from fenBefore, in each of notations.
+func notate(fenBefore, uci string, notations []game.Notation) (map[string]string, error) {
+	out := make(map[string]string, len(notations))
+	for _, n := range notations {
+		s, err := game.Notate(fenBefore, uci, n)
+		if err != nil {
+			return nil, err
+		}
+		out[string(n)] = s
+	}
+	return out, nil
+}
+
+// movesContract describes the input accepted by POST /games/:game_id/moves.
+var movesContract = map[string]any{
+	"methods":      []string{"POST", "OPTIONS"},
+	"content_type": "application/json",
+	"headers": map[string]string{
+		"X-Client-Id": "required; UUID identifying the client",
+		"Prefer":      "optional; return=minimal answers with only accepted, state_version and fen",
+	},
+	"query": map[string]string{
+		"include_legal":   "optional boolean; adds legal_moves for the resulting position",
+		"include_changes": "optional boolean; adds the move's changed squares as move.changes",
+		"group_by":        "optional; from returns legal_moves as destinations keyed by origin square",
+		"notation":        "optional comma-separated list of uci, san, lan; adds notations to the move and history",
+	},
+	"move_forms": []map[string]string{
+		{"uci": "move in UCI notation, e.g. e2e4 or e7e8q"},
+		{
+			"from":      "origin square, e.g. e2",
+			"to":        "destination square, e.g. e4",
+			"promotion": "optional; one of q, r, b, n",
 		},
-		"game":                 toGameJSON(res.Game, res.History),
-		"next_assignment_hint": nextHint,
+	},
+	"fields": map[string]string{
+		"expected_version": "integer; the game's current state_version, required once the game has moves",
+		"client_nonce":     "optional string",
+	},
+}
+
+// handleMovesOptions answers a plain OPTIONS on the moves route with the
+// route's input contract.
+func (h *Handlers) handleMovesOptions(c echo.Context) error {
+	c.Response().Header().Set(echo.HeaderAllow, "POST, OPTIONS")
+	return c.JSON(http.StatusOK, movesContract)
+}
+
+// draftMaxBody caps a move draft body; drafts are three short strings.
+const draftMaxBody = "1K"
+
+// moveDraftJSON is a saved move draft. Its fields are stored as sent.
+type moveDraftJSON struct {
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Promotion string    `json:"promotion,omitempty"`
+	SavedAt   time.Time `json:"saved_at"`
+}
+
+func toMoveDraftJSON(d usecase.MoveDraft) moveDraftJSON {
+	return moveDraftJSON{From: d.From, To: d.To, Promotion: d.Promotion, SavedAt: d.SavedAt}
+}
+
+// handlePutDraft stashes the move the client is building in a game so it can
+// be restored after a reconnect. Requires X-Client-Id header.
+func (h *Handlers) handlePutDraft(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	clientID, err := h.parseClientID(c)
+	if err != nil {
+		return nil // response already written
+	}
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	var body struct {
+		From      string `json:"from"`
+		To        string `json:"to"`
+		Promotion string `json:"promotion"`
+	}
+	if bindErr := c.Bind(&body); bindErr != nil {
+		return h.writeErr(c, bindErr)
+	}
+
+	draft, err := h.submitter.SaveDraft(c.Request().Context(), ip, token, id, clientID, usecase.MoveDraft{
+		From:      body.From,
+		To:        body.To,
+		Promotion: body.Promotion,
+	})
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, toMoveDraftJSON(draft))
+}
+
+// handleGetDraft returns the client's saved draft for a game. Requires
+// X-Client-Id header.
+func (h *Handlers) handleGetDraft(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	clientID, err := h.parseClientID(c)
+	if err != nil {
+		return nil // response already written
+	}
+	id, err := uuid.Parse(c.Param("game_id"))
+	if err != nil {
+		return h.writeErr(c, ports.ErrNotFound)
+	}
+
+	draft, err := h.submitter.Draft(c.Request().Context(), ip, token, id, clientID)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, toMoveDraftJSON(draft))
+}
+
+// handleAvailableCount reports how many games the client has not yet claimed,
+// so it can skip /games/next when nothing is left.
+func (h *Handlers) handleAvailableCount(c echo.Context) error {
+	ip := c.RealIP()
+	token := c.Request().Header.Get("X-Client-Token")
+
+	clientID, err := parseClientIDParam(c)
+	if err != nil {
+		return nil // response already written
+	}
+
+	n, err := h.nextGame.CountAvailable(c.Request().Context(), ip, token, clientID)
+	if err != nil {
+		return h.writeErr(c, err)
+	}
+
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{
+		"client_id":       clientID.String(),
+		"available_count": n,
 	})
 }
+
+// prefersMinimal reports whether the Prefer header values (RFC 7240) ask for
+// return=minimal.
+func prefersMinimal(values []string) bool {
+	for _, v := range values {
+		for _, pref := range strings.Split(v, ",") {
+			token, _, _ := strings.Cut(pref, ";")
+			if strings.EqualFold(strings.ReplaceAll(strings.TrimSpace(token), " ", ""), "return=minimal") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// setArtifactCache sets Cache-Control on a response derived only from g's
+// position and moves, which never change once g is finished: such responses
+// are public for finishedMaxAge, everything else is no-store.
+func (h *Handlers) setArtifactCache(c echo.Context, g *game.Game) {
+	cc := "no-store"
+	if g.Status.Finished() && h.finishedMaxAge > 0 {
+		cc = fmt.Sprintf("public, max-age=%d", int(h.finishedMaxAge.Seconds()))
+	}
+	c.Response().Header().Set("Cache-Control", cc)
+}
+
+// setGameHeaders exposes the game's status and state version as headers, so
+// polling clients can use HEAD or skip parsing the body.
+func setGameHeaders(c echo.Context, g *game.Game) {
+	c.Response().Header().Set("X-Game-Status", string(g.Status))
+	c.Response().Header().Set("X-Game-Version", strconv.Itoa(g.StateVersion))
+}
+
+var invalidMoveFieldDetail = map[string]string{
+	"from":      "from must be a square such as e2.",
+	"to":        "to must be a square such as e4.",
+	"promotion": "promotion must be one of q, r, b, n.",
+}
+
+// invalidMoveField checks the from/to move form field by field and names the
+// first malformed one. ok is true when all fields are well formed.
+func invalidMoveField(from, to string, promotion *string) (field string, ok bool) {
+	switch {
+	case !isSquare(from):
+		return "from", false
+	case !isSquare(to):
+		return "to", false
+	case promotion != nil && !slices.Contains([]string{"q", "r", "b", "n"}, *promotion):
+		return "promotion", false
+	}
+	return "", true
+}
+
+func isSquare(s string) bool {
+	return len(s) == 2 && s[0] >= 'a' && s[0] <= 'h' && s[1] >= '1' && s[1] <= '8'
+}
@@ -2,37 +2,83 @@ package http_test
 
 import (
 	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"maps"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
 
 	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
 	transporthttp "github.com/randomtoy/random-chess-backend/internal/transport/http"
 	"github.com/randomtoy/random-chess-backend/internal/usecase"
 )
 
-const testBatchSize = 3
+const (
+	testBatchSize  = 3
+	testAdminToken = "test-admin-token"
+)
 
 func newTestServer(t *testing.T) *transporthttp.Handlers {
 	t.Helper()
 	return newTestServerWithStore(t, memory.New(testBatchSize))
 }
 
+// testOptions configures the optional usecase behavior of a test server.
+type testOptions struct {
+	getter usecase.GameGetterOptions
+	next   usecase.NextGameOptions
+	submit usecase.MoveSubmitterOptions
+}
+
 func newTestServerWithStore(t *testing.T, store *memory.Store) *transporthttp.Handlers {
+	t.Helper()
+	return newTestServerWithOptions(t, store, testOptions{})
+}
+
+func newTestServerWithOptions(t *testing.T, store ports.GameStore, opts testOptions) *transporthttp.Handlers {
 	t.Helper()
 	rl := memory.AlwaysAllow{}
+	blocklist := usecase.NewBlocklist(store, nil)
+	opts.next.Blocklist = blocklist
+	opts.submit.Blocklist = blocklist
 	return transporthttp.NewHandlers(
 		usecase.NewAssigner(store, rl),
-		usecase.NewNextGame(store, rl, testBatchSize),
-		usecase.NewGameGetter(store, rl),
-		usecase.NewMoveSubmitter(store, rl),
+		usecase.NewNextGame(store, rl, testBatchSize, opts.next),
+		usecase.NewGameGetter(store, rl, opts.getter),
+		usecase.NewMoveSubmitter(store, rl, opts.submit),
+		usecase.NewAdmin(store, blocklist),
 	)
 }
 
+// defaultServerOptions enables every optional route so tests can reach them.
+func defaultServerOptions() transporthttp.Options {
+	return transporthttp.Options{AdminToken: testAdminToken, CursorSecret: []byte("test-cursor-secret")}
+}
+
 func doRequest(t *testing.T, h *transporthttp.Handlers, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	return doRequestWithOptions(t, h, defaultServerOptions(), method, path, body, headers)
+}
+
+func doRequestWithOptions(t *testing.T, h *transporthttp.Handlers, opts transporthttp.Options, method, path string, body any, headers map[string]string) *httptest.ResponseRecorder {
 	t.Helper()
 	var buf bytes.Buffer
 	if body != nil {
@@ -48,10 +94,55 @@ func doRequest(t *testing.T, h *transporthttp.Handlers, method, path string, bod
 		req.Header.Set(k, v)
 	}
 	rec := httptest.NewRecorder()
-	transporthttp.New(h).ServeHTTP(rec, req)
+	transporthttp.New(h, opts).ServeHTTP(rec, req)
 	return rec
 }
 
+// adminHeaders returns headers authenticating as the test admin.
+func adminHeaders() map[string]string {
+	return map[string]string{"Authorization": "Bearer " + testAdminToken}
+}
+
+// sampleGames returns the first n of store's games in ID order.
+func sampleGames(t *testing.T, store *memory.Store, n int) []*game.Game {
+	t.Helper()
+	games, err := store.ListGames(context.Background(), ports.GameFilter{}, uuid.Nil, n)
+	if err != nil || len(games) != n {
+		t.Fatalf("list %d games: got %d, %v", n, len(games), err)
+	}
+	return games
+}
+
+// seedGame loads game id from store, applies mutate to a copy and saves it as
+// the next state version, returning the saved game.
+func seedGame(t *testing.T, store *memory.Store, id uuid.UUID, mutate func(*game.Game)) *game.Game {
+	t.Helper()
+	ctx := context.Background()
+	g, err := store.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get %s: %v", id, err)
+	}
+	seeded := *g
+	mutate(&seeded)
+	seeded.StateVersion++
+	if err := store.SaveIfVersion(ctx, &seeded, g.StateVersion); err != nil {
+		t.Fatalf("save %s: %v", id, err)
+	}
+	return &seeded
+}
+
+// problemCode returns the code of the Problem in rec's body.
+func problemCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+	var p struct {
+		Code string `json:"code"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &p); err != nil {
+		t.Fatalf("decode problem %q: %v", rec.Body.String(), err)
+	}
+	return p.Code
+}
+
 // getNextGame calls GET /api/v1/games/next and returns gameID + stateVersion.
 func getNextGame(t *testing.T, h *transporthttp.Handlers, clientID string) (gameID string, stateVersion int) {
 	t.Helper()
@@ -90,6 +181,52 @@ func TestHealthz(t *testing.T) {
 	}
 }
 
+func TestBasePath(t *testing.T) {
+	h := newTestServer(t)
+	opts := defaultServerOptions()
+	opts.BasePath = "/chess"
+
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/chess/api/v1/healthz", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("prefixed healthz: expected 200, got %d", rec.Code)
+	}
+	rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/healthz", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unprefixed healthz: expected 404, got %d", rec.Code)
+	}
+
+	rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/chess/api/v1/games/changed?since=2000-01-01T00:00:00Z&limit=1", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("changed: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if link := rec.Header().Get("Link"); !strings.Contains(link, "<http://example.com/chess/api/v1/games/changed?") {
+		t.Fatalf("Link does not keep the base path: %q", link)
+	}
+}
+
+func TestRobotsTxt(t *testing.T) {
+	h := newTestServer(t)
+	opts := defaultServerOptions()
+	opts.BasePath = "/chess"
+
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/robots.txt", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.HasPrefix(rec.Header().Get(echo.HeaderContentType), "text/plain") {
+		t.Fatalf("unexpected Content-Type %q", rec.Header().Get(echo.HeaderContentType))
+	}
+	if !strings.Contains(rec.Body.String(), "Disallow: /chess/api/\n") {
+		t.Fatalf("missing disallow directive: %q", rec.Body.String())
+	}
+
+	opts.RobotsTxt = "User-agent: *\nDisallow: /\n"
+	rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/robots.txt", nil, nil)
+	if rec.Body.String() != opts.RobotsTxt {
+		t.Fatalf("expected configured robots.txt, got %q", rec.Body.String())
+	}
+}
+
 func TestGetAssigned_OngoingGame(t *testing.T) {
 	h := newTestServer(t)
 	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/assigned", nil, nil)
@@ -233,6 +370,68 @@ func TestGetNext_RequiresClientID(t *testing.T) {
 	}
 }
 
+func TestGetNext_RejectsPlaceholderClientIDs(t *testing.T) {
+	h := newTestServer(t)
+	placeholder := uuid.MustParse("11111111-1111-1111-1111-111111111111")
+	opts := defaultServerOptions()
+	opts.PlaceholderClientIDs = []uuid.UUID{placeholder}
+
+	for _, id := range []string{uuid.Nil.String(), placeholder.String()} {
+		rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/next", nil,
+			map[string]string{"X-Client-Id": id})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", id, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "placeholder-client-id") {
+			t.Fatalf("%s: unexpected problem: %s", id, rec.Body.String())
+		}
+	}
+
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/next", nil,
+		map[string]string{"X-Client-Id": uuid.New().String()})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("random v4: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestClientID_RejectsHouseID: nobody can claim or move as the house.
+func TestClientID_RejectsHouseID(t *testing.T) {
+	h := newTestServer(t)
+	house := usecase.HouseClientID.String()
+	for _, path := range []string{"/api/v1/games/next", "/api/v1/games/assigned"} {
+		rec := doRequest(t, h, http.MethodGet, path, nil, map[string]string{
+			"X-Client-Id":    house,
+			"X-Client-Token": house,
+		})
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		if code := problemCode(t, rec); code != "placeholder_client_id" {
+			t.Fatalf("%s: code = %q, want placeholder_client_id", path, code)
+		}
+	}
+}
+
+// TestGetAssigned_RejectsPlaceholderToken: the legacy endpoint's UUID token
+// path applies the same placeholder check as X-Client-Id.
+func TestGetAssigned_RejectsPlaceholderToken(t *testing.T) {
+	h := newTestServer(t)
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/assigned", nil,
+		map[string]string{"X-Client-Token": uuid.Nil.String()})
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if code := problemCode(t, rec); code != "placeholder_client_id" {
+		t.Fatalf("code = %q, want placeholder_client_id", code)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/assigned", nil,
+		map[string]string{"X-Client-Token": uuid.New().String()})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("random v4: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
 func TestGetNext_ReturnsGameWithHistory(t *testing.T) {
 	h := newTestServer(t)
 	clientID := uuid.New().String()
@@ -340,6 +539,44 @@ func TestSubmitMove_OneMoveLimit(t *testing.T) {
 	}
 }
 
+// TestSubmitMove_RetryIsIdempotent: resubmitting the same move after it was
+// accepted returns the original move instead of 409.
+func TestSubmitMove_RetryIsIdempotent(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	submit := func() *httptest.ResponseRecorder {
+		return doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+			map[string]any{"uci": "e2e4", "expected_version": ver},
+			map[string]string{"X-Client-Id": clientID},
+		)
+	}
+	type moveResp struct {
+		Move struct {
+			MoveID string `json:"move_id"`
+			UCI    string `json:"uci"`
+		} `json:"move"`
+	}
+	decode := func(rec *httptest.ResponseRecorder) moveResp {
+		t.Helper()
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var r moveResp
+		if err := json.NewDecoder(rec.Body).Decode(&r); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return r
+	}
+
+	first := decode(submit())
+	retry := decode(submit())
+	if retry.Move.MoveID != first.Move.MoveID || retry.Move.UCI != "e2e4" {
+		t.Fatalf("retry returned %+v, want original %+v", retry.Move, first.Move)
+	}
+}
+
 // TestSubmitMove_NotAssigned: submit without claiming via /games/next first → 403.
 func TestSubmitMove_NotAssigned(t *testing.T) {
 	// Use store with pre-seeded games.
@@ -352,7 +589,9 @@ func TestSubmitMove_NotAssigned(t *testing.T) {
 		t.Fatalf("assigned: expected 200, got %d", rec.Code)
 	}
 	var assignResp struct {
-		Game struct{ GameID string `json:"game_id"` } `json:"game"`
+		Game struct {
+			GameID string `json:"game_id"`
+		} `json:"game"`
 	}
 	if err := json.NewDecoder(rec.Body).Decode(&assignResp); err != nil {
 		t.Fatalf("decode: %v", err)
@@ -384,7 +623,9 @@ func TestSubmitMove_FromToForm(t *testing.T) {
 		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
 	}
 	var resp struct {
-		Move struct{ UCI string `json:"uci"` } `json:"move"`
+		Move struct {
+			UCI string `json:"uci"`
+		} `json:"move"`
 	}
 	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
 		t.Fatalf("decode: %v", err)
@@ -426,3 +667,2840 @@ func TestGetGame_IncludesMoveHistory(t *testing.T) {
 		t.Fatalf("expected ply 0, got %d", resp.MoveHistory[0].Ply)
 	}
 }
+
+// TestGetNext_SingleActiveClaim: with single-active-claim mode a client must
+// move in its current game before it can claim another.
+func TestGetNext_SingleActiveClaim(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(testBatchSize), testOptions{
+		next: usecase.NextGameOptions{SingleActiveClaim: true},
+	})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{
+		"X-Client-Id": clientID,
+	})
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("second claim: expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Code          string `json:"code"`
+		CurrentGameID string `json:"current_game_id"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Code != "active_claim" {
+		t.Fatalf("expected code active_claim, got %q", resp.Code)
+	}
+	if resp.CurrentGameID != gameID {
+		t.Fatalf("current_game_id: want %s, got %s", gameID, resp.CurrentGameID)
+	}
+
+	// Moving releases the claim.
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if id, _ := getNextGame(t, h, clientID); id == gameID {
+		t.Fatalf("expected a different game after moving, got %s again", id)
+	}
+}
+
+// TestAvailableCount: the count drops as the client claims games.
+func TestAvailableCount(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(2))
+	clientID := uuid.New().String()
+
+	count := func() int {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/clients/"+clientID+"/available-count", nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			AvailableCount int `json:"available_count"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.AvailableCount
+	}
+
+	if n := count(); n != 2 {
+		t.Fatalf("before claim: want 2, got %d", n)
+	}
+	getNextGame(t, h, clientID)
+	if n := count(); n != 1 {
+		t.Fatalf("after claim: want 1, got %d", n)
+	}
+}
+
+func TestAvailableCount_InvalidClientID(t *testing.T) {
+	h := newTestServer(t)
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/clients/not-a-uuid/available-count", nil, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+// TestSubmitMove_Cooldown: after a move the client must wait out the cooldown
+// before moving in another game.
+func TestSubmitMove_Cooldown(t *testing.T) {
+	const cooldown = 200 * time.Millisecond
+	h := newTestServerWithOptions(t, memory.New(testBatchSize), testOptions{
+		submit: usecase.MoveSubmitterOptions{Cooldown: cooldown},
+	})
+	clientID := uuid.New().String()
+
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("first move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	gameID, ver = getNextGame(t, h, clientID)
+	move := func() *httptest.ResponseRecorder {
+		return doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+			map[string]any{"uci": "e2e4", "expected_version": ver},
+			map[string]string{"X-Client-Id": clientID},
+		)
+	}
+
+	rec = move()
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("within cooldown: expected 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec.Header().Get("Retry-After") != "1" {
+		t.Fatalf("Retry-After: want 1, got %q", rec.Header().Get("Retry-After"))
+	}
+	var resp struct {
+		Type         string `json:"type"`
+		Code         string `json:"code"`
+		RetryAfterMS int64  `json:"retry_after_ms"`
+		Game         any    `json:"game"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Code != "move_cooldown" || !strings.HasSuffix(resp.Type, "/move-cooldown") {
+		t.Fatalf("expected move_cooldown problem, got type %q code %q", resp.Type, resp.Code)
+	}
+	if resp.RetryAfterMS <= 0 || resp.RetryAfterMS > cooldown.Milliseconds() {
+		t.Fatalf("retry_after_ms = %d, want within (0, %d]", resp.RetryAfterMS, cooldown.Milliseconds())
+	}
+	if resp.Game != nil {
+		t.Fatalf("cooldown problem carries a game: %v", resp.Game)
+	}
+
+	time.Sleep(cooldown)
+	if rec = move(); rec.Code != http.StatusOK {
+		t.Fatalf("after cooldown: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBlockedClient: a client blocked via the admin API can neither claim nor
+// move until it is unblocked.
+func TestBlockedClient(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPut, "/api/v1/admin/blocked-clients/"+clientID, nil, adminHeaders())
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("block: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{"X-Client-Id": clientID})
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("claim while blocked: expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusForbidden {
+		t.Fatalf("move while blocked: expected 403, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodDelete, "/api/v1/admin/blocked-clients/"+clientID, nil, adminHeaders())
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("unblock: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+	getNextGame(t, h, clientID)
+}
+
+func TestAdmin_RequiresToken(t *testing.T) {
+	h := newTestServer(t)
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/admin/blocked-clients", nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", rec.Code)
+	}
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/blocked-clients", nil, map[string]string{
+		"Authorization": "Bearer wrong",
+	})
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("wrong token: expected 401, got %d", rec.Code)
+	}
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/blocked-clients", nil, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("valid token: expected 200, got %d", rec.Code)
+	}
+}
+
+func TestAdmin_DisabledWithoutToken(t *testing.T) {
+	h := newTestServer(t)
+	rec := doRequestWithOptions(t, h, transporthttp.Options{}, http.MethodGet, "/api/v1/admin/blocked-clients", nil, adminHeaders())
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("expected 404 when admin is disabled, got %d", rec.Code)
+	}
+}
+
+// TestSubmitMove_WasFirstMove: only the opening move of a game is flagged.
+func TestSubmitMove_WasFirstMove(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(1))
+
+	submit := func(clientID, uci string) bool {
+		t.Helper()
+		gameID, ver := getNextGame(t, h, clientID)
+		rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+			map[string]any{"uci": uci, "expected_version": ver},
+			map[string]string{"X-Client-Id": clientID},
+		)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("submit %s: expected 200, got %d: %s", uci, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Move struct {
+				WasFirstMove bool `json:"was_first_move"`
+			} `json:"move"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp.Move.WasFirstMove
+	}
+
+	if !submit(uuid.New().String(), "e2e4") {
+		t.Fatal("opening move: expected was_first_move true")
+	}
+	if submit(uuid.New().String(), "e7e5") {
+		t.Fatal("reply: expected was_first_move false")
+	}
+}
+
+func TestSubmitMove_IncludeLegal(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?include_legal=true",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	legal, ok := resp["legal_moves"].([]any)
+	if !ok || len(legal) != 20 {
+		t.Fatalf("expected 20 legal replies for black, got %v", resp["legal_moves"])
+	}
+
+	// Off by default.
+	h = newTestServer(t)
+	gameID, ver = getNextGame(t, h, clientID)
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "legal_moves") {
+		t.Fatalf("legal_moves present without include_legal: %s", rec.Body.String())
+	}
+}
+
+func TestGetNext_MaxClaimsPerIP(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(testBatchSize), testOptions{
+		next: usecase.NextGameOptions{MaxClaimsPerIP: 2, ClaimCounter: memory.NewClaimCounter(time.Hour)},
+	})
+	// Every test request shares one remote address.
+	getNextGame(t, h, uuid.New().String())
+	getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{
+		"X-Client-Id": uuid.New().String(),
+	})
+	if rec.Code != http.StatusTooManyRequests {
+		t.Fatalf("third claim: expected 429, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), "/ip-claim-limit") {
+		t.Fatalf("unexpected problem: %s", rec.Body.String())
+	}
+}
+
+// TestGetNext_MaxClaimsPerIP_Prefix: the per-IP cap keys IPv6 callers on
+// their /64 and IPv4 callers on the full address unless configured otherwise.
+func TestGetNext_MaxClaimsPerIP_Prefix(t *testing.T) {
+	tests := []struct {
+		name          string
+		v4Prefix      int
+		first, second string
+		wantCapped    bool
+	}{
+		{"ipv6 same /64", 0, "2001:db8:1:2::1", "2001:db8:1:2:ffff::9", true},
+		{"ipv6 other /64", 0, "2001:db8:1:2::1", "2001:db8:1:3::1", false},
+		{"ipv4 full address", 0, "192.0.2.1", "192.0.2.2", false},
+		{"ipv4 same /24", 24, "192.0.2.1", "192.0.2.2", true},
+		{"ipv4-mapped ipv6 is ipv4", 0, "192.0.2.1", "::ffff:192.0.2.1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestServerWithOptions(t, memory.New(testBatchSize), testOptions{
+				next: usecase.NextGameOptions{
+					MaxClaimsPerIP: 1,
+					ClaimCounter:   memory.NewClaimCounter(time.Hour),
+					IPv4PrefixLen:  tt.v4Prefix,
+				},
+			})
+			claim := func(ip string) int {
+				return doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{
+					"X-Client-Id": uuid.New().String(),
+					"X-Real-Ip":   ip,
+				}).Code
+			}
+			if code := claim(tt.first); code != http.StatusOK {
+				t.Fatalf("first claim: expected 200, got %d", code)
+			}
+			want := http.StatusOK
+			if tt.wantCapped {
+				want = http.StatusTooManyRequests
+			}
+			if code := claim(tt.second); code != want {
+				t.Fatalf("second claim: expected %d, got %d", want, code)
+			}
+		})
+	}
+}
+
+// TestRetryHint_Jittered: 429 responses carry Retry-After and retry_after_ms
+// within [RetryAfter, RetryAfter+RetryJitter].
+func TestRetryHint_Jittered(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(testBatchSize), testOptions{
+		next: usecase.NextGameOptions{MaxClaimsPerIP: 1, ClaimCounter: memory.NewClaimCounter(time.Hour)},
+	})
+	getNextGame(t, h, uuid.New().String())
+
+	opts := defaultServerOptions()
+	opts.RetryAfter = 3 * time.Second
+	opts.RetryJitter = 500 * time.Millisecond
+	for range 20 {
+		rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/next", nil, map[string]string{
+			"X-Client-Id": uuid.New().String(),
+		})
+		if rec.Code != http.StatusTooManyRequests {
+			t.Fatalf("expected 429, got %d", rec.Code)
+		}
+		if got := rec.Header().Get("Retry-After"); got != "3" && got != "4" {
+			t.Fatalf("Retry-After: want 3 or 4, got %q", got)
+		}
+		var resp struct {
+			RetryAfterMS int64 `json:"retry_after_ms"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.RetryAfterMS < 3000 || resp.RetryAfterMS > 3500 {
+			t.Fatalf("retry_after_ms %d outside [3000, 3500]", resp.RetryAfterMS)
+		}
+	}
+}
+
+// TestGetNext_SeededRandomStrategy: two stores with the same seed hand out the
+// same games in the same order.
+func TestGetNext_SeededRandomStrategy(t *testing.T) {
+	claims := func() []string {
+		h := newTestServerWithStore(t, memory.NewSeeded(10, 42).WithClaimStrategy(ports.ClaimRandom))
+		var ids []string
+		for range 5 {
+			id, _ := getNextGame(t, h, uuid.New().String())
+			ids = append(ids, id)
+		}
+		return ids
+	}
+	first, second := claims(), claims()
+	if !slices.Equal(first, second) {
+		t.Fatalf("claim order differs between seeded runs:\n%v\n%v", first, second)
+	}
+}
+
+// TestGetNext_MostActiveStrategy: once a game is underway, new clients are
+// sent there instead of opening another waiting game.
+func TestGetNext_MostActiveStrategy(t *testing.T) {
+	store := memory.New(0).WithClaimStrategy(ports.ClaimMostActive)
+	h := newTestServerWithStore(t, store)
+
+	first := uuid.New().String()
+	gameID, ver := getNextGame(t, h, first)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": first},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	for i := 0; i < 3; i++ {
+		if got, _ := getNextGame(t, h, uuid.New().String()); got != gameID {
+			t.Fatalf("claim %d: want active game %s, got %s", i, gameID, got)
+		}
+	}
+}
+
+// TestGetNext_PreferInProgress: a game with moves is handed out ahead of
+// older untouched games only when the preference is on.
+func TestGetNext_PreferInProgress(t *testing.T) {
+	store := memory.New(3)
+	// Make the started game the newest, so the default oldest-first order
+	// never picks it.
+	started := seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		g.PlyCount = 1
+		g.CreatedAt = time.Now().Add(time.Hour)
+	})
+	wantID := started.ID.String()
+
+	if got, _ := getNextGame(t, newTestServerWithStore(t, store), uuid.New().String()); got == wantID {
+		t.Fatal("default order: got the newest game")
+	}
+	if got, _ := getNextGame(t, newTestServerWithStore(t, store.WithPreferInProgress()), uuid.New().String()); got != wantID {
+		t.Fatalf("prefer in-progress: want %s, got %s", wantID, got)
+	}
+}
+
+// TestGetNext_ExclusiveWindow: a fresh claim keeps the game from other
+// clients until the claimant moves or the window runs out.
+func TestGetNext_ExclusiveWindow(t *testing.T) {
+	t.Run("honored", func(t *testing.T) {
+		h := newTestServerWithStore(t, memory.New(1).WithExclusiveWindow(time.Hour))
+		holder := uuid.New().String()
+		gameID, ver := getNextGame(t, h, holder)
+		if other, _ := getNextGame(t, h, uuid.New().String()); other == gameID {
+			t.Fatal("held game handed to another client")
+		}
+
+		rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+			map[string]any{"uci": "e2e4", "expected_version": ver},
+			map[string]string{"X-Client-Id": holder},
+		)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if next, _ := getNextGame(t, h, uuid.New().String()); next != gameID {
+			t.Fatalf("after the move: want oldest game %s, got %s", gameID, next)
+		}
+	})
+	t.Run("expired", func(t *testing.T) {
+		h := newTestServerWithStore(t, memory.New(1).WithExclusiveWindow(time.Millisecond))
+		gameID, _ := getNextGame(t, h, uuid.New().String())
+		time.Sleep(5 * time.Millisecond)
+		if other, _ := getNextGame(t, h, uuid.New().String()); other != gameID {
+			t.Fatalf("expired window: want %s, got %s", gameID, other)
+		}
+	})
+	t.Run("second claimant rejected", func(t *testing.T) {
+		// The early claimant took the game before the window applied, so the
+		// holder could claim it too.
+		store := memory.New(1)
+		early := uuid.New().String()
+		gameID, ver := getNextGame(t, newTestServerWithStore(t, store), early)
+		held := store.WithExclusiveWindow(time.Hour)
+		h := newTestServerWithStore(t, held)
+		holder := uuid.New().String()
+		if got, _ := getNextGame(t, h, holder); got != gameID {
+			t.Fatalf("holder: want %s, got %s", gameID, got)
+		}
+
+		move := func(clientID, uci string, ver int) *httptest.ResponseRecorder {
+			return doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+				map[string]any{"uci": uci, "expected_version": ver},
+				map[string]string{"X-Client-Id": clientID},
+			)
+		}
+		rec := move(early, "e2e4", ver)
+		if rec.Code != http.StatusConflict || problemCode(t, rec) != "claim_held" {
+			t.Fatalf("inside the window: expected 409 claim_held, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if n, err := held.CountAvailableForClient(context.Background(), uuid.New()); err != nil || n != 0 {
+			t.Fatalf("available inside the window = %d, %v; want 0", n, err)
+		}
+
+		if rec := move(holder, "e2e4", ver); rec.Code != http.StatusOK {
+			t.Fatalf("holder move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		if rec := move(early, "e7e5", ver+1); rec.Code != http.StatusOK {
+			t.Fatalf("after the holder moved: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+func TestGetRandomGame(t *testing.T) {
+	rec := doRequest(t, newTestServerWithStore(t, memory.New(0)), http.MethodGet, "/api/v1/games/random", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("no games: expected 404, got %d", rec.Code)
+	}
+
+	random := func(store *memory.Store) string {
+		t.Helper()
+		rec := doRequest(t, newTestServerWithStore(t, store), http.MethodGet, "/api/v1/games/random", nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			GameID string `json:"game_id"`
+			Status string `json:"status"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if resp.Status != string(game.StatusOngoing) {
+			t.Fatalf("want an ongoing game, got %q", resp.Status)
+		}
+		return resp.GameID
+	}
+	if random(memory.NewSeeded(5, 42)) != random(memory.NewSeeded(5, 42)) {
+		t.Fatal("the same seed should pick the same game")
+	}
+
+	// Only the one game still ongoing can be picked.
+	store := memory.New(3)
+	games := sampleGames(t, store, 3)
+	for _, g := range games[1:] {
+		seedGame(t, store, g.ID, func(g *game.Game) { g.Status = game.StatusDraw })
+	}
+	if got := random(store); got != games[0].ID.String() {
+		t.Fatalf("want ongoing game %s, got %s", games[0].ID, got)
+	}
+}
+
+func TestGetGame_Accept(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	const fen = "rnbqkbnr/pppppppp/8/8/4P3/8/PPPP1PPP/RNBQKBNR b KQkq e3 0 1"
+
+	tests := []struct {
+		accept      string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"", http.StatusOK, "application/json", `"game_id":"` + gameID + `"`},
+		{"application/json", http.StatusOK, "application/json", `"game_id":"` + gameID + `"`},
+		{"application/x-chess-pgn", http.StatusOK, "application/x-chess-pgn", "1. e4"},
+		{"text/plain", http.StatusOK, "text/plain", fen},
+		{"text/plain;q=0, application/x-chess-pgn", http.StatusOK, "application/x-chess-pgn", `[GameId "` + gameID + `"]`},
+		{"image/png", http.StatusNotAcceptable, "application/json", `"code":"not_acceptable"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.accept, func(t *testing.T) {
+			rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, map[string]string{"Accept": tt.accept})
+			if rec.Code != tt.status {
+				t.Fatalf("expected %d, got %d: %s", tt.status, rec.Code, rec.Body.String())
+			}
+			if ct := rec.Header().Get(echo.HeaderContentType); !strings.HasPrefix(ct, tt.contentType) {
+				t.Fatalf("Content-Type = %q, want %s", ct, tt.contentType)
+			}
+			if !strings.Contains(rec.Body.String(), tt.body) {
+				t.Fatalf("body missing %q: %s", tt.body, rec.Body.String())
+			}
+			if tt.status == http.StatusOK && !slices.Contains(rec.Header().Values(echo.HeaderVary), echo.HeaderAccept) {
+				t.Fatalf("Vary = %q, want Accept", rec.Header().Values(echo.HeaderVary))
+			}
+		})
+	}
+}
+
+func TestGetGame_IncludeEval(t *testing.T) {
+	h := newTestServer(t)
+	gameID, _ := getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if strings.Contains(rec.Body.String(), "eval_cp") {
+		t.Fatalf("eval_cp present without include_eval: %s", rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"?include_eval=true", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		EvalCP *int `json:"eval_cp"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.EvalCP == nil || *resp.EvalCP != 0 {
+		t.Fatalf("initial position: want eval_cp 0, got %v", resp.EvalCP)
+	}
+}
+
+func TestGetGame_IncludePieceCounts(t *testing.T) {
+	h := newTestServer(t)
+	gameID, _ := getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if strings.Contains(rec.Body.String(), "piece_counts") {
+		t.Fatalf("piece_counts present without include_piece_counts: %s", rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"?include_piece_counts=true", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		PieceCounts map[string]int `json:"piece_counts"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.PieceCounts) != 12 || resp.PieceCounts["wP"] != 8 || resp.PieceCounts["bK"] != 1 {
+		t.Fatalf("initial position: got piece_counts %v", resp.PieceCounts)
+	}
+}
+
+func TestGetGame_HistoryLimit(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(1), testOptions{
+		getter: usecase.GameGetterOptions{HistoryLimit: 2},
+	})
+	var gameID string
+	for _, uci := range []string{"e2e4", "e7e5", "g1f3"} {
+		clientID := uuid.New().String()
+		id, ver := getNextGame(t, h, clientID)
+		gameID = id
+		rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+id+"/moves",
+			map[string]any{"uci": uci, "expected_version": ver},
+			map[string]string{"X-Client-Id": clientID},
+		)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("move %s: expected 200, got %d: %s", uci, rec.Code, rec.Body.String())
+		}
+	}
+
+	type historyResp struct {
+		MoveHistory []struct {
+			Ply int    `json:"ply"`
+			UCI string `json:"uci"`
+		} `json:"move_history"`
+		HistoryTruncated bool `json:"history_truncated"`
+		TotalPlies       int  `json:"total_plies"`
+	}
+	get := func(path string) historyResp {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, path, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("GET %s: expected 200, got %d", path, rec.Code)
+		}
+		var resp historyResp
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	resp := get("/api/v1/games/" + gameID)
+	if !resp.HistoryTruncated || resp.TotalPlies != 3 || len(resp.MoveHistory) != 2 {
+		t.Fatalf("default: unexpected %+v", resp)
+	}
+	if resp.MoveHistory[0].UCI != "e7e5" || resp.MoveHistory[1].UCI != "g1f3" {
+		t.Fatalf("default: want the most recent moves, got %+v", resp.MoveHistory)
+	}
+
+	resp = get("/api/v1/games/" + gameID + "?full_history=true")
+	if resp.HistoryTruncated || len(resp.MoveHistory) != 3 {
+		t.Fatalf("full_history: unexpected %+v", resp)
+	}
+}
+
+func TestGetTheoretical(t *testing.T) {
+	h := newTestServer(t)
+	gameID, _ := getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/theoretical", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		GameID string `json:"game_id"`
+		Result string `json:"result"`
+		Known  bool   `json:"known"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.GameID != gameID || resp.Result != "unknown" || resp.Known {
+		t.Fatalf("opening position: unexpected %+v", resp)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+uuid.New().String()+"/theoretical", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+// TestPprof: profiles are served only by the dedicated handler, never by the
+// API server.
+func TestPprof(t *testing.T) {
+	rec := httptest.NewRecorder()
+	transporthttp.NewPprofHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pprof/", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pprof handler: expected 200, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, newTestServer(t), http.MethodGet, "/debug/pprof/", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("API server: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	reg := metrics.NewRegistry()
+	reg.Counter("webhook_dropped_total", "Dropped webhooks.").Add(3)
+	opts := defaultServerOptions()
+	opts.Metrics = reg
+
+	rec := doRequestWithOptions(t, newTestServer(t), opts, http.MethodGet, "/metrics", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	if !strings.Contains(rec.Body.String(), "webhook_dropped_total 3\n") {
+		t.Fatalf("missing counter in:\n%s", rec.Body.String())
+	}
+
+	rec = doRequest(t, newTestServer(t), http.MethodGet, "/metrics", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("metrics disabled: expected 404, got %d", rec.Code)
+	}
+}
+
+// TestAdminMetrics: the admin registry is served only behind the admin
+// token, and its series stay off the public /metrics.
+func TestAdminMetrics(t *testing.T) {
+	admin := metrics.NewRegistry()
+	admin.GaugeFunc("db_pool_in_use_conns", "Database connections in use.", func() int64 { return 2 })
+	admin.CounterFunc("db_pool_acquire_count", "Total successful connection acquires.", func() int64 { return 7 })
+	opts := defaultServerOptions()
+	opts.Metrics = metrics.NewRegistry()
+	opts.AdminMetrics = admin
+	h := newTestServer(t)
+
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/admin/metrics", nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("no token: expected 401, got %d", rec.Code)
+	}
+
+	rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/admin/metrics", nil, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d", rec.Code)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE db_pool_in_use_conns gauge\n",
+		"db_pool_in_use_conns 2\n",
+		"# TYPE db_pool_acquire_count counter\n",
+		"db_pool_acquire_count 7\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Fatalf("missing %q in:\n%s", want, body)
+		}
+	}
+
+	rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/metrics", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("public metrics: expected 200, got %d", rec.Code)
+	}
+	if strings.Contains(rec.Body.String(), "db_pool_") {
+		t.Fatalf("pool stats leaked onto public /metrics:\n%s", rec.Body.String())
+	}
+}
+
+// TestMetrics_InFlight: the in-flight gauges count a request while its
+// handler runs and drop back once it returns.
+func TestMetrics_InFlight(t *testing.T) {
+	reg := metrics.NewRegistry()
+	opts := defaultServerOptions()
+	opts.Metrics = reg
+	opts.RouteInFlight = true
+	e := transporthttp.New(newTestServer(t), opts)
+	entered, release := make(chan struct{}), make(chan struct{})
+	e.GET("/slow", func(c echo.Context) error {
+		close(entered)
+		<-release
+		return c.NoContent(http.StatusOK)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		e.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/slow", nil))
+	}()
+	<-entered
+	total := reg.Gauge("http_requests_in_flight", "")
+	route := reg.Gauge(`http_route_requests_in_flight{method="GET",route="/slow"}`, "")
+	if total.Value() != 1 || route.Value() != 1 {
+		t.Fatalf("during request: total %d, route %d; want 1, 1", total.Value(), route.Value())
+	}
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+	if !strings.Contains(buf.String(), "# TYPE http_route_requests_in_flight gauge\n"+`http_route_requests_in_flight{method="GET",route="/slow"} 1`) {
+		t.Fatalf("route gauge not exposed as a family:\n%s", buf.String())
+	}
+	close(release)
+	<-done
+	if total.Value() != 0 || route.Value() != 0 {
+		t.Fatalf("after request: total %d, route %d; want 0, 0", total.Value(), route.Value())
+	}
+}
+
+// TestListChanged_PagesThroughTies: seeded games share one updated_at, so
+// paging must rely on the id tiebreaker to return each game exactly once.
+func TestListChanged_PagesThroughTies(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(5))
+
+	seen := map[string]bool{}
+	path := "/api/v1/games/changed?limit=2&since=2000-01-01T00:00:00Z"
+	for page := 0; page < 5; page++ {
+		rec := doRequest(t, h, http.MethodGet, path, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("page %d: expected 200, got %d: %s", page, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Games []struct {
+				GameID string `json:"game_id"`
+			} `json:"games"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if len(resp.Games) == 0 {
+			break
+		}
+		for _, g := range resp.Games {
+			if seen[g.GameID] {
+				t.Fatalf("game %s returned twice", g.GameID)
+			}
+			seen[g.GameID] = true
+		}
+		path = "/api/v1/games/changed?limit=2&cursor=" + resp.NextCursor
+	}
+	if len(seen) != 5 {
+		t.Fatalf("saw %d games, want 5", len(seen))
+	}
+}
+
+// TestListChanged_IncludesMetadataEdits: a PATCHed title or tag list is a
+// change, so the game is served again after the feed's cursor.
+func TestListChanged_IncludesMetadataEdits(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(3))
+	page := func(path string) (ids []string, cursor string) {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, path, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Games []struct {
+				GameID string `json:"game_id"`
+			} `json:"games"`
+			NextCursor string `json:"next_cursor"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		for _, g := range resp.Games {
+			ids = append(ids, g.GameID)
+		}
+		return ids, resp.NextCursor
+	}
+
+	ids, cursor := page("/api/v1/games/changed?since=2000-01-01T00:00:00Z")
+	if len(ids) != 3 {
+		t.Fatalf("first page: got %d games, want 3", len(ids))
+	}
+	if ids, _ := page("/api/v1/games/changed?cursor=" + cursor); len(ids) != 0 {
+		t.Fatalf("nothing changed yet, got %v", ids)
+	}
+
+	rec := doRequest(t, h, http.MethodPatch, "/api/v1/admin/games/"+ids[0],
+		map[string]any{"title": "Friday blitz"}, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got, _ := page("/api/v1/games/changed?cursor=" + cursor); !slices.Equal(got, ids[:1]) {
+		t.Fatalf("after patch: got %v, want [%s]", got, ids[0])
+	}
+}
+
+// TestListChanged_RejectsTamperedCursor: a server-issued cursor round-trips,
+// while any edit to it is rejected.
+func TestListChanged_RejectsTamperedCursor(t *testing.T) {
+	h := newTestServer(t)
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/changed?limit=1&since=2000-01-01T00:00:00Z", nil, nil)
+	var resp struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/changed?cursor="+resp.NextCursor, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("issued cursor: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	mutated := []byte(resp.NextCursor)
+	if mutated[5] == 'A' {
+		mutated[5] = 'B'
+	} else {
+		mutated[5] = 'A'
+	}
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/changed?cursor="+string(mutated), nil, nil)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "invalid-cursor") {
+		t.Fatalf("mutated cursor: expected 400 invalid-cursor, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestListChanged_RequiresSince(t *testing.T) {
+	h := newTestServer(t)
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/changed", nil, nil)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d", rec.Code)
+	}
+}
+
+func TestMovesOptions(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(1))
+	path := "/api/v1/games/" + uuid.New().String() + "/moves"
+
+	rec := doRequest(t, h, http.MethodOptions, path, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		MoveForms []map[string]string `json:"move_forms"`
+		Headers   map[string]string   `json:"headers"`
+		Fields    map[string]string   `json:"fields"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.MoveForms) == 0 {
+		t.Fatalf("missing move_forms: %+v", resp)
+	}
+
+	// The contract matches what the route does: expected_version may be
+	// left out until the game has moves, and Prefer: return=minimal is
+	// honoured.
+	if v := resp.Fields["expected_version"]; strings.HasPrefix(v, "required") || !strings.Contains(v, "once the game has moves") {
+		t.Fatalf("expected_version is conditional, contract says %q", v)
+	}
+	if !strings.Contains(resp.Headers["Prefer"], "return=minimal") {
+		t.Fatalf("contract does not describe Prefer: %+v", resp.Headers)
+	}
+	clientID := uuid.New().String()
+	gameID, _ := getNextGame(t, h, clientID)
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4"},
+		map[string]string{"X-Client-Id": clientID, "Prefer": "return=minimal"},
+	)
+	if rec.Code != http.StatusOK || rec.Header().Get("Preference-Applied") != "return=minimal" {
+		t.Fatalf("first move without expected_version: got %d (%q): %s", rec.Code, rec.Header().Get("Preference-Applied"), rec.Body.String())
+	}
+	otherID := uuid.New().String()
+	if got, _ := getNextGame(t, h, otherID); got != gameID {
+		t.Fatalf("want the moved game %s, got %s", gameID, got)
+	}
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e7e5"},
+		map[string]string{"X-Client-Id": otherID},
+	)
+	if rec.Code != http.StatusBadRequest || problemCode(t, rec) != "missing_expected_version" {
+		t.Fatalf("later move without expected_version: got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// CORS preflights are still answered by the CORS middleware.
+	rec = doRequest(t, h, http.MethodOptions, path, nil, map[string]string{
+		"Origin":                        "https://chess.randomtoy.dev",
+		"Access-Control-Request-Method": "POST",
+	})
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("preflight: expected 204, got %d", rec.Code)
+	}
+	if rec.Header().Get("Access-Control-Allow-Origin") == "" {
+		t.Fatal("preflight: missing Access-Control-Allow-Origin")
+	}
+}
+
+// TestSubmitMove_RecordsUserAgent: the User-Agent is stored when enabled,
+// truncated, and visible only through the admin game view.
+func TestSubmitMove_RecordsUserAgent(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(1), testOptions{
+		submit: usecase.MoveSubmitterOptions{RecordUserAgent: true},
+	})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	ua := "TestBot/1.0 " + strings.Repeat("x", 500)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID, "User-Agent": ua},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if strings.Contains(rec.Body.String(), "user_agent") {
+		t.Fatalf("public view leaks user_agent: %s", rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/games/"+gameID, nil, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("admin view: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		MoveHistory []struct {
+			UserAgent *string `json:"user_agent"`
+		} `json:"move_history"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp.MoveHistory) != 1 || resp.MoveHistory[0].UserAgent == nil {
+		t.Fatalf("admin view: missing user_agent: %+v", resp)
+	}
+	got := *resp.MoveHistory[0].UserAgent
+	if len(got) != usecase.MaxUserAgentLen || !strings.HasPrefix(got, "TestBot/1.0") {
+		t.Fatalf("user_agent not truncated to %d bytes: %d", usecase.MaxUserAgentLen, len(got))
+	}
+}
+
+// failingPersistStore simulates an infrastructure failure on PersistMove and
+// captures dead-letter records.
+type failingPersistStore struct {
+	*memory.Store
+	failed []ports.FailedMove
+}
+
+func (s *failingPersistStore) PersistMove(context.Context, uuid.UUID, uuid.UUID, *game.Game, game.MoveRecord, int) ([]game.MoveHistoryItem, error) {
+	return nil, errors.New("connection reset")
+}
+
+func (s *failingPersistStore) RecordFailedMove(_ context.Context, fm ports.FailedMove) error {
+	s.failed = append(s.failed, fm)
+	return nil
+}
+
+func TestSubmitMove_PreferMinimal(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID, "Prefer": "respond-async, return=minimal"},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("Preference-Applied"); got != "return=minimal" {
+		t.Fatalf("Preference-Applied: %q", got)
+	}
+	var resp map[string]any
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(resp) != 3 || resp["accepted"] != true || resp["state_version"] != float64(ver+1) || resp["fen"] == "" {
+		t.Fatalf("unexpected minimal body: %v", resp)
+	}
+}
+
+func TestGameStatusHeaders(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Game-Version"); got != strconv.Itoa(ver+1) {
+		t.Fatalf("move X-Game-Version: %q", got)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	var body struct {
+		Status       string `json:"status"`
+		StateVersion int    `json:"state_version"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if got := rec.Header().Get("X-Game-Status"); got != body.Status {
+		t.Fatalf("X-Game-Status %q, body status %q", got, body.Status)
+	}
+	if got := rec.Header().Get("X-Game-Version"); got != strconv.Itoa(body.StateVersion) {
+		t.Fatalf("X-Game-Version %q, body state_version %d", got, body.StateVersion)
+	}
+
+	rec = doRequest(t, h, http.MethodHead, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusOK || rec.Header().Get("X-Game-Status") != body.Status {
+		t.Fatalf("HEAD: code %d, X-Game-Status %q", rec.Code, rec.Header().Get("X-Game-Status"))
+	}
+}
+
+func TestContributors(t *testing.T) {
+	h := newTestServer(t)
+	first := uuid.New().String()
+	gameID, ver := getNextGame(t, h, first)
+
+	type contributorsResp struct {
+		Contributors []struct {
+			ClientID string `json:"client_id"`
+			FirstPly int    `json:"first_ply"`
+		} `json:"contributors"`
+	}
+	get := func() contributorsResp {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/contributors", nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp contributorsResp
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return resp
+	}
+
+	if resp := get(); resp.Contributors == nil || len(resp.Contributors) != 0 {
+		t.Fatalf("no moves: want empty array, got %+v", resp.Contributors)
+	}
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": first},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	resp := get()
+	if len(resp.Contributors) != 1 || resp.Contributors[0].ClientID != first || resp.Contributors[0].FirstPly != 0 {
+		t.Fatalf("unexpected contributors: %+v", resp.Contributors)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+uuid.New().String()+"/contributors", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestSubmitMove_InvalidFromTo(t *testing.T) {
+	cases := []struct {
+		name  string
+		body  map[string]any
+		field string
+	}{
+		{"bad from", map[string]any{"from": "e2x", "to": "e4"}, "from"},
+		{"bad to", map[string]any{"from": "e2", "to": "e44"}, "to"},
+		{"missing to", map[string]any{"from": "e2"}, "to"},
+		{"bad promotion", map[string]any{"from": "e7", "to": "e8", "promotion": "k"}, "promotion"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestServer(t)
+			clientID := uuid.New().String()
+			gameID, ver := getNextGame(t, h, clientID)
+			tc.body["expected_version"] = ver
+
+			rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves", tc.body,
+				map[string]string{"X-Client-Id": clientID})
+			if rec.Code != http.StatusBadRequest {
+				t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+			}
+			var resp struct {
+				Field string `json:"field"`
+			}
+			if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if resp.Field != tc.field {
+				t.Fatalf("field: want %q, got %q", tc.field, resp.Field)
+			}
+		})
+	}
+}
+
+func TestReadOnlyMode(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	opts := defaultServerOptions()
+	opts.ReadOnly = true
+	writes := []struct {
+		method, path string
+		body         any
+	}{
+		{http.MethodGet, "/api/v1/games/next", nil},
+		{http.MethodGet, "/api/v1/games/assigned", nil},
+		{http.MethodPost, "/api/v1/games/" + gameID + "/moves", map[string]any{"uci": "e2e4", "expected_version": ver}},
+	}
+	for _, w := range writes {
+		rec := doRequestWithOptions(t, h, opts, w.method, w.path, w.body, map[string]string{"X-Client-Id": clientID})
+		if rec.Code != http.StatusServiceUnavailable {
+			t.Fatalf("%s %s: expected 503, got %d", w.method, w.path, rec.Code)
+		}
+		if !strings.Contains(rec.Body.String(), "read-only mode") {
+			t.Fatalf("%s %s: unexpected body %s", w.method, w.path, rec.Body.String())
+		}
+	}
+
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("read: expected 200, got %d", rec.Code)
+	}
+}
+
+func TestMovesCSV(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "g1f3", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/moves.csv", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/csv") {
+		t.Fatalf("Content-Type: %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); !strings.Contains(cd, "attachment") {
+		t.Fatalf("Content-Disposition: %q", cd)
+	}
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("want header + 1 row, got %d rows", len(rows))
+	}
+	if got := strings.Join(rows[0], ","); got != "ply,uci,san,from,to,promotion,client_id,created_at,fen_before,fen_after" {
+		t.Fatalf("header: %s", got)
+	}
+	if rows[1][1] != "g1f3" || rows[1][2] != "Nf3" || rows[1][6] != clientID {
+		t.Fatalf("unexpected row: %v", rows[1])
+	}
+}
+
+// failingHistoryStore simulates a failing move-history query.
+type failingHistoryStore struct {
+	*memory.Store
+}
+
+func (s failingHistoryStore) GetMovesSince(context.Context, uuid.UUID, int) ([]game.MoveHistoryItem, error) {
+	return nil, errors.New("statement timeout")
+}
+
+func (s failingHistoryStore) GetGameWithHistory(context.Context, uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	return nil, nil, errors.New("statement timeout")
+}
+
+func TestGetGame_DegradedHistory(t *testing.T) {
+	store := failingHistoryStore{Store: memory.New(1)}
+	clientID := uuid.New().String()
+	gameID, _ := getNextGame(t, newTestServerWithStore(t, store.Store), clientID)
+
+	rec := doRequest(t, newTestServerWithOptions(t, store, testOptions{}), http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("default: expected 500, got %d", rec.Code)
+	}
+
+	h := newTestServerWithOptions(t, store, testOptions{
+		getter: usecase.GameGetterOptions{DegradeHistory: true},
+	})
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("degraded: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		GameID             string            `json:"game_id"`
+		MoveHistory        []json.RawMessage `json:"move_history"`
+		HistoryUnavailable bool              `json:"history_unavailable"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.GameID != gameID || !resp.HistoryUnavailable || resp.MoveHistory == nil || len(resp.MoveHistory) != 0 {
+		t.Fatalf("unexpected response: %+v", resp)
+	}
+
+	// Reads that need every move cannot degrade.
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/summary", nil, nil)
+	if rec.Code != http.StatusServiceUnavailable || problemCode(t, rec) != "history_unavailable" {
+		t.Fatalf("summary: expected 503 history_unavailable, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestGetGame_HistoryDisabled: with move history off, moves still advance the
+// game and its version but the detail reports no history, and reads that
+// need history fail with a history_disabled Problem.
+func TestGetGame_HistoryDisabled(t *testing.T) {
+	h := newTestServerWithOptions(t, memory.New(1).WithoutHistory(), testOptions{
+		getter: usecase.GameGetterOptions{HistoryDisabled: true},
+	})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d", rec.Code)
+	}
+	var resp struct {
+		StateVersion     int               `json:"state_version"`
+		MoveHistory      []json.RawMessage `json:"move_history"`
+		HistoryDisabled  bool              `json:"history_disabled"`
+		HistoryTruncated bool              `json:"history_truncated"`
+		TotalPlies       int               `json:"total_plies"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !resp.HistoryDisabled || resp.HistoryTruncated || resp.MoveHistory == nil || len(resp.MoveHistory) != 0 {
+		t.Fatalf("unexpected history fields: %+v", resp)
+	}
+	if resp.StateVersion != ver+1 || resp.TotalPlies != 1 {
+		t.Fatalf("want version %d and 1 ply, got %+v", ver+1, resp)
+	}
+
+	for _, path := range []string{"/contributors", "/moves.csv", "/summary"} {
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+path, nil, nil)
+		if rec.Code != http.StatusNotFound || problemCode(t, rec) != "history_disabled" {
+			t.Fatalf("%s: expected 404 history_disabled, got %d: %s", path, rec.Code, rec.Body.String())
+		}
+	}
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID, nil, map[string]string{"Accept": "application/x-chess-pgn"})
+	if rec.Code != http.StatusNotFound || problemCode(t, rec) != "history_disabled" {
+		t.Fatalf("pgn: expected 404 history_disabled, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Without the recorded move a retry cannot be replayed as a success.
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("retry: expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestSubmitMove_DeadLetter: a validated move that fails to persist is kept
+// in the dead-letter log and the client still sees a 500.
+func TestSubmitMove_DeadLetter(t *testing.T) {
+	store := &failingPersistStore{Store: memory.New(1)}
+	h := newTestServerWithOptions(t, store, testOptions{
+		submit: usecase.MoveSubmitterOptions{DeadLetter: true},
+	})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusInternalServerError {
+		t.Fatalf("expected 500, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if len(store.failed) != 1 {
+		t.Fatalf("expected 1 dead-letter record, got %d", len(store.failed))
+	}
+	fm := store.failed[0]
+	if fm.GameID.String() != gameID || fm.ClientID.String() != clientID {
+		t.Errorf("record ids: game=%s client=%s", fm.GameID, fm.ClientID)
+	}
+	if fm.UCI != "e2e4" || fm.FENBefore == "" || fm.FENAfter == "" || fm.Error == "" {
+		t.Errorf("incomplete record: %+v", fm)
+	}
+}
+
+// TestPatchGameMetadata: admins can set title/tags without affecting play.
+func TestAdminPoolStats(t *testing.T) {
+	h := newTestServer(t)
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/admin/pool-stats", nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: expected 401, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/pool-stats", nil, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp map[string]int64
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	for _, k := range []string{"total_conns", "idle_conns", "in_use_conns", "acquire_count", "acquire_duration_ms"} {
+		if v, ok := resp[k]; !ok || v != 0 {
+			t.Fatalf("%s: want 0 for the memory store, got %v (present=%v)", k, v, ok)
+		}
+	}
+}
+
+func TestPatchGameMetadata(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPatch, "/api/v1/admin/games/"+gameID,
+		map[string]any{"title": "Friday blitz", "tags": []string{"featured"}},
+		adminHeaders(),
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("patch: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Title        *string  `json:"title"`
+		Tags         []string `json:"tags"`
+		StateVersion int      `json:"state_version"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Title == nil || *resp.Title != "Friday blitz" {
+		t.Fatalf("title: want Friday blitz, got %v", resp.Title)
+	}
+	if len(resp.Tags) != 1 || resp.Tags[0] != "featured" {
+		t.Fatalf("tags: want [featured], got %v", resp.Tags)
+	}
+	if resp.StateVersion != ver {
+		t.Fatalf("state_version changed: want %d, got %d", ver, resp.StateVersion)
+	}
+
+	// The move CAS is unaffected and the metadata survives the move.
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// Clearing the title leaves tags alone.
+	rec = doRequest(t, h, http.MethodPatch, "/api/v1/admin/games/"+gameID,
+		map[string]any{"title": nil}, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("clear title: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	resp.Title, resp.Tags = nil, nil
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Title != nil {
+		t.Fatalf("title: want null, got %q", *resp.Title)
+	}
+	if len(resp.Tags) != 1 {
+		t.Fatalf("tags: want [featured], got %v", resp.Tags)
+	}
+}
+
+func TestPatchGameMetadata_RejectsUnknownField(t *testing.T) {
+	h := newTestServer(t)
+	gameID, _ := getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodPatch, "/api/v1/admin/games/"+gameID,
+		map[string]any{"fen": "8/8/8/8/8/8/8/8 w - - 0 1"}, adminHeaders())
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+// TestBodyLimits: admin routes accept bodies that the move route rejects.
+func TestBodyLimits(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, _ := getNextGame(t, h, clientID)
+
+	opts := defaultServerOptions()
+	opts.MoveMaxBody = "1K"
+	opts.AdminMaxBody = "64K"
+	e := transporthttp.New(h, opts)
+
+	// Valid JSON padded past the move limit but well under the admin limit.
+	padding := strings.Repeat(" ", 4096)
+	send := func(method, path, body string, headers map[string]string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		for k, v := range headers {
+			req.Header.Set(k, v)
+		}
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := send(http.MethodPatch, "/api/v1/admin/games/"+gameID,
+		`{"title":"big"}`+padding, adminHeaders()); code != http.StatusOK {
+		t.Fatalf("admin: expected 200, got %d", code)
+	}
+	if code := send(http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		`{"uci":"e2e4","expected_version":0}`+padding,
+		map[string]string{"X-Client-Id": clientID}); code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("move: expected 413, got %d", code)
+	}
+}
+
+func TestSubmitMove_Notations(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?notation=fan",
+		map[string]any{"uci": "g1f3", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("unknown notation: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?notation=san,lan",
+		map[string]any{"uci": "g1f3", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Move struct {
+			UCI       string            `json:"uci"`
+			Notations map[string]string `json:"notations"`
+		} `json:"move"`
+		Game struct {
+			MoveHistory []struct {
+				Notations map[string]string `json:"notations"`
+			} `json:"move_history"`
+		} `json:"game"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string]string{"san": "Nf3", "lan": "Ng1-f3"}
+	if resp.Move.UCI != "g1f3" || !maps.Equal(resp.Move.Notations, want) {
+		t.Fatalf("move: uci %q, notations %v", resp.Move.UCI, resp.Move.Notations)
+	}
+	if len(resp.Game.MoveHistory) != 1 || !maps.Equal(resp.Game.MoveHistory[0].Notations, want) {
+		t.Fatalf("history notations: %+v", resp.Game.MoveHistory)
+	}
+}
+
+func TestSubmitMove_IncludeChanges(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?include_changes=true",
+		map[string]any{"uci": "g1f3", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	type change struct {
+		Square      string `json:"square"`
+		BeforePiece string `json:"before_piece"`
+		AfterPiece  string `json:"after_piece"`
+	}
+	var resp struct {
+		Move struct {
+			Changes []change `json:"changes"`
+		} `json:"move"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []change{{Square: "g1", BeforePiece: "N"}, {Square: "f3", AfterPiece: "N"}}
+	if !slices.Equal(resp.Move.Changes, want) {
+		t.Fatalf("changes = %+v, want %+v", resp.Move.Changes, want)
+	}
+}
+
+func TestSubmitMove_EchoesClientNonce(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver, "client_nonce": "retry-7"},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Move map[string]any `json:"move"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Move["client_nonce"] != "retry-7" {
+		t.Fatalf("client_nonce = %v, want retry-7", resp.Move["client_nonce"])
+	}
+
+	gameID, ver = getNextGame(t, h, clientID)
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("second move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if strings.Contains(rec.Body.String(), "client_nonce") {
+		t.Fatalf("client_nonce present without a nonce: %s", rec.Body.String())
+	}
+}
+
+func TestSubmitMove_WouldBeInCheck(t *testing.T) {
+	store := memory.New(1)
+	// The e2 knight is pinned to its king by the e8 rook.
+	seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		g.FEN = "4r1k1/8/8/8/8/8/4N3/4K3 w - - 0 1"
+	})
+	h := newTestServerWithStore(t, store)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2c3", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if !strings.Contains(rec.Body.String(), `"code":"would_be_in_check"`) || !strings.Contains(rec.Body.String(), "/illegal-move") {
+		t.Fatalf("unexpected problem: %s", rec.Body.String())
+	}
+}
+
+func TestSubmitMove_FlagsBlunder(t *testing.T) {
+	store := memory.New(1)
+	// White is a queen up; Qf7 stalemates and throws the win away.
+	seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		g.FEN = "7k/4Q3/6K1/8/8/8/8/8 w - - 0 1"
+		g.SideToMove = "white"
+	})
+	h := newTestServerWithOptions(t, store, testOptions{submit: usecase.MoveSubmitterOptions{BlunderThresholdCP: 300}})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e7f7", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Move struct {
+			IsBlunder bool `json:"is_blunder"`
+		} `json:"move"`
+		Game struct {
+			Status      string `json:"status"`
+			MoveHistory []struct {
+				IsBlunder bool `json:"is_blunder"`
+			} `json:"move_history"`
+		} `json:"game"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Game.Status != "stalemate" {
+		t.Fatalf("want stalemate, got %q", resp.Game.Status)
+	}
+	if !resp.Move.IsBlunder || len(resp.Game.MoveHistory) != 1 || !resp.Game.MoveHistory[0].IsBlunder {
+		t.Fatalf("stalemating a won position should be flagged: %+v", resp)
+	}
+}
+
+func TestCORSPreflight_MatchesRegisteredMethods(t *testing.T) {
+	opts := defaultServerOptions()
+	opts.Metrics = metrics.NewRegistry()
+	e := transporthttp.New(newTestServer(t), opts)
+
+	want := make(map[string][]string)
+	for _, r := range e.Routes() {
+		if r.Method != echo.RouteNotFound {
+			want[r.Path] = append(want[r.Path], r.Method)
+		}
+	}
+	for path, methods := range want {
+		if !slices.Contains(methods, http.MethodOptions) {
+			methods = append(methods, http.MethodOptions)
+		}
+		slices.Sort(methods)
+
+		segments := strings.Split(path, "/")
+		for i, s := range segments {
+			if strings.HasPrefix(s, ":") {
+				segments[i] = uuid.NewString()
+			} else if name, ext, ok := strings.Cut(s, "."); ok && strings.HasPrefix(name, ":") {
+				segments[i] = uuid.NewString() + "." + ext
+			}
+		}
+		req := httptest.NewRequest(http.MethodOptions, strings.Join(segments, "/"), nil)
+		req.Header.Set("Origin", "https://chess.randomtoy.dev")
+		req.Header.Set("Access-Control-Request-Method", methods[0])
+		rec := httptest.NewRecorder()
+		e.ServeHTTP(rec, req)
+
+		var got []string
+		for _, m := range strings.Split(rec.Header().Get("Access-Control-Allow-Methods"), ",") {
+			got = append(got, strings.TrimSpace(m))
+		}
+		slices.Sort(got)
+		if !slices.Equal(got, methods) {
+			t.Errorf("%s: preflight allows %v, router accepts %v", path, got, methods)
+		}
+	}
+}
+
+func TestAdminClientActivity(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	first, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+first+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID, "User-Agent": "activity-test"},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	second, _ := getNextGame(t, h, clientID)
+
+	type page struct {
+		ClientID string `json:"client_id"`
+		Claims   []struct {
+			GameID   string `json:"game_id"`
+			HasMoved bool   `json:"has_moved"`
+			Move     *struct {
+				UCI string `json:"uci"`
+			} `json:"move"`
+		} `json:"claims"`
+		NextCursor string `json:"next_cursor"`
+	}
+	fetch := func(query string) page {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/admin/clients/"+clientID+query, nil, adminHeaders())
+		if rec.Code != http.StatusOK {
+			t.Fatalf("activity: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var p page
+		if err := json.NewDecoder(rec.Body).Decode(&p); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		return p
+	}
+
+	p := fetch("?limit=1")
+	if p.ClientID != clientID || len(p.Claims) != 1 || p.Claims[0].GameID != first ||
+		!p.Claims[0].HasMoved || p.Claims[0].Move == nil || p.Claims[0].Move.UCI != "e2e4" {
+		t.Fatalf("first page: %+v", p)
+	}
+	p = fetch("?limit=1&cursor=" + p.NextCursor)
+	if len(p.Claims) != 1 || p.Claims[0].GameID != second || p.Claims[0].HasMoved || p.Claims[0].Move != nil {
+		t.Fatalf("second page: %+v", p)
+	}
+	if p = fetch("?cursor=" + p.NextCursor); len(p.Claims) != 0 {
+		t.Fatalf("third page: %+v", p)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/clients/"+clientID, nil, nil)
+	if rec.Code != http.StatusUnauthorized {
+		t.Fatalf("without token: expected 401, got %d", rec.Code)
+	}
+}
+
+func TestSubmitMove_BothMoveForms(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	body := map[string]any{"uci": "d2d4", "from": "e2", "to": "e4", "expected_version": ver}
+	headers := map[string]string{"X-Client-Id": clientID}
+
+	strict := defaultServerOptions()
+	strict.StrictMoveInput = true
+	rec := doRequestWithOptions(t, h, strict, http.MethodPost, "/api/v1/games/"+gameID+"/moves", body, headers)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("strict: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var problem transporthttp.Problem
+	if err := json.NewDecoder(rec.Body).Decode(&problem); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasSuffix(problem.Type, "/ambiguous-move-input") {
+		t.Fatalf("strict: unexpected problem type %q", problem.Type)
+	}
+
+	// Lenient by default: from/to wins.
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves", body, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("lenient: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Move struct {
+			UCI string `json:"uci"`
+		} `json:"move"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Move.UCI != "e2e4" {
+		t.Fatalf("lenient: played %q, want e2e4", resp.Move.UCI)
+	}
+}
+
+func TestAdminGameEvents(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("submit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/games/"+gameID+"/events", nil, adminHeaders())
+	if rec.Code != http.StatusOK {
+		t.Fatalf("events: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Events []struct {
+			Kind   string  `json:"kind"`
+			Actor  *string `json:"actor"`
+			Detail string  `json:"detail"`
+		} `json:"events"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	var kinds []string
+	for _, ev := range resp.Events {
+		kinds = append(kinds, ev.Kind+":"+ev.Detail)
+	}
+	// Seeded memory games start ongoing, so claiming changes no status.
+	want := []string{"created:", "claimed:", "move:e2e4"}
+	if !slices.Equal(kinds, want) {
+		t.Fatalf("events %v, want %v", kinds, want)
+	}
+	if resp.Events[0].Actor != nil || resp.Events[2].Actor == nil || *resp.Events[2].Actor != clientID {
+		t.Fatalf("unexpected actors: %+v", resp.Events)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/admin/games/"+uuid.New().String()+"/events", nil, adminHeaders())
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestListChanged_LinkHeaders(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(3))
+	links := func(rec *httptest.ResponseRecorder) map[string]*url.URL {
+		t.Helper()
+		out := map[string]*url.URL{}
+		for _, link := range strings.Split(rec.Header().Get("Link"), ", ") {
+			target, params, ok := strings.Cut(link, ">; ")
+			if !ok || !strings.HasPrefix(target, "<") {
+				t.Fatalf("malformed link %q", link)
+			}
+			u, err := url.Parse(strings.TrimPrefix(target, "<"))
+			if err != nil || !u.IsAbs() {
+				t.Fatalf("link target %q: not an absolute URL", target)
+			}
+			rel, _ := strings.CutPrefix(params, "rel=")
+			out[strings.Trim(rel, `"`)] = u
+		}
+		return out
+	}
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/changed?limit=2&since=2000-01-01T00:00:00Z", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	got := links(rec)
+	if first := got["first"]; first == nil || first.Query().Get("since") != "2000-01-01T00:00:00Z" || first.Query().Has("cursor") {
+		t.Fatalf("first link: %v", first)
+	}
+	next := got["next"]
+	if next == nil || next.Path != "/api/v1/games/changed" || next.Query().Get("cursor") == "" || next.Query().Get("limit") != "2" {
+		t.Fatalf("next link: %v", next)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, next.RequestURI(), nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("next page: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	got = links(rec)
+	if got["first"] == nil {
+		t.Fatal("last page: missing first link")
+	}
+	if got["next"] != nil {
+		t.Fatalf("last page: unexpected next link %v", got["next"])
+	}
+}
+
+func TestPerft(t *testing.T) {
+	h := newTestServer(t)
+	gameID, _ := getNextGame(t, h, uuid.New().String())
+
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/perft?depth=2", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Depth int    `json:"depth"`
+		Nodes uint64 `json:"nodes"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Depth != 2 || resp.Nodes != 400 {
+		t.Fatalf("unexpected perft: %+v", resp)
+	}
+
+	for _, depth := range []string{"5", "-1", "deep", ""} {
+		rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/perft?depth="+depth, nil, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("depth %q: expected 400, got %d", depth, rec.Code)
+		}
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+uuid.New().String()+"/perft?depth=1", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+func TestSubmitMove_ConflictIncludesCurrentGame(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver + 5},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusConflict {
+		t.Fatalf("expected 409, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Type string `json:"type"`
+		Game *struct {
+			GameID       string `json:"game_id"`
+			StateVersion int    `json:"state_version"`
+			MoveHistory  []any  `json:"move_history"`
+		} `json:"game"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if !strings.HasSuffix(resp.Type, "/conflict") || resp.Game == nil {
+		t.Fatalf("unexpected body: %+v", resp)
+	}
+	if resp.Game.GameID != gameID || resp.Game.StateVersion != ver || resp.Game.MoveHistory == nil {
+		t.Fatalf("conflict game: %+v", resp.Game)
+	}
+}
+
+// TestStreamGames: the NDJSON stream pages past StreamPageSize, returns each
+// game once in ID order and honours the status filter.
+func TestStreamGames(t *testing.T) {
+	store := memory.New(usecase.StreamPageSize + 5)
+	h := newTestServerWithStore(t, store)
+	done := seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		draw := game.ResultDraw
+		g.Status, g.Result = game.StatusDraw, &draw
+	})
+
+	stream := func(query string) []string {
+		t.Helper()
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/games/stream"+query, nil, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: expected 200, got %d: %s", query, rec.Code, rec.Body.String())
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+			t.Fatalf("%s: Content-Type %q", query, ct)
+		}
+		var ids []string
+		for line := range strings.Lines(rec.Body.String()) {
+			var g struct {
+				GameID string `json:"game_id"`
+				Status string `json:"status"`
+			}
+			if err := json.Unmarshal([]byte(line), &g); err != nil {
+				t.Fatalf("%s: decode %q: %v", query, line, err)
+			}
+			if query == "?status=ongoing" && g.Status != "ongoing" {
+				t.Fatalf("%s: got a %s game", query, g.Status)
+			}
+			ids = append(ids, g.GameID)
+		}
+		return ids
+	}
+
+	all := stream("")
+	if len(all) != usecase.StreamPageSize+5 {
+		t.Fatalf("all: got %d games, want %d", len(all), usecase.StreamPageSize+5)
+	}
+	if !slices.IsSortedFunc(all, strings.Compare) || len(slices.Compact(slices.Clone(all))) != len(all) {
+		t.Fatal("all: games are not unique and in ID order")
+	}
+	if ongoing := stream("?status=ongoing"); len(ongoing) != usecase.StreamPageSize+4 {
+		t.Fatalf("ongoing: got %d games, want %d", len(ongoing), usecase.StreamPageSize+4)
+	}
+
+	if drawn := stream("?result=1/2-1/2"); !slices.Equal(drawn, []string{done.ID.String()}) {
+		t.Fatalf("drawn: got %v, want only %s", drawn, done.ID)
+	}
+	if won := stream("?status=draw&result=1-0"); len(won) != 0 {
+		t.Fatalf("draw won by white: got %v, want none", won)
+	}
+
+	for _, query := range []string{"?status=bogus", "?result=2-0"} {
+		rec := doRequest(t, h, http.MethodGet, "/api/v1/games/stream"+query, nil, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Fatalf("%s: expected 400, got %d", query, rec.Code)
+		}
+	}
+}
+
+// TestArtifactCacheControl: position and export endpoints are cacheable once
+// a game is finished and stay no-store while it is live.
+func TestArtifactCacheControl(t *testing.T) {
+	store := memory.New(2)
+	games := sampleGames(t, store, 2)
+	finished := seedGame(t, store, games[0].ID, func(g *game.Game) { g.Status = game.StatusStalemate })
+	live := games[1]
+
+	h := newTestServerWithStore(t, store)
+	opts := defaultServerOptions()
+	opts.FinishedMaxAge = time.Hour
+	for _, tc := range []struct{ suffix, accept string }{
+		{"/moves.csv", ""},
+		{"/theoretical", ""},
+		{"/perft?depth=1", ""},
+		{"", "application/x-chess-pgn"},
+		{"", "text/plain"},
+	} {
+		name := tc.suffix + tc.accept
+		var headers map[string]string
+		if tc.accept != "" {
+			headers = map[string]string{"Accept": tc.accept}
+		}
+		rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/"+finished.ID.String()+tc.suffix, nil, headers)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("finished %s: expected 200, got %d", name, rec.Code)
+		}
+		if cc := rec.Header().Get("Cache-Control"); cc != "public, max-age=3600" {
+			t.Errorf("finished %s: Cache-Control %q", name, cc)
+		}
+
+		rec = doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/"+live.ID.String()+tc.suffix, nil, headers)
+		if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("live %s: Cache-Control %q", name, cc)
+		}
+
+		rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+finished.ID.String()+tc.suffix, nil, headers)
+		if cc := rec.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("finished %s without max-age: Cache-Control %q", name, cc)
+		}
+	}
+}
+
+func TestValidateMoves(t *testing.T) {
+	h := newTestServer(t)
+	const start = "rnbqkbnr/pppppppp/8/8/8/8/PPPPPPPP/RNBQKBNR w KQkq - 0 1"
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/validate-moves", []map[string]string{
+		{"fen": start, "uci": "g1f3"},
+		{"fen": start, "uci": "e2e5"},
+		{"fen": start, "uci": "zz"},
+		{"fen": "8/8", "uci": "e2e4"},
+	}, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	type result struct {
+		Legal        bool   `json:"legal"`
+		ResultingFEN string `json:"resulting_fen"`
+		Reason       string `json:"reason"`
+	}
+	var resp struct {
+		Results []result `json:"results"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := []result{
+		{Legal: true, ResultingFEN: "rnbqkbnr/pppppppp/8/8/8/5N2/PPPPPPPP/RNBQKB1R b KQkq - 1 1"},
+		{Reason: "illegal_move"},
+		{Reason: "invalid_uci"},
+		{Reason: "invalid_fen"},
+	}
+	if !slices.Equal(resp.Results, want) {
+		t.Fatalf("results = %+v, want %+v", resp.Results, want)
+	}
+
+	for name, body := range map[string]any{
+		"empty":     []map[string]string{},
+		"not array": map[string]string{"fen": start, "uci": "e2e4"},
+		"too many":  slices.Repeat([]map[string]string{{"fen": start, "uci": "e2e4"}}, 101),
+	} {
+		rec := doRequest(t, h, http.MethodPost, "/api/v1/validate-moves", body, nil)
+		if rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: expected 400, got %d", name, rec.Code)
+		}
+	}
+}
+
+// denyAll is a rate limiter that rejects every request.
+type denyAll struct{}
+
+func (denyAll) Allow(string, string) bool { return false }
+
+// TestSubmitMove_RejectionCodes: every way the move endpoint can reject a
+// submission carries a code, whatever the status.
+func TestSubmitMove_RejectionCodes(t *testing.T) {
+	store := memory.New(1)
+	h := newTestServerWithStore(t, store)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	submit := func(h *transporthttp.Handlers, gameID, clientID, uci string, ver int) *httptest.ResponseRecorder {
+		t.Helper()
+		return doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+			map[string]any{"uci": uci, "expected_version": ver},
+			map[string]string{"X-Client-Id": clientID},
+		)
+	}
+	check := func(name string, rec *httptest.ResponseRecorder, status int, code string) {
+		t.Helper()
+		var resp struct {
+			Code string `json:"code"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if rec.Code != status || resp.Code != code {
+			t.Errorf("%s: got %d %q, want %d %q", name, rec.Code, resp.Code, status, code)
+		}
+	}
+
+	check("unknown game", submit(h, uuid.New().String(), clientID, "e2e4", ver), http.StatusNotFound, "not_found")
+	check("unassigned client", submit(h, gameID, uuid.New().String(), "e2e4", ver), http.StatusForbidden, "not_assigned")
+	check("bad uci", submit(h, gameID, clientID, "zz", ver), http.StatusUnprocessableEntity, "invalid_uci")
+	check("illegal move", submit(h, gameID, clientID, "e2e5", ver), http.StatusUnprocessableEntity, "illegal_move")
+	check("stale version", submit(h, gameID, clientID, "e2e4", ver+1), http.StatusConflict, "version_conflict")
+	if rec := submit(h, gameID, clientID, "e2e4", ver); rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	check("second move", submit(h, gameID, clientID, "e7e5", ver+1), http.StatusConflict, "one_move_limit")
+
+	limited := transporthttp.NewHandlers(
+		usecase.NewAssigner(store, denyAll{}),
+		usecase.NewNextGame(store, denyAll{}, testBatchSize, usecase.NextGameOptions{}),
+		usecase.NewGameGetter(store, denyAll{}, usecase.GameGetterOptions{}),
+		usecase.NewMoveSubmitter(store, denyAll{}, usecase.MoveSubmitterOptions{}),
+		usecase.NewAdmin(store, usecase.NewBlocklist(store, nil)),
+	)
+	check("rate limited", submit(limited, gameID, clientID, "e7e5", ver+1), http.StatusTooManyRequests, "rate_limited")
+}
+
+// TestSubmitMove_MissingExpectedVersion: expected_version may only be left
+// out before anyone has moved in the game.
+func TestSubmitMove_MissingExpectedVersion(t *testing.T) {
+	h := newTestServerWithStore(t, memory.New(1))
+	first := uuid.New().String()
+	gameID, _ := getNextGame(t, h, first)
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4"},
+		map[string]string{"X-Client-Id": first},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("fresh game: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	second := uuid.New().String()
+	if id, _ := getNextGame(t, h, second); id != gameID {
+		t.Fatalf("want the same game, got %s", id)
+	}
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e7e5"},
+		map[string]string{"X-Client-Id": second},
+	)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("moved game: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Code string `json:"code"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Code != "missing_expected_version" {
+		t.Fatalf("code = %q", resp.Code)
+	}
+}
+
+// TestGetNext_LastMove: the claim response highlights the last move, and
+// still does when history is off, only without SAN.
+func TestGetNext_LastMove(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		store   *memory.Store
+		wantSAN string
+	}{
+		{"history", memory.New(1), "Nf3"},
+		{"without history", memory.New(1).WithoutHistory(), ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			h := newTestServerWithStore(t, tc.store)
+
+			type claimResp struct {
+				Game struct {
+					GameID       string `json:"game_id"`
+					StateVersion int    `json:"state_version"`
+				} `json:"game"`
+				LastMove *struct {
+					UCI      string `json:"uci"`
+					From     string `json:"from"`
+					To       string `json:"to"`
+					SAN      string `json:"san"`
+					PlayedAt string `json:"played_at"`
+				} `json:"last_move"`
+			}
+			claim := func(clientID string) claimResp {
+				t.Helper()
+				rec := doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{"X-Client-Id": clientID})
+				if rec.Code != http.StatusOK {
+					t.Fatalf("GET /games/next: expected 200, got %d: %s", rec.Code, rec.Body.String())
+				}
+				if !strings.Contains(rec.Body.String(), `"last_move"`) {
+					t.Fatalf("last_move missing from response: %s", rec.Body.String())
+				}
+				var resp claimResp
+				if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+					t.Fatalf("decode: %v", err)
+				}
+				return resp
+			}
+
+			first := uuid.New().String()
+			fresh := claim(first)
+			if fresh.LastMove != nil {
+				t.Fatalf("fresh game: want null last_move, got %+v", fresh.LastMove)
+			}
+			rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+fresh.Game.GameID+"/moves",
+				map[string]any{"uci": "g1f3", "expected_version": fresh.Game.StateVersion},
+				map[string]string{"X-Client-Id": first},
+			)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+			}
+
+			resumed := claim(uuid.New().String())
+			if resumed.Game.GameID != fresh.Game.GameID {
+				t.Fatalf("expected the in-progress game %s, got %s", fresh.Game.GameID, resumed.Game.GameID)
+			}
+			lm := resumed.LastMove
+			if lm == nil || lm.UCI != "g1f3" || lm.From != "g1" || lm.To != "f3" || lm.SAN != tc.wantSAN || lm.PlayedAt == "" {
+				t.Fatalf("last_move = %+v, want g1f3 g1->f3 %q", lm, tc.wantSAN)
+			}
+		})
+	}
+}
+
+func TestGetGameSummary(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+gameID+"/summary", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		GameID      string   `json:"game_id"`
+		Status      string   `json:"status"`
+		Result      *string  `json:"result"`
+		StartingFEN string   `json:"starting_fen"`
+		Moves       []string `json:"moves"`
+		CurrentFEN  string   `json:"current_fen"`
+		PlyCount    int      `json:"ply_count"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.GameID != gameID || resp.Status != "ongoing" || resp.Result != nil {
+		t.Fatalf("unexpected game fields: %+v", resp)
+	}
+	if resp.StartingFEN != game.StartingFEN {
+		t.Fatalf("starting_fen = %q, want the standard position", resp.StartingFEN)
+	}
+	if len(resp.Moves) != 1 || resp.Moves[0] != "e2e4" || resp.PlyCount != 1 {
+		t.Fatalf("moves = %v, ply_count = %d; want [e2e4], 1", resp.Moves, resp.PlyCount)
+	}
+	if !strings.HasPrefix(resp.CurrentFEN, "rnbqkbnr/pppppppp/8/8/4P3/") {
+		t.Fatalf("current_fen = %q, want the position after e2e4", resp.CurrentFEN)
+	}
+
+	rec = doRequest(t, h, http.MethodGet, "/api/v1/games/"+uuid.New().String()+"/summary", nil, nil)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+// slowStore makes the single-game read and move persistence take delay,
+// giving up early when the request context ends.
+type slowStore struct {
+	*memory.Store
+	delay time.Duration
+}
+
+func (s slowStore) wait(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (s slowStore) GetGameWithHistory(ctx context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, nil, err
+	}
+	return s.Store.GetGameWithHistory(ctx, id)
+}
+
+func (s slowStore) PersistMove(ctx context.Context, gameID, clientID uuid.UUID, newGame *game.Game, rec game.MoveRecord, ply int) ([]game.MoveHistoryItem, error) {
+	if err := s.wait(ctx); err != nil {
+		return nil, err
+	}
+	return s.Store.PersistMove(ctx, gameID, clientID, newGame, rec, ply)
+}
+
+func TestRouteTimeouts(t *testing.T) {
+	store := slowStore{Store: memory.New(testBatchSize), delay: 100 * time.Millisecond}
+	h := newTestServerWithOptions(t, store, testOptions{})
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	opts := defaultServerOptions()
+	opts.ReadTimeout = 20 * time.Millisecond
+	opts.WriteTimeout = time.Second
+
+	// The read limit cuts the slow read short...
+	start := time.Now()
+	rec := doRequestWithOptions(t, h, opts, http.MethodGet, "/api/v1/games/"+gameID, nil, nil)
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow read: expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed >= store.delay {
+		t.Fatalf("slow read took %v, want it cut at the %v read limit", elapsed, opts.ReadTimeout)
+	}
+	if !strings.Contains(rec.Body.String(), `"request_timeout"`) || rec.Header().Get("Retry-After") == "" {
+		t.Fatalf("slow read: want request_timeout with Retry-After, got %s", rec.Body.String())
+	}
+
+	// ...but not the equally slow write, which has the larger write limit.
+	move := map[string]any{"uci": "e2e4", "expected_version": ver}
+	rec = doRequestWithOptions(t, h, opts, http.MethodPost, "/api/v1/games/"+gameID+"/moves", move,
+		map[string]string{"X-Client-Id": clientID})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("write under the write limit: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	opts.WriteTimeout = 20 * time.Millisecond
+	h = newTestServerWithOptions(t, slowStore{Store: memory.New(1), delay: store.delay}, testOptions{})
+	other := uuid.New().String()
+	gameID, ver = getNextGame(t, h, other)
+	start = time.Now()
+	rec = doRequestWithOptions(t, h, opts, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver}, map[string]string{"X-Client-Id": other})
+	if rec.Code != http.StatusServiceUnavailable {
+		t.Fatalf("slow write: expected 503, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if elapsed := time.Since(start); elapsed >= store.delay {
+		t.Fatalf("slow write took %v, want it cut at the %v write limit", elapsed, opts.WriteTimeout)
+	}
+}
+
+func TestAdmin_SignedRequests(t *testing.T) {
+	h := newTestServer(t)
+	opts := defaultServerOptions()
+	opts.AdminSignedRequests = true
+	opts.AdminSignatureSkew = time.Minute
+	// One server for the whole test, so it remembers used signatures.
+	srv := transporthttp.New(h, opts)
+
+	clientID := uuid.New().String()
+	path := "/api/v1/admin/blocked-clients/" + clientID
+	signed := func(method, uri string, at time.Time) *http.Request {
+		ts := strconv.FormatInt(at.Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(testAdminToken))
+		mac.Write([]byte(method + "\n" + uri + "\n" + ts + "\n"))
+		req := httptest.NewRequest(method, uri, nil)
+		req.Header.Set("X-Admin-Timestamp", ts)
+		req.Header.Set("X-Admin-Signature", hex.EncodeToString(mac.Sum(nil)))
+		return req
+	}
+	serve := func(req *http.Request) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	now := time.Now()
+	rec := serve(signed(http.MethodPut, path, now))
+	if rec.Code != http.StatusNoContent {
+		t.Fatalf("valid signature: expected 204, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(signed(http.MethodPut, path, now))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "signature_replayed") {
+		t.Fatalf("replayed signature: expected 401 signature_replayed, got %d: %s", rec.Code, rec.Body.String())
+	}
+	req := signed(http.MethodPut, path, now)
+	req.Header.Set("X-Admin-Signature", strings.ToUpper(req.Header.Get("X-Admin-Signature")))
+	rec = serve(req)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "signature_replayed") {
+		t.Fatalf("upper-cased replay: expected 401 signature_replayed, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	rec = serve(signed(http.MethodDelete, path, now.Add(-2*time.Minute)))
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "signature_expired") {
+		t.Fatalf("expired signature: expected 401 signature_expired, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// A signature for one request does not authorize another.
+	req = signed(http.MethodDelete, path, now)
+	req.URL.Path = "/api/v1/admin/blocked-clients/" + uuid.New().String()
+	rec = serve(req)
+	if rec.Code != http.StatusUnauthorized || !strings.Contains(rec.Body.String(), "signature_invalid") {
+		t.Fatalf("tampered request: expected 401 signature_invalid, got %d: %s", rec.Code, rec.Body.String())
+	}
+
+	// The bearer token alone is no longer enough.
+	req = httptest.NewRequest(http.MethodGet, "/api/v1/admin/blocked-clients", nil)
+	for k, v := range adminHeaders() {
+		req.Header.Set(k, v)
+	}
+	if rec = serve(req); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("bearer token in signed mode: expected 401, got %d", rec.Code)
+	}
+}
+
+// slowFindStore widens the gap between the active-claim check and the claim,
+// for a check run outside the claim's transaction.
+type slowFindStore struct {
+	*memory.Store
+}
+
+func (s slowFindStore) FindActiveClaim(ctx context.Context, clientID uuid.UUID) (uuid.UUID, bool, error) {
+	gameID, found, err := s.Store.FindActiveClaim(ctx, clientID)
+	time.Sleep(10 * time.Millisecond)
+	return gameID, found, err
+}
+
+// TestGetNext_SingleActiveClaimConcurrent: in single-active-claim mode,
+// concurrent claims from one client yield exactly one game; the rest are
+// told to finish it.
+func TestGetNext_SingleActiveClaimConcurrent(t *testing.T) {
+	const claims = 20
+	h := newTestServerWithOptions(t, slowFindStore{memory.New(claims)}, testOptions{
+		next: usecase.NextGameOptions{SingleActiveClaim: true},
+	})
+	srv := transporthttp.New(h, defaultServerOptions())
+	clientID := uuid.New().String()
+
+	recs := make([]*httptest.ResponseRecorder, claims)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/games/next", nil)
+			req.Header.Set("X-Client-Id", clientID)
+			recs[i] = httptest.NewRecorder()
+			srv.ServeHTTP(recs[i], req)
+		}()
+	}
+	wg.Wait()
+
+	claimed := 0
+	for _, rec := range recs {
+		switch rec.Code {
+		case http.StatusOK:
+			claimed++
+		case http.StatusConflict:
+		default:
+			t.Fatalf("expected 200 or 409, got %d: %s", rec.Code, rec.Body.String())
+		}
+	}
+	if claimed != 1 {
+		t.Fatalf("got %d claims, want exactly 1", claimed)
+	}
+}
+
+// TestGetNext_ConcurrentClaimsSameClient: concurrent claims from one client
+// must never hand it the same game twice, as in the postgres store.
+func TestGetNext_ConcurrentClaimsSameClient(t *testing.T) {
+	const claims = 20
+	srv := transporthttp.New(newTestServerWithStore(t, memory.New(claims/2)), defaultServerOptions())
+	clientID := uuid.New().String()
+
+	recs := make([]*httptest.ResponseRecorder, claims)
+	var wg sync.WaitGroup
+	for i := range recs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/games/next", nil)
+			req.Header.Set("X-Client-Id", clientID)
+			recs[i] = httptest.NewRecorder()
+			srv.ServeHTTP(recs[i], req)
+		}()
+	}
+	wg.Wait()
+
+	seen := map[string]bool{}
+	for _, rec := range recs {
+		if rec.Code == http.StatusServiceUnavailable {
+			continue // ran out of games
+		}
+		if rec.Code != http.StatusOK {
+			t.Fatalf("expected 200 or 503, got %d: %s", rec.Code, rec.Body.String())
+		}
+		var resp struct {
+			Game struct {
+				GameID string `json:"game_id"`
+			} `json:"game"`
+		}
+		if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		if seen[resp.Game.GameID] {
+			t.Fatalf("game %s handed to the same client twice", resp.Game.GameID)
+		}
+		seen[resp.Game.GameID] = true
+	}
+	if len(seen) < claims/2 {
+		t.Fatalf("only %d claims succeeded, want at least the %d seeded games", len(seen), claims/2)
+	}
+}
+
+func TestSubmitMove_LegalGroupedByFrom(t *testing.T) {
+	store := memory.New(1)
+	// After white's king move, black can only move its king or promote.
+	seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		g.FEN = "4k3/8/8/8/8/8/1p6/4K3 w - - 0 1"
+	})
+	h := newTestServerWithStore(t, store)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+
+	rec := doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?include_legal=true&group_by=from",
+		map[string]any{"uci": "e1f2", "expected_version": ver},
+		map[string]string{"X-Client-Id": clientID},
+	)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		LegalMoves map[string][]string `json:"legal_moves"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	want := map[string][]string{
+		"b2": {"b1b", "b1n", "b1q", "b1r"},
+		"e8": {"d7", "d8", "e7", "f7", "f8"},
+	}
+	if !maps.EqualFunc(resp.LegalMoves, want, slices.Equal) {
+		t.Fatalf("legal_moves = %v, want %v", resp.LegalMoves, want)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves?include_legal=true&group_by=to",
+		map[string]any{"uci": "e8d8", "expected_version": ver + 1},
+		map[string]string{"X-Client-Id": uuid.New().String()},
+	)
+	if rec.Code != http.StatusBadRequest {
+		t.Fatalf("group_by=to: expected 400, got %d: %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestVersion(t *testing.T) {
+	h := newTestServer(t)
+	rec := doRequest(t, h, http.MethodGet, "/api/v1/version", nil, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var resp struct {
+		Version      string            `json:"version"`
+		ChessLibrary map[string]string `json:"chess_library"`
+		Ruleset      map[string]bool   `json:"ruleset"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&resp); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if resp.Version == "" || resp.ChessLibrary["module"] != "github.com/notnil/chess" || resp.ChessLibrary["version"] == "" {
+		t.Fatalf("unexpected build info: %+v", resp)
+	}
+	want := map[string]bool{
+		"underpromotion":                 true,
+		"auto_insufficient_material":     true,
+		"auto_seventy_five_move_rule":    true,
+		"auto_fivefold_repetition":       false,
+		"claimable_threefold_repetition": true,
+		"claimable_fifty_move_rule":      true,
+	}
+	if !maps.Equal(resp.Ruleset, want) {
+		t.Fatalf("ruleset = %v, want %v", resp.Ruleset, want)
+	}
+}
+
+// blockingClaimStore holds every ClaimNextGame until release is closed,
+// signalling entered as each one starts.
+type blockingClaimStore struct {
+	*memory.Store
+	entered chan struct{}
+	release chan struct{}
+}
+
+func (s blockingClaimStore) ClaimNextGame(ctx context.Context, clientID uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	s.entered <- struct{}{}
+	<-s.release
+	return s.Store.ClaimNextGame(ctx, clientID)
+}
+
+func TestGetNext_ClaimOverflow(t *testing.T) {
+	claim := func(h *transporthttp.Handlers) *httptest.ResponseRecorder {
+		return doRequest(t, h, http.MethodGet, "/api/v1/games/next", nil, map[string]string{"X-Client-Id": uuid.New().String()})
+	}
+	// saturate returns a server whose single claim slot is taken by a claim
+	// that runs until the returned function is called.
+	saturate := func(next usecase.NextGameOptions) (*transporthttp.Handlers, func()) {
+		store := blockingClaimStore{Store: memory.New(testBatchSize), entered: make(chan struct{}, 10), release: make(chan struct{})}
+		next.MaxConcurrentClaims = 1
+		h := newTestServerWithOptions(t, store, testOptions{next: next})
+		done := make(chan int)
+		go func() {
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/games/next", nil)
+			req.Header.Set("X-Client-Id", uuid.New().String())
+			rec := httptest.NewRecorder()
+			transporthttp.New(h, defaultServerOptions()).ServeHTTP(rec, req)
+			done <- rec.Code
+		}()
+		<-store.entered
+		return h, func() {
+			close(store.release)
+			if code := <-done; code != http.StatusOK {
+				t.Errorf("slot holder: expected 200, got %d", code)
+			}
+		}
+	}
+
+	t.Run("reject", func(t *testing.T) {
+		h, finish := saturate(usecase.NextGameOptions{ClaimOverflow: usecase.ClaimOverflowReject})
+		defer finish()
+		rec := claim(h)
+		if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), `"claims_saturated"`) {
+			t.Fatalf("expected 429 claims_saturated, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("queue times out", func(t *testing.T) {
+		h, finish := saturate(usecase.NextGameOptions{ClaimOverflow: usecase.ClaimOverflowQueue, ClaimQueueTimeout: 20 * time.Millisecond})
+		defer finish()
+		rec := claim(h)
+		if rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), `"claim_queue_timeout"`) {
+			t.Fatalf("expected 503 claim_queue_timeout, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+
+	t.Run("queue proceeds", func(t *testing.T) {
+		h, finish := saturate(usecase.NextGameOptions{ClaimOverflow: usecase.ClaimOverflowQueue, ClaimQueueTimeout: 5 * time.Second})
+		queued := make(chan *httptest.ResponseRecorder)
+		go func() { queued <- claim(h) }()
+		// Free the slot while the second claim waits for it.
+		time.Sleep(20 * time.Millisecond)
+		finish()
+		if rec := <-queued; rec.Code != http.StatusOK {
+			t.Fatalf("queued claim: expected 200, got %d: %s", rec.Code, rec.Body.String())
+		}
+	})
+}
+
+// TestMoveDraft: a client's draft is returned on reconnect, is private to the
+// client, and is cleared once the real move is accepted.
+func TestMoveDraft(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	draftURL := "/api/v1/games/" + gameID + "/draft"
+	headers := map[string]string{"X-Client-Id": clientID}
+
+	getDraft := func(headers map[string]string) *httptest.ResponseRecorder {
+		t.Helper()
+		return doRequest(t, h, http.MethodGet, draftURL, nil, headers)
+	}
+	if rec := getDraft(headers); rec.Code != http.StatusNotFound {
+		t.Fatalf("before put: expected 404, got %d", rec.Code)
+	}
+
+	rec := doRequest(t, h, http.MethodPut, draftURL, map[string]any{"from": "e2", "to": "e4"}, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("put: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	rec = getDraft(headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	var draft struct {
+		From    string `json:"from"`
+		To      string `json:"to"`
+		SavedAt string `json:"saved_at"`
+	}
+	if err := json.NewDecoder(rec.Body).Decode(&draft); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if draft.From != "e2" || draft.To != "e4" || draft.SavedAt == "" {
+		t.Fatalf("unexpected draft: %+v", draft)
+	}
+	if rec := getDraft(map[string]string{"X-Client-Id": uuid.New().String()}); rec.Code != http.StatusNotFound {
+		t.Fatalf("other client: expected 404, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, h, http.MethodPost, "/api/v1/games/"+gameID+"/moves",
+		map[string]any{"uci": "e2e4", "expected_version": ver}, headers)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("move: expected 200, got %d: %s", rec.Code, rec.Body.String())
+	}
+	if rec := getDraft(headers); rec.Code != http.StatusNotFound {
+		t.Fatalf("after move: expected 404, got %d", rec.Code)
+	}
+
+	rec = doRequest(t, h, http.MethodPut, "/api/v1/games/"+uuid.New().String()+"/draft",
+		map[string]any{"from": "e2", "to": "e4"}, headers)
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("unknown game: expected 404, got %d", rec.Code)
+	}
+}
+
+// TestRejections_CarryCode: every rejection body has a machine-readable code,
+// whichever layer writes it.
+func TestRejections_CarryCode(t *testing.T) {
+	h := newTestServer(t)
+	clientID := uuid.New().String()
+	gameID, ver := getNextGame(t, h, clientID)
+	client := map[string]string{"X-Client-Id": clientID}
+	moves := "/api/v1/games/" + gameID + "/moves"
+
+	strict := defaultServerOptions()
+	strict.StrictMoveInput = true
+	readOnly := defaultServerOptions()
+	readOnly.ReadOnly = true
+
+	cases := []struct {
+		name         string
+		opts         transporthttp.Options
+		method, path string
+		body         any
+		headers      map[string]string
+		wantStatus   int
+		wantCode     string
+	}{
+		{"missing client id", defaultServerOptions(), http.MethodGet, "/api/v1/games/next", nil, nil, http.StatusBadRequest, "missing_client_id"},
+		{"invalid client id", defaultServerOptions(), http.MethodGet, "/api/v1/games/next", nil, map[string]string{"X-Client-Id": "nope"}, http.StatusBadRequest, "invalid_client_id"},
+		{"placeholder client id", defaultServerOptions(), http.MethodGet, "/api/v1/games/next", nil, map[string]string{"X-Client-Id": uuid.Nil.String()}, http.StatusBadRequest, "placeholder_client_id"},
+		{"read-only", readOnly, http.MethodGet, "/api/v1/games/next", nil, client, http.StatusServiceUnavailable, "read_only"},
+		{"ambiguous move input", strict, http.MethodPost, moves, map[string]any{"uci": "e2e4", "from": "e2", "to": "e4", "expected_version": ver}, client, http.StatusBadRequest, "ambiguous_move_input"},
+		{"invalid move field", defaultServerOptions(), http.MethodPost, moves, map[string]any{"from": "z9", "to": "e4", "expected_version": ver}, client, http.StatusBadRequest, "invalid_move_field"},
+		{"invalid notation", defaultServerOptions(), http.MethodPost, moves + "?notation=fan", map[string]any{"uci": "e2e4", "expected_version": ver}, client, http.StatusBadRequest, "invalid_query"},
+		{"invalid since", defaultServerOptions(), http.MethodGet, "/api/v1/games/changed?since=yesterday", nil, nil, http.StatusBadRequest, "invalid_query"},
+		{"invalid cursor", defaultServerOptions(), http.MethodGet, "/api/v1/games/changed?cursor=forged", nil, nil, http.StatusBadRequest, "invalid_cursor"},
+		{"unparsable depth", defaultServerOptions(), http.MethodGet, "/api/v1/games/" + gameID + "/perft?depth=deep", nil, nil, http.StatusBadRequest, "invalid_query"},
+		{"depth out of range", defaultServerOptions(), http.MethodGet, "/api/v1/games/" + gameID + "/perft?depth=5", nil, nil, http.StatusBadRequest, "invalid_depth"},
+		{"invalid batch", defaultServerOptions(), http.MethodPost, "/api/v1/validate-moves", []map[string]string{}, nil, http.StatusBadRequest, "invalid_batch"},
+		{"missing admin token", defaultServerOptions(), http.MethodGet, "/api/v1/admin/blocked-clients", nil, nil, http.StatusUnauthorized, "unauthorized"},
+		{"invalid admin client id", defaultServerOptions(), http.MethodPut, "/api/v1/admin/blocked-clients/nope", nil, adminHeaders(), http.StatusBadRequest, "invalid_client_id"},
+		{"unknown metadata field", defaultServerOptions(), http.MethodPatch, "/api/v1/admin/games/" + gameID, map[string]any{"fen": "8/8/8/8/8/8/8/8 w - - 0 1"}, adminHeaders(), http.StatusBadRequest, "invalid_metadata"},
+		{"metadata too long", defaultServerOptions(), http.MethodPatch, "/api/v1/admin/games/" + gameID, map[string]any{"title": strings.Repeat("x", 201)}, adminHeaders(), http.StatusBadRequest, "invalid_metadata"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := doRequestWithOptions(t, h, tc.opts, tc.method, tc.path, tc.body, tc.headers)
+			if rec.Code != tc.wantStatus {
+				t.Fatalf("expected %d, got %d: %s", tc.wantStatus, rec.Code, rec.Body.String())
+			}
+			if got := problemCode(t, rec); got != tc.wantCode {
+				t.Fatalf("code = %q, want %q", got, tc.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package http
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+// gameFormat is a representation GET /games/:id can negotiate.
+type gameFormat int
+
+const (
+	formatJSON gameFormat = iota
+	formatPGN
+	formatFEN
+)
+
+const mimePGN = "application/x-chess-pgn"
+
+// gameFormats maps the media ranges handleGetGame accepts to the format they
+// select.
+var gameFormats = map[string]gameFormat{
+	"*/*":                    formatJSON,
+	"application/*":          formatJSON,
+	echo.MIMEApplicationJSON: formatJSON,
+	mimePGN:                  formatPGN,
+	"text/*":                 formatFEN,
+	echo.MIMETextPlain:       formatFEN,
+}
+
+// negotiateGameFormat picks the first media range of an Accept header that
+// names a supported format. Quality values are not weighed, except that q=0
+// rules a range out. A missing header means JSON; ok is false when nothing
+// listed is supported.
+func negotiateGameFormat(accept string) (f gameFormat, ok bool) {
+	if strings.TrimSpace(accept) == "" {
+		return formatJSON, true
+	}
+	for _, r := range strings.Split(accept, ",") {
+		params := strings.Split(r, ";")
+		if slices.ContainsFunc(params[1:], zeroQuality) {
+			continue
+		}
+		if f, ok := gameFormats[strings.ToLower(strings.TrimSpace(params[0]))]; ok {
+			return f, true
+		}
+	}
+	return 0, false
+}
+
+// zeroQuality reports whether a media range parameter is q=0.
+func zeroQuality(param string) bool {
+	q, found := strings.CutPrefix(strings.TrimSpace(param), "q=")
+	return found && strings.Trim(q, "0.") == ""
+}
+
+func notAcceptable(c echo.Context) error {
+	return c.JSON(http.StatusNotAcceptable, Problem{
+		Type:   errBase + "/not-acceptable",
+		Title:  "Not Acceptable",
+		Status: http.StatusNotAcceptable,
+		Detail: "Accept must allow application/json, " + mimePGN + ", or text/plain.",
+		Code:   "not_acceptable",
+	})
+}
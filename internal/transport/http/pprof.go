@@ -0,0 +1,19 @@
+package http
+
+import (
+	"net/http"
+	"net/http/pprof"
+)
+
+// NewPprofHandler returns a mux serving the net/http/pprof endpoints under
+// /debug/pprof/. It is meant for a separate, non-public listener and is never
+// mounted on the API server.
+func NewPprofHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	return mux
+}
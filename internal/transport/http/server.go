@@ -1,27 +1,323 @@
 package http
 
 import (
+	"context"
+	"crypto/rand"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
+
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
 )
 
+// Options holds transport-level configuration for New.
+type Options struct {
+	// AdminToken guards the /api/v1/admin routes. Admin routes are not
+	// registered when it is empty.
+	AdminToken string
+
+	// AdminSignedRequests replaces the bearer token check with HMAC-signed,
+	// timestamped requests keyed by AdminToken, which cannot be replayed if
+	// a request leaks; see requireAdminSignature. AdminSignatureSkew bounds
+	// the accepted clock difference, zero meaning five minutes.
+	AdminSignedRequests bool
+	AdminSignatureSkew  time.Duration
+
+	// MoveMaxBody and AdminMaxBody cap request bodies on the gameplay write
+	// routes and the admin routes respectively, in echo BodyLimit syntax
+	// ("4K", "1M"). Empty means no limit.
+	MoveMaxBody  string
+	AdminMaxBody string
+
+	// PlaceholderClientIDs are well-known placeholder UUIDs rejected as
+	// X-Client-Id in addition to the nil UUID and usecase.HouseClientID.
+	PlaceholderClientIDs []uuid.UUID
+
+	// CursorSecret signs pagination cursors. When empty a random secret is
+	// generated, so cursors do not survive restarts or span replicas.
+	CursorSecret []byte
+
+	// Metrics, when set, is exposed in the Prometheus text format on
+	// GET /metrics and tracks the requests in flight.
+	Metrics *metrics.Registry
+
+	// AdminMetrics, when set, is exposed in the Prometheus text format on
+	// GET /api/v1/admin/metrics behind the admin token, for series such as
+	// the database pool stats that must not be public. It is not served
+	// without AdminToken.
+	AdminMetrics *metrics.Registry
+
+	// RouteInFlight additionally tracks in-flight requests per route. It
+	// has no effect without Metrics.
+	RouteInFlight bool
+
+	// RetryAfter and RetryJitter set the retry hint on rate-limit and
+	// no-games responses: RetryAfter plus up to RetryJitter of random delay.
+	// Zero RetryAfter means 2s.
+	RetryAfter  time.Duration
+	RetryJitter time.Duration
+
+	// ReadOnly rejects game claims and move submissions with 503 while
+	// reads keep working. Admin routes are unaffected.
+	ReadOnly bool
+
+	// StrictMoveInput rejects move submissions that carry both uci and
+	// from/to with 400, instead of letting from/to win.
+	StrictMoveInput bool
+
+	// FinishedMaxAge is the Cache-Control max-age of export and position
+	// endpoints (moves.csv, theoretical, perft) for finished games, which
+	// never change. Zero keeps them no-store like every live response.
+	FinishedMaxAge time.Duration
+
+	// BasePath prefixes every route, e.g. "/chess" serves the API under
+	// /chess/api/v1. It must start with a slash and not end with one; empty
+	// mounts the API at the root.
+	BasePath string
+
+	// RobotsTxt is served verbatim at /robots.txt. Empty serves a policy
+	// that disallows crawling everything under <BasePath>/api/, since game
+	// URLs are otherwise indexable.
+	RobotsTxt string
+
+	// ReadTimeout and WriteTimeout bound the request context of the game
+	// read routes and of the claim and move routes respectively; a store
+	// call still running at the deadline is abandoned with 503. Zero means
+	// no limit.
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+}
+
+// bodyLimit returns a BodyLimit middleware, or a pass-through when limit is
+// empty.
+func bodyLimit(limit string) echo.MiddlewareFunc {
+	if limit == "" {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+	return middleware.BodyLimit(limit)
+}
+
+// readOnly returns a middleware rejecting requests with 503, or a
+// pass-through when enabled is false.
+func readOnly(enabled bool) echo.MiddlewareFunc {
+	if !enabled {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+	return func(echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			return c.JSON(http.StatusServiceUnavailable, Problem{
+				Type:   errBase + "/read-only",
+				Title:  "Service Unavailable",
+				Status: http.StatusServiceUnavailable,
+				Detail: "service in read-only mode",
+				Code:   "read_only",
+			})
+		}
+	}
+}
+
+// requestTimeout returns a middleware that cancels the request context after
+// d, or a pass-through when d is zero.
+func requestTimeout(d time.Duration) echo.MiddlewareFunc {
+	if d <= 0 {
+		return func(next echo.HandlerFunc) echo.HandlerFunc { return next }
+	}
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			ctx, cancel := context.WithTimeout(c.Request().Context(), d)
+			defer cancel()
+			c.SetRequest(c.Request().WithContext(ctx))
+			return next(c)
+		}
+	}
+}
+
+// inFlight tracks the requests being served in reg, in total and, when
+// perRoute is set, per method and route. Gauges are released in a defer, so a
+// panicking handler does not leak its slot.
+func inFlight(reg *metrics.Registry, perRoute bool) echo.MiddlewareFunc {
+	total := reg.Gauge("http_requests_in_flight", "Requests currently being served.")
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			total.Inc()
+			defer total.Dec()
+			if perRoute && c.Path() != "" {
+				route := reg.Gauge(
+					fmt.Sprintf("http_route_requests_in_flight{method=%q,route=%q}", c.Request().Method, c.Path()),
+					"Requests currently being served, by route.",
+				)
+				route.Inc()
+				defer route.Dec()
+			}
+			return next(c)
+		}
+	}
+}
+
+// routeAllow fills in the Allow value for preflights on paths with their own
+// OPTIONS handler, which the router only computes for paths without one. The
+// CORS middleware advertises that value, so every preflight lists exactly the
+// methods registered for its route.
+func routeAllow(allow map[string]string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if c.Request().Method == http.MethodOptions {
+				if _, ok := c.Get(echo.ContextKeyHeaderAllow).(string); !ok {
+					c.Set(echo.ContextKeyHeaderAllow, allow[c.Path()])
+				}
+			}
+			return next(c)
+		}
+	}
+}
+
+// allowHeaders builds routeAllow's table from the registered routes, in the
+// router's own "OPTIONS, GET, POST" format.
+func allowHeaders(routes []*echo.Route) map[string]string {
+	byPath := make(map[string][]string)
+	for _, r := range routes {
+		if r.Method == echo.RouteNotFound || r.Method == http.MethodOptions {
+			continue
+		}
+		byPath[r.Path] = append(byPath[r.Path], r.Method)
+	}
+	out := make(map[string]string, len(byPath))
+	for path, methods := range byPath {
+		slices.Sort(methods)
+		out[path] = strings.Join(append([]string{http.MethodOptions}, methods...), ", ")
+	}
+	return out
+}
+
 // New constructs and returns a configured Echo instance.
-func New(h *Handlers) *echo.Echo {
+func New(h *Handlers, opts Options) *echo.Echo {
+	// The house's ID is public, so callers must not be able to move as it.
+	h.placeholderIDs = map[uuid.UUID]struct{}{usecase.HouseClientID: {}}
+	for _, id := range opts.PlaceholderClientIDs {
+		h.placeholderIDs[id] = struct{}{}
+	}
+
+	h.cursors = cursorCodec{secret: opts.CursorSecret}
+	if len(h.cursors.secret) == 0 {
+		h.cursors.secret = make([]byte, 32)
+		if _, err := rand.Read(h.cursors.secret); err != nil {
+			panic(err)
+		}
+	}
+
+	h.retry = retryPolicy{base: opts.RetryAfter, jitter: opts.RetryJitter}
+	if h.retry.base <= 0 {
+		h.retry.base = 2 * time.Second
+	}
+
+	h.strictMoveInput = opts.StrictMoveInput
+	h.finishedMaxAge = opts.FinishedMaxAge
+
 	e := echo.New()
 	e.HideBanner = true
+	allow := make(map[string]string) // filled once every route is registered
+	e.Use(routeAllow(allow))
+	if opts.Metrics != nil {
+		e.Use(inFlight(opts.Metrics, opts.RouteInFlight))
+	}
 	e.Use(middleware.CORSWithConfig(middleware.CORSConfig{
+		// Plain OPTIONS requests (not preflights) reach the route handlers, so
+		// discovery endpoints such as the moves contract can answer them.
+		Skipper: func(c echo.Context) bool {
+			req := c.Request()
+			return req.Method == http.MethodOptions && req.Header.Get(echo.HeaderAccessControlRequestMethod) == ""
+		},
 		AllowOrigins: []string{"https://chess.randomtoy.dev"},
-		AllowMethods: []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders: []string{"Content-Type", "X-Client-Token", "X-Client-Id"},
+		// AllowMethods is left unset so preflights advertise the methods the
+		// router accepts for the requested path.
+		AllowHeaders:  []string{"Content-Type", "X-Client-Token", "X-Client-Id"},
+		ExposeHeaders: []string{"X-Game-Status", "X-Game-Version", "Link"},
 	}))
 	e.Use(middleware.RequestLogger())
 	e.Use(middleware.Recover())
 
-	e.GET("/api/v1/healthz", h.handleHealthz)
-	e.GET("/api/v1/games/assigned", h.handleGetAssigned)
-	e.GET("/api/v1/games/next", h.handleGetNext)
-	e.GET("/api/v1/games/:game_id", h.handleGetGame)
-	e.POST("/api/v1/games/:game_id/moves", h.handleSubmitMove)
+	// Every route hangs off root so the whole API can be mounted under
+	// BasePath.
+	root := e.Group(opts.BasePath)
+	root.GET("/api/v1/healthz", h.handleHealthz)
+	root.GET("/api/v1/version", h.handleVersion)
+	writes := []echo.MiddlewareFunc{readOnly(opts.ReadOnly), requestTimeout(opts.WriteTimeout)}
+	reads := requestTimeout(opts.ReadTimeout)
+	root.GET("/api/v1/games/assigned", h.handleGetAssigned, writes...)
+	root.GET("/api/v1/games/next", h.handleGetNext, writes...)
+	root.GET("/api/v1/games/changed", h.handleListChanged, reads)
+	// The NDJSON export runs as long as the table is large, so it is not
+	// bounded by ReadTimeout.
+	root.GET("/api/v1/games/stream", h.handleStreamGames)
+	root.GET("/api/v1/games/random", h.handleRandomGame, reads)
+	root.GET("/api/v1/games/:game_id", h.handleGetGame, reads)
+	root.HEAD("/api/v1/games/:game_id", h.handleGetGame, reads)
+	root.GET("/api/v1/games/:game_id/theoretical", h.handleTheoretical, reads)
+	root.GET("/api/v1/games/:game_id/contributors", h.handleContributors, reads)
+	root.GET("/api/v1/games/:game_id/summary", h.handleGameSummary, reads)
+	root.GET("/api/v1/games/:game_id/perft", h.handlePerft, reads)
+	root.POST("/api/v1/games/:game_id/moves", h.handleSubmitMove, append(writes, bodyLimit(opts.MoveMaxBody))...)
+	root.OPTIONS("/api/v1/games/:game_id/moves", h.handleMovesOptions)
+	root.GET("/api/v1/games/:game_id/moves.csv", h.handleMovesCSV, reads)
+	root.PUT("/api/v1/games/:game_id/draft", h.handlePutDraft, append(writes, bodyLimit(draftMaxBody))...)
+	root.GET("/api/v1/games/:game_id/draft", h.handleGetDraft, reads)
+	root.GET("/api/v1/clients/:client_id/available-count", h.handleAvailableCount, reads)
+	root.POST("/api/v1/validate-moves", h.handleValidateMoves, bodyLimit(validateMaxBody))
+
+	// Crawlers only look for robots.txt at the host root, so it stays
+	// outside root even when BasePath is set.
+	robots := opts.RobotsTxt
+	if robots == "" {
+		robots = "User-agent: *\nDisallow: " + opts.BasePath + "/api/\n"
+	}
+	e.GET("/robots.txt", func(c echo.Context) error {
+		return c.String(http.StatusOK, robots)
+	})
 
+	if opts.Metrics != nil {
+		root.GET("/metrics", handleMetrics(opts.Metrics))
+	}
+
+	if opts.AdminToken != "" {
+		// The guard goes on each route rather than on the group: group
+		// middleware registers catch-all routes that would hide the admin
+		// routes' methods from CORS preflights.
+		admin := root.Group("/api/v1/admin")
+		guard := []echo.MiddlewareFunc{requireAdminToken(opts.AdminToken), bodyLimit(opts.AdminMaxBody)}
+		if opts.AdminSignedRequests {
+			// The signature covers the body, so the limit applies first.
+			guard = []echo.MiddlewareFunc{bodyLimit(opts.AdminMaxBody), requireAdminSignature(opts.AdminToken, opts.AdminSignatureSkew)}
+		}
+		admin.GET("/blocked-clients", h.handleListBlockedClients, guard...)
+		admin.PUT("/blocked-clients/:client_id", h.handleBlockClient, guard...)
+		admin.DELETE("/blocked-clients/:client_id", h.handleUnblockClient, guard...)
+		admin.GET("/games/:game_id", h.handleAdminGetGame, guard...)
+		admin.PATCH("/games/:game_id", h.handlePatchGameMetadata, guard...)
+		admin.GET("/games/:game_id/events", h.handleGameEvents, guard...)
+		admin.GET("/pool-stats", h.handlePoolStats, guard...)
+		if opts.AdminMetrics != nil {
+			admin.GET("/metrics", handleMetrics(opts.AdminMetrics), guard...)
+		}
+		admin.GET("/clients/:client_id", h.handleClientActivity, guard...)
+	}
+
+	maps.Copy(allow, allowHeaders(e.Routes()))
 	return e
 }
+
+// handleMetrics renders reg in the Prometheus text exposition format.
+func handleMetrics(reg *metrics.Registry) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		c.Response().Header().Set(echo.HeaderContentType, "text/plain; version=0.0.4")
+		c.Response().WriteHeader(http.StatusOK)
+		return reg.WriteText(c.Response())
+	}
+}
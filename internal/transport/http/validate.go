@@ -0,0 +1,69 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+)
+
+// maxValidateBatch caps the positions checked by one validate-moves request,
+// and validateMaxBody the size of its body.
+const (
+	maxValidateBatch = 100
+	validateMaxBody  = "64K"
+)
+
+type validateMoveJSON struct {
+	Legal        bool   `json:"legal"`
+	ResultingFEN string `json:"resulting_fen,omitempty"`
+	Reason       string `json:"reason,omitempty"`
+}
+
+// handleValidateMoves checks a batch of {fen, uci} pairs against their own
+// positions, touching no stored game. Results keep the request order.
+func (h *Handlers) handleValidateMoves(c echo.Context) error {
+	var body []struct {
+		FEN string `json:"fen"`
+		UCI string `json:"uci"`
+	}
+	if err := json.NewDecoder(c.Request().Body).Decode(&body); err != nil {
+		return badValidateBatch(c, "Body must be a JSON array of {fen, uci} objects.")
+	}
+	if len(body) == 0 || len(body) > maxValidateBatch {
+		return badValidateBatch(c, fmt.Sprintf("Send between 1 and %d moves.", maxValidateBatch))
+	}
+
+	out := make([]validateMoveJSON, len(body))
+	for i, item := range body {
+		fen, err := game.ValidateMove(item.FEN, item.UCI)
+		switch {
+		case err == nil:
+			out[i] = validateMoveJSON{Legal: true, ResultingFEN: fen}
+		case errors.Is(err, game.ErrInvalidFEN):
+			out[i] = validateMoveJSON{Reason: "invalid_fen"}
+		case errors.Is(err, game.ErrIllegalPosition):
+			out[i] = validateMoveJSON{Reason: "illegal_position"}
+		case errors.Is(err, game.ErrInvalidUCI), errors.Is(err, game.ErrIllegalMove):
+			out[i] = validateMoveJSON{Reason: err.Error()}
+		default:
+			return h.writeErr(c, err)
+		}
+	}
+	c.Response().Header().Set("Cache-Control", "no-store")
+	return c.JSON(http.StatusOK, map[string]any{"results": out})
+}
+
+func badValidateBatch(c echo.Context, detail string) error {
+	return c.JSON(http.StatusBadRequest, Problem{
+		Type:   errBase + "/invalid-batch",
+		Title:  "Bad Request",
+		Status: http.StatusBadRequest,
+		Detail: detail,
+		Code:   "invalid_batch",
+	})
+}
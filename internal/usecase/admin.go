@@ -0,0 +1,110 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// Metadata limits enforced by PatchGameMetadata.
+const (
+	maxTitleLen = 200
+	maxTags     = 20
+	maxTagLen   = 50
+)
+
+// Page size bounds for ClientActivity.
+const (
+	DefaultActivityLimit = 50
+	MaxActivityLimit     = 200
+)
+
+// ErrInvalidMetadata is returned when a metadata patch exceeds the limits.
+var ErrInvalidMetadata = errors.New("invalid game metadata")
+
+// Admin groups operator-only operations exposed under the admin API.
+type Admin struct {
+	store     ports.GameStore
+	blocklist *Blocklist
+}
+
+func NewAdmin(store ports.GameStore, blocklist *Blocklist) *Admin {
+	return &Admin{store: store, blocklist: blocklist}
+}
+
+// BlockClient bans clientID from claiming games and submitting moves.
+func (a *Admin) BlockClient(ctx context.Context, clientID uuid.UUID) error {
+	return a.blocklist.Block(ctx, clientID)
+}
+
+// UnblockClient lifts a ban placed with BlockClient.
+func (a *Admin) UnblockClient(ctx context.Context, clientID uuid.UUID) error {
+	return a.blocklist.Unblock(ctx, clientID)
+}
+
+// ListBlockedClients returns the store-backed block list.
+func (a *Admin) ListBlockedClients(ctx context.Context) ([]uuid.UUID, error) {
+	return a.store.ListBlockedClients(ctx)
+}
+
+// GetGame returns a game with its full move history, including admin-only
+// fields such as captured User-Agents.
+func (a *Admin) GetGame(ctx context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	return a.store.GetGameWithHistory(ctx, id)
+}
+
+// GameEvents returns the game's lifecycle event log, oldest first.
+func (a *Admin) GameEvents(ctx context.Context, id uuid.UUID) ([]ports.GameEvent, error) {
+	if _, err := a.store.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return a.store.ListGameEvents(ctx, id)
+}
+
+// ClientActivity returns the games clientID claimed after the (since,
+// afterGameID) cursor, with the move it made in each. limit is clamped to
+// [1, MaxActivityLimit]; zero selects DefaultActivityLimit.
+func (a *Admin) ClientActivity(ctx context.Context, clientID uuid.UUID, since time.Time, afterGameID uuid.UUID, limit int) ([]ports.ClientClaim, error) {
+	switch {
+	case limit <= 0:
+		limit = DefaultActivityLimit
+	case limit > MaxActivityLimit:
+		limit = MaxActivityLimit
+	}
+	return a.store.ListClientClaims(ctx, clientID, since, afterGameID, limit)
+}
+
+// PoolStats returns the store's connection pool statistics, or zeros when
+// the store does not pool connections.
+func (a *Admin) PoolStats() ports.PoolStats {
+	if p, ok := a.store.(ports.PoolStatsProvider); ok {
+		return p.Stats()
+	}
+	return ports.PoolStats{}
+}
+
+// PatchGameMetadata updates a game's title and/or tags. The chess state is not
+// touched, so the patch never races with move submission. Returns the updated
+// game with its move history.
+func (a *Admin) PatchGameMetadata(ctx context.Context, id uuid.UUID, patch ports.MetadataPatch) (*game.Game, []game.MoveHistoryItem, error) {
+	if patch.Title != nil && len(*patch.Title) > maxTitleLen {
+		return nil, nil, ErrInvalidMetadata
+	}
+	if len(patch.Tags) > maxTags {
+		return nil, nil, ErrInvalidMetadata
+	}
+	for _, tag := range patch.Tags {
+		if tag == "" || len(tag) > maxTagLen {
+			return nil, nil, ErrInvalidMetadata
+		}
+	}
+	if _, err := a.store.UpdateMetadata(ctx, id, patch); err != nil {
+		return nil, nil, err
+	}
+	return a.store.GetGameWithHistory(ctx, id)
+}
@@ -0,0 +1,62 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// Auditor samples stored games and checks that their columns agree with
+// their FEN. It only reads; mismatches are logged and counted.
+type Auditor struct {
+	store      ports.GameStore
+	sampleSize int
+	mismatches *metrics.Counter
+}
+
+// NewAuditor creates an Auditor checking sampleSize games per pass.
+// mismatches counts inconsistent games and may be nil.
+func NewAuditor(store ports.GameStore, sampleSize int, mismatches *metrics.Counter) *Auditor {
+	return &Auditor{store: store, sampleSize: sampleSize, mismatches: mismatches}
+}
+
+// Check audits one sample and returns how many games were inconsistent.
+func (a *Auditor) Check(ctx context.Context) (int, error) {
+	games, err := a.store.SampleForAudit(ctx, a.sampleSize)
+	if err != nil {
+		return 0, err
+	}
+	bad := 0
+	for _, g := range games {
+		problems := g.Inconsistencies()
+		if len(problems) == 0 {
+			continue
+		}
+		bad++
+		log.Printf("audit: game %s inconsistent: %v", g.ID, problems)
+	}
+	if a.mismatches != nil {
+		a.mismatches.Add(int64(bad))
+	}
+	return bad, nil
+}
+
+// Run audits a sample every interval until ctx is cancelled. Errors are
+// logged and retried on the next tick.
+func (a *Auditor) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := a.Check(ctx); err != nil {
+				log.Printf("audit failed: %v", err)
+			}
+		}
+	}
+}
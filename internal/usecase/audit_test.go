@@ -0,0 +1,26 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+func TestAuditor_CountsInconsistentGames(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(3)
+	seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) { g.PlyCount = 7 })
+
+	counter := metrics.NewRegistry().Counter("audit_inconsistent_games_total", "")
+	bad, err := usecase.NewAuditor(store, 10, counter).Check(ctx)
+	if err != nil {
+		t.Fatalf("Check: %v", err)
+	}
+	if bad != 1 || counter.Value() != 1 {
+		t.Fatalf("want 1 inconsistent game, got %d (counter %d)", bad, counter.Value())
+	}
+}
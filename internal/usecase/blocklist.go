@@ -0,0 +1,111 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// ErrClientBlocked is returned when a blocked client tries to claim or move.
+var ErrClientBlocked = errors.New("client is blocked")
+
+// Blocklist is an in-memory cache of blocked client IDs. It combines a static
+// set (from config) with the store-backed list, which is reloaded periodically
+// by Run so the hot path never queries the store.
+type Blocklist struct {
+	store  ports.GameStore
+	static map[uuid.UUID]struct{}
+
+	mu     sync.RWMutex
+	stored map[uuid.UUID]struct{}
+}
+
+// NewBlocklist creates a Blocklist that always blocks the given static IDs in
+// addition to whatever the store holds.
+func NewBlocklist(store ports.GameStore, static []uuid.UUID) *Blocklist {
+	b := &Blocklist{
+		store:  store,
+		static: make(map[uuid.UUID]struct{}, len(static)),
+		stored: make(map[uuid.UUID]struct{}),
+	}
+	for _, id := range static {
+		b.static[id] = struct{}{}
+	}
+	return b
+}
+
+// IsBlocked reports whether clientID is blocked. A nil Blocklist blocks nobody.
+func (b *Blocklist) IsBlocked(clientID uuid.UUID) bool {
+	if b == nil {
+		return false
+	}
+	if _, ok := b.static[clientID]; ok {
+		return true
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	_, ok := b.stored[clientID]
+	return ok
+}
+
+// Refresh reloads the store-backed block list.
+func (b *Blocklist) Refresh(ctx context.Context) error {
+	ids, err := b.store.ListBlockedClients(ctx)
+	if err != nil {
+		return err
+	}
+	stored := make(map[uuid.UUID]struct{}, len(ids))
+	for _, id := range ids {
+		stored[id] = struct{}{}
+	}
+	b.mu.Lock()
+	b.stored = stored
+	b.mu.Unlock()
+	return nil
+}
+
+// Run refreshes the cache every interval until ctx is cancelled. Refresh
+// errors are logged and the previous list stays in effect.
+func (b *Blocklist) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := b.Refresh(ctx); err != nil {
+				log.Printf("blocklist refresh failed: %v", err)
+			}
+		}
+	}
+}
+
+// Block persists clientID as blocked and updates the cache immediately.
+func (b *Blocklist) Block(ctx context.Context, clientID uuid.UUID) error {
+	if err := b.store.BlockClient(ctx, clientID); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	b.stored[clientID] = struct{}{}
+	b.mu.Unlock()
+	return nil
+}
+
+// Unblock removes clientID from the stored block list and the cache. Static
+// IDs from config stay blocked.
+func (b *Blocklist) Unblock(ctx context.Context, clientID uuid.UUID) error {
+	if err := b.store.UnblockClient(ctx, clientID); err != nil {
+		return err
+	}
+	b.mu.Lock()
+	delete(b.stored, clientID)
+	b.mu.Unlock()
+	return nil
+}
@@ -0,0 +1,152 @@
+package usecase
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// DefaultDraftTTL is how long a move draft is kept when
+// MoveSubmitterOptions.DraftTTL is zero.
+const DefaultDraftTTL = 30 * time.Minute
+
+// DefaultMaxDrafts is how many drafts a replica holds when
+// MoveSubmitterOptions.MaxDrafts is zero. Client IDs are self-asserted, so
+// without a cap drafts would grow with every ID a caller invents.
+const DefaultMaxDrafts = 10_000
+
+// MoveDraft is a move a client has started building but not submitted, kept
+// so the client can restore its input after reconnecting. It is neither
+// validated nor applied.
+type MoveDraft struct {
+	From      string
+	To        string
+	Promotion string
+	SavedAt   time.Time
+}
+
+type draftKey struct {
+	gameID   uuid.UUID
+	clientID uuid.UUID
+}
+
+// moveDrafts holds drafts in process. Drafts are per replica and lost on
+// restart, which is fine for an input convenience. Once limit drafts are held,
+// saving a new one evicts the oldest.
+type moveDrafts struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	limit     int
+	now       func() time.Time
+	drafts    map[draftKey]MoveDraft
+	lastPrune time.Time
+}
+
+func newMoveDrafts(ttl time.Duration, limit int) *moveDrafts {
+	if ttl <= 0 {
+		ttl = DefaultDraftTTL
+	}
+	if limit <= 0 {
+		limit = DefaultMaxDrafts
+	}
+	return &moveDrafts{ttl: ttl, limit: limit, now: time.Now, drafts: make(map[draftKey]MoveDraft)}
+}
+
+func (d *moveDrafts) put(key draftKey, draft MoveDraft) MoveDraft {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	d.prune(now)
+	if _, ok := d.drafts[key]; !ok && len(d.drafts) >= d.limit {
+		d.evictOldest()
+	}
+	draft.SavedAt = now
+	d.drafts[key] = draft
+	return draft
+}
+
+func (d *moveDrafts) get(key draftKey) (MoveDraft, bool) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := d.now()
+	d.prune(now)
+	draft, ok := d.drafts[key]
+	if !ok || now.Sub(draft.SavedAt) >= d.ttl {
+		delete(d.drafts, key)
+		return MoveDraft{}, false
+	}
+	return draft, true
+}
+
+func (d *moveDrafts) clear(key draftKey) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.drafts, key)
+}
+
+// evictOldest drops the draft saved longest ago. Callers hold mu.
+func (d *moveDrafts) evictOldest() {
+	var (
+		oldest draftKey
+		at     time.Time
+		found  bool
+	)
+	for k, draft := range d.drafts {
+		if !found || draft.SavedAt.Before(at) {
+			oldest, at, found = k, draft.SavedAt, true
+		}
+	}
+	if found {
+		delete(d.drafts, oldest)
+	}
+}
+
+// prune drops expired drafts, at most once per TTL. Callers hold mu.
+func (d *moveDrafts) prune(now time.Time) {
+	if now.Sub(d.lastPrune) < d.ttl {
+		return
+	}
+	for k, draft := range d.drafts {
+		if now.Sub(draft.SavedAt) >= d.ttl {
+			delete(d.drafts, k)
+		}
+	}
+	d.lastPrune = now
+}
+
+// SaveDraft stores draft for clientID in gameID, replacing any earlier one.
+// The game must exist; the draft itself is not checked against it.
+func (m *MoveSubmitter) SaveDraft(
+	ctx context.Context,
+	ip, token string,
+	gameID, clientID uuid.UUID,
+	draft MoveDraft,
+) (MoveDraft, error) {
+	if !m.rl.Allow(ip, token) {
+		return MoveDraft{}, ErrRateLimited
+	}
+	if m.opts.Blocklist.IsBlocked(clientID) {
+		return MoveDraft{}, ErrClientBlocked
+	}
+	if _, err := m.store.GetByID(ctx, gameID); err != nil {
+		return MoveDraft{}, err
+	}
+	return m.drafts.put(draftKey{gameID, clientID}, draft), nil
+}
+
+// Draft returns the draft clientID saved in gameID, or ports.ErrNotFound when
+// there is none, it expired, or the client has since moved there.
+func (m *MoveSubmitter) Draft(ctx context.Context, ip, token string, gameID, clientID uuid.UUID) (MoveDraft, error) {
+	if !m.rl.Allow(ip, token) {
+		return MoveDraft{}, ErrRateLimited
+	}
+	draft, ok := m.drafts.get(draftKey{gameID, clientID})
+	if !ok {
+		return MoveDraft{}, ports.ErrNotFound
+	}
+	return draft, nil
+}
@@ -2,6 +2,9 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"log"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -9,19 +12,272 @@ import (
 	"github.com/randomtoy/random-chess-backend/internal/ports"
 )
 
+// Page size bounds for ListChanged.
+const (
+	DefaultChangedLimit = 100
+	MaxChangedLimit     = 500
+)
+
+// StreamPageSize is how many games StreamGames, or moves StreamMoves, reads
+// per store query.
+const StreamPageSize = 100
+
+// ErrHistoryDisabled is returned by reads that need move history when the
+// server does not persist it (GameGetterOptions.HistoryDisabled).
+var ErrHistoryDisabled = errors.New("move history is not persisted")
+
+// ErrHistoryUnavailable is returned by reads that need the full move history
+// when DegradeHistory hid a failure to load it.
+var ErrHistoryUnavailable = errors.New("move history unavailable")
+
+// GameGetterOptions holds optional game-retrieval behavior.
+type GameGetterOptions struct {
+	// HistoryLimit caps the move history GetGame returns by default to the
+	// most recent HistoryLimit moves. Zero returns all moves.
+	HistoryLimit int
+
+	// DegradeHistory makes GetGame return the game with an empty history
+	// and HistoryUnavailable set, instead of failing, when only the history
+	// query errors.
+	DegradeHistory bool
+
+	// HistoryDisabled reports that the store does not persist moves, so
+	// GetGame skips the history query and sets GameDetail.HistoryDisabled.
+	HistoryDisabled bool
+}
+
 // GameGetter handles single-game retrieval.
 type GameGetter struct {
 	store ports.GameStore
 	rl    ports.RateLimiter
+	opts  GameGetterOptions
+}
+
+func NewGameGetter(store ports.GameStore, rl ports.RateLimiter, opts GameGetterOptions) *GameGetter {
+	return &GameGetter{store: store, rl: rl, opts: opts}
+}
+
+// GameDetail is the value returned by GameGetter.GetGame.
+type GameDetail struct {
+	Game    *game.Game
+	History []game.MoveHistoryItem
+	// HistoryUnavailable is set when DegradeHistory hid a history failure;
+	// History is then empty.
+	HistoryUnavailable bool
+	// HistoryDisabled is set when move history is not persisted; History is
+	// then empty.
+	HistoryDisabled bool
+}
+
+// HistoryErr returns ErrHistoryDisabled or ErrHistoryUnavailable when History
+// is empty for one of those reasons, for callers that need every move.
+func (d GameDetail) HistoryErr() error {
+	switch {
+	case d.HistoryDisabled:
+		return ErrHistoryDisabled
+	case d.HistoryUnavailable:
+		return ErrHistoryUnavailable
+	}
+	return nil
+}
+
+// GetGame returns the game and its move history. Unless fullHistory is set,
+// games longer than HistoryLimit only get their most recent moves; callers
+// compare len(History) with Game.PlyCount to detect truncation.
+func (g *GameGetter) GetGame(ctx context.Context, ip, token string, id uuid.UUID, fullHistory bool) (GameDetail, error) {
+	if !g.rl.Allow(ip, token) {
+		return GameDetail{}, ErrRateLimited
+	}
+	if g.opts.HistoryDisabled {
+		gm, err := g.store.GetByID(ctx, id)
+		if err != nil {
+			return GameDetail{}, err
+		}
+		return GameDetail{Game: gm, History: []game.MoveHistoryItem{}, HistoryDisabled: true}, nil
+	}
+	windowed := !fullHistory && g.opts.HistoryLimit > 0
+	if !windowed && !g.opts.DegradeHistory {
+		gm, hist, err := g.store.GetGameWithHistory(ctx, id)
+		if err != nil {
+			return GameDetail{}, err
+		}
+		return GameDetail{Game: gm, History: hist}, nil
+	}
+
+	gm, err := g.store.GetByID(ctx, id)
+	if err != nil {
+		return GameDetail{}, err
+	}
+	fromPly := 0
+	if windowed {
+		fromPly = max(0, gm.PlyCount-g.opts.HistoryLimit)
+	}
+	hist, err := g.store.GetMovesSince(ctx, id, fromPly)
+	if err != nil {
+		if !g.opts.DegradeHistory || ctx.Err() != nil {
+			return GameDetail{}, err
+		}
+		log.Printf("history for game %s unavailable: %v", id, err)
+		return GameDetail{Game: gm, History: []game.MoveHistoryItem{}, HistoryUnavailable: true}, nil
+	}
+	return GameDetail{Game: gm, History: hist}, nil
+}
+
+// Contributors returns the clients that moved in the game, in order of
+// their first move. Returns ports.ErrNotFound for unknown games and
+// ErrHistoryDisabled when moves are not persisted.
+func (g *GameGetter) Contributors(ctx context.Context, ip, token string, id uuid.UUID) ([]ports.Contributor, error) {
+	if !g.rl.Allow(ip, token) {
+		return nil, ErrRateLimited
+	}
+	if g.opts.HistoryDisabled {
+		return nil, ErrHistoryDisabled
+	}
+	if _, err := g.store.GetByID(ctx, id); err != nil {
+		return nil, err
+	}
+	return g.store.ListContributors(ctx, id)
+}
+
+// TheoreticalResult evaluates the game's current position with
+// game.TheoreticalResult.
+func (g *GameGetter) TheoreticalResult(ctx context.Context, ip, token string, id uuid.UUID) (*game.Game, string, bool, error) {
+	if !g.rl.Allow(ip, token) {
+		return nil, "", false, ErrRateLimited
+	}
+	gm, err := g.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, "", false, err
+	}
+	result, known := game.TheoreticalResult(gm.FEN)
+	return gm, result, known, nil
 }
 
-func NewGameGetter(store ports.GameStore, rl ports.RateLimiter) *GameGetter {
-	return &GameGetter{store: store, rl: rl}
+// MaxPerftDepth bounds the CPU a single Perft request can use.
+const MaxPerftDepth = 4
+
+// Perft counts the leaf nodes depth plies below the game's current position.
+// Depths outside [0, MaxPerftDepth] fail with game.ErrInvalidDepth.
+func (g *GameGetter) Perft(ctx context.Context, ip, token string, id uuid.UUID, depth int) (*game.Game, uint64, error) {
+	if !g.rl.Allow(ip, token) {
+		return nil, 0, ErrRateLimited
+	}
+	if depth < 0 || depth > MaxPerftDepth {
+		return nil, 0, game.ErrInvalidDepth
+	}
+	gm, err := g.store.GetByID(ctx, id)
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes, err := game.Perft(gm.FEN, depth)
+	if err != nil {
+		return nil, 0, err
+	}
+	return gm, nodes, nil
 }
 
-func (g *GameGetter) GetGame(ctx context.Context, ip, token string, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+// ListChanged returns games updated after the (since, afterID) cursor for
+// incremental sync. limit is clamped to [1, MaxChangedLimit]; zero selects
+// DefaultChangedLimit.
+func (g *GameGetter) ListChanged(ctx context.Context, ip, token string, since time.Time, afterID uuid.UUID, limit int) ([]*game.Game, error) {
 	if !g.rl.Allow(ip, token) {
-		return nil, nil, ErrRateLimited
+		return nil, ErrRateLimited
+	}
+	switch {
+	case limit <= 0:
+		limit = DefaultChangedLimit
+	case limit > MaxChangedLimit:
+		limit = MaxChangedLimit
+	}
+	return g.store.ListChangedSince(ctx, since, afterID, limit)
+}
+
+// RandomOngoing returns one ongoing game at random for spectators, without
+// claiming it. Returns ports.ErrNotFound when no game is ongoing.
+func (g *GameGetter) RandomOngoing(ctx context.Context, ip, token string) (*game.Game, error) {
+	if !g.rl.Allow(ip, token) {
+		return nil, ErrRateLimited
+	}
+	return g.store.RandomOngoing(ctx)
+}
+
+// StreamGames calls emit for every game matching filter, in ID order. It
+// pages through the store so the full result is never held in memory, and
+// stops at the first emit error or once ctx is done.
+func (g *GameGetter) StreamGames(ctx context.Context, ip, token string, filter ports.GameFilter, emit func(*game.Game) error) error {
+	if !g.rl.Allow(ip, token) {
+		return ErrRateLimited
+	}
+	afterID := uuid.Nil
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		games, err := g.store.ListGames(ctx, filter, afterID, StreamPageSize)
+		if err != nil {
+			return err
+		}
+		for _, gm := range games {
+			if err := emit(gm); err != nil {
+				return err
+			}
+		}
+		if len(games) < StreamPageSize {
+			return nil
+		}
+		afterID = games[len(games)-1].ID
+	}
+}
+
+// StreamMoves loads game id and its first page of moves, passes the game to
+// start, then calls emit with every page of moves in ply order, so the full
+// history is never held in memory. It fails with ErrHistoryDisabled when
+// moves are not persisted, and with ErrHistoryUnavailable when DegradeHistory
+// is set and the first page cannot be read. Once start has run, errors can
+// only cut the stream short.
+func (g *GameGetter) StreamMoves(
+	ctx context.Context,
+	ip, token string,
+	id uuid.UUID,
+	start func(*game.Game) error,
+	emit func([]game.MoveHistoryItem) error,
+) error {
+	if !g.rl.Allow(ip, token) {
+		return ErrRateLimited
+	}
+	if g.opts.HistoryDisabled {
+		return ErrHistoryDisabled
+	}
+	gm, err := g.store.GetByID(ctx, id)
+	if err != nil {
+		return err
+	}
+	moves, err := g.store.GetMovesPage(ctx, id, 0, StreamPageSize)
+	if err != nil {
+		if !g.opts.DegradeHistory || ctx.Err() != nil {
+			return err
+		}
+		log.Printf("history for game %s unavailable: %v", id, err)
+		return ErrHistoryUnavailable
+	}
+	if err := start(gm); err != nil {
+		return err
+	}
+	for {
+		if len(moves) > 0 {
+			if err := emit(moves); err != nil {
+				return err
+			}
+		}
+		if len(moves) < StreamPageSize {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		next := moves[len(moves)-1].Ply + 1
+		if moves, err = g.store.GetMovesPage(ctx, id, next, StreamPageSize); err != nil {
+			return err
+		}
 	}
-	return g.store.GetGameWithHistory(ctx, id)
 }
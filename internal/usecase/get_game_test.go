@@ -0,0 +1,84 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+// pagedHistoryStore serves plies synthetic moves for every game and counts
+// the page reads.
+type pagedHistoryStore struct {
+	*memory.Store
+	plies int
+	pages int
+	fail  bool
+}
+
+func (s *pagedHistoryStore) GetMovesPage(_ context.Context, _ uuid.UUID, fromPly, limit int) ([]game.MoveHistoryItem, error) {
+	s.pages++
+	if s.fail {
+		return nil, errors.New("boom")
+	}
+	out := []game.MoveHistoryItem{}
+	for ply := fromPly; ply < s.plies && len(out) < limit; ply++ {
+		out = append(out, game.MoveHistoryItem{Ply: ply})
+	}
+	return out, nil
+}
+
+func TestStreamMoves_Pages(t *testing.T) {
+	ctx := context.Background()
+	store := &pagedHistoryStore{Store: memory.New(1), plies: usecase.StreamPageSize + 5}
+	g, _, err := store.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	getter := usecase.NewGameGetter(store, memory.AlwaysAllow{}, usecase.GameGetterOptions{})
+
+	var started bool
+	var batches, plies int
+	err = getter.StreamMoves(ctx, "", "", g.ID, func(*game.Game) error {
+		started = true
+		return nil
+	}, func(moves []game.MoveHistoryItem) error {
+		for _, item := range moves {
+			if item.Ply != plies {
+				t.Fatalf("got ply %d, want %d", item.Ply, plies)
+			}
+			plies++
+		}
+		batches++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamMoves: %v", err)
+	}
+	if !started || batches != 2 || plies != store.plies || store.pages != 2 {
+		t.Fatalf("started=%v batches=%d plies=%d pages=%d", started, batches, plies, store.pages)
+	}
+}
+
+func TestStreamMoves_HistoryUnavailable(t *testing.T) {
+	ctx := context.Background()
+	store := &pagedHistoryStore{Store: memory.New(1), fail: true}
+	g, _, err := store.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	getter := usecase.NewGameGetter(store, memory.AlwaysAllow{}, usecase.GameGetterOptions{DegradeHistory: true})
+
+	err = getter.StreamMoves(ctx, "", "", g.ID, func(*game.Game) error {
+		t.Fatal("start called despite the failed read")
+		return nil
+	}, func([]game.MoveHistoryItem) error { return nil })
+	if !errors.Is(err, usecase.ErrHistoryUnavailable) {
+		t.Fatalf("got %v, want ErrHistoryUnavailable", err)
+	}
+}
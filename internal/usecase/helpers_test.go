@@ -0,0 +1,40 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// sampleGames returns the first n of store's games in ID order.
+func sampleGames(t *testing.T, store *memory.Store, n int) []*game.Game {
+	t.Helper()
+	games, err := store.ListGames(context.Background(), ports.GameFilter{}, uuid.Nil, n)
+	if err != nil || len(games) != n {
+		t.Fatalf("list %d games: got %d, %v", n, len(games), err)
+	}
+	return games
+}
+
+// seedGame loads game id from store, applies mutate to a copy and saves it as
+// the next state version, returning the saved game.
+func seedGame(t *testing.T, store *memory.Store, id uuid.UUID, mutate func(*game.Game)) *game.Game {
+	t.Helper()
+	ctx := context.Background()
+	g, err := store.GetByID(ctx, id)
+	if err != nil {
+		t.Fatalf("get %s: %v", id, err)
+	}
+	seeded := *g
+	mutate(&seeded)
+	seeded.StateVersion++
+	if err := store.SaveIfVersion(ctx, &seeded, g.StateVersion); err != nil {
+		t.Fatalf("save %s: %v", id, err)
+	}
+	return &seeded
+}
@@ -0,0 +1,106 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// HouseClientID attributes moves made by the server itself. It is a fixed,
+// recognisable ID so house moves stand out in history.
+var HouseClientID = uuid.MustParse("00000000-0000-4000-8000-000000000001")
+
+// HouseMover keeps stalled games going by playing a random legal move on any
+// ongoing game nobody has moved in for longer than the stall threshold.
+type HouseMover struct {
+	store    ports.GameStore
+	stall    time.Duration
+	notifier ports.GameCompletionNotifier
+	now      func() time.Time
+}
+
+// NewHouseMover creates a HouseMover for games idle longer than stall.
+// notifier, when non-nil, is told about games a house move finishes.
+func NewHouseMover(store ports.GameStore, stall time.Duration, notifier ports.GameCompletionNotifier) *HouseMover {
+	return &HouseMover{store: store, stall: stall, notifier: notifier, now: time.Now}
+}
+
+// Move plays one house move on every stalled game and returns how many moves
+// were made. Games a player moved in meanwhile are skipped, as are games the
+// move cannot be applied to; the latter are logged.
+func (h *HouseMover) Move(ctx context.Context) (int, error) {
+	games, err := h.store.ListOngoing(ctx)
+	if err != nil {
+		return 0, err
+	}
+	now := h.now()
+	moved := 0
+	for _, g := range games {
+		if g.LastMoveAt == nil || now.Sub(*g.LastMoveAt) < h.stall {
+			continue
+		}
+		legal, err := g.LegalMoves()
+		if err != nil || len(legal) == 0 {
+			continue
+		}
+		next, rec, err := g.ApplyMove(legal[rand.IntN(len(legal))], now)
+		if err != nil {
+			log.Printf("house move on game %s: %v", g.ID, err)
+			continue
+		}
+		err = h.store.PersistHouseMove(ctx, g.ID, HouseClientID, next, rec, next.PlyCount-1)
+		if errors.Is(err, ports.ErrVersionConflict) {
+			continue
+		}
+		if err != nil {
+			return moved, err
+		}
+		moved++
+		if next.Status != game.StatusOngoing {
+			h.notifyFinished(ctx, next)
+		}
+	}
+	return moved, nil
+}
+
+// notifyFinished hands g, just finished by a house move, to the notifier
+// along with its history.
+func (h *HouseMover) notifyFinished(ctx context.Context, g *game.Game) {
+	if h.notifier == nil {
+		return
+	}
+	_, history, err := h.store.GetGameWithHistory(ctx, g.ID)
+	if err != nil {
+		log.Printf("house move on game %s: load history for notification: %v", g.ID, err)
+		return
+	}
+	notifyIfFinished(h.notifier, g, history)
+}
+
+// Run makes house moves every interval until ctx is cancelled. Errors are
+// logged and retried on the next tick.
+func (h *HouseMover) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := h.Move(ctx)
+			if err != nil {
+				log.Printf("house moves failed: %v", err)
+			}
+			if n > 0 {
+				log.Printf("made %d house moves", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+func TestHouseMover_MovesOnlyStalledGames(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(2)
+	games := sampleGames(t, store, 2)
+	start := func(movedAt time.Time, idx int) {
+		g := games[idx]
+		next, _, err := g.ApplyMove("e2e4", movedAt)
+		if err != nil {
+			t.Fatalf("apply: %v", err)
+		}
+		if err := store.SaveIfVersion(ctx, next, g.StateVersion); err != nil {
+			t.Fatalf("save: %v", err)
+		}
+	}
+	start(time.Now().Add(-time.Hour), 0)
+	start(time.Now(), 1)
+
+	n, err := usecase.NewHouseMover(store, 10*time.Minute, nil).Move(ctx)
+	if err != nil {
+		t.Fatalf("Move: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("want 1 house move, got %d", n)
+	}
+	stalled, hist, err := store.GetGameWithHistory(ctx, games[0].ID)
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if stalled.PlyCount != 2 || len(hist) != 1 || hist[0].ClientID != usecase.HouseClientID || hist[0].Ply != 1 {
+		t.Fatalf("stalled game: ply %d, history %+v", stalled.PlyCount, hist)
+	}
+	if active, _ := store.GetByID(ctx, games[1].ID); active.PlyCount != 1 {
+		t.Fatalf("active game moved: ply %d", active.PlyCount)
+	}
+}
+
+// TestHouseMover_NotifiesOnCompletion: a house move that ends the game fires
+// the completion notification like a player's move.
+func TestHouseMover_NotifiesOnCompletion(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	// White's only legal move, Kxh2, leaves bare kings: a draw.
+	stalled := seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(g *game.Game) {
+		g.FEN = "8/8/8/8/8/8/5k1p/7K w - - 0 1"
+		g.SideToMove = "white"
+		g.Status = game.StatusOngoing
+		g.PlyCount = 1
+		movedAt := time.Now().Add(-time.Hour)
+		g.LastMoveAt = &movedAt
+	})
+
+	notifier := &recordingNotifier{}
+	n, err := usecase.NewHouseMover(store, 10*time.Minute, notifier).Move(ctx)
+	if err != nil || n != 1 {
+		t.Fatalf("Move = %d, %v; want 1 house move", n, err)
+	}
+	if len(notifier.games) != 1 || notifier.games[0].ID != stalled.ID || notifier.games[0].Status != game.StatusDraw {
+		t.Fatalf("want one draw notification for %s, got %+v", stalled.ID, notifier.games)
+	}
+}
@@ -1,8 +1,15 @@
 package usecase
 
 import (
+	"cmp"
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"net/netip"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
@@ -14,6 +21,114 @@ import (
 type NextGameResult struct {
 	Game    *game.Game
 	History []game.MoveHistoryItem
+	// LastMove is the game's most recent move, or nil for a fresh game.
+	LastMove *LastMove
+}
+
+// LastMove summarises the move a claiming client should see highlighted.
+type LastMove struct {
+	UCI      string
+	From     string
+	To       string
+	SAN      string // empty when the move is not in the history
+	PlayedAt *time.Time
+}
+
+// newNextGameResult takes the last move from hist, falling back to the game
+// row's LastMoveUCI when history is not persisted or is windowed away.
+func newNextGameResult(g *game.Game, hist []game.MoveHistoryItem) NextGameResult {
+	res := NextGameResult{Game: g, History: hist}
+	if len(hist) > 0 {
+		last := slices.MaxFunc(hist, func(a, b game.MoveHistoryItem) int { return a.Ply - b.Ply })
+		if last.Ply == g.PlyCount-1 {
+			san, _ := game.SAN(last.FENBefore, last.UCI)
+			res.LastMove = &LastMove{UCI: last.UCI, From: last.FromSq, To: last.ToSq, SAN: san, PlayedAt: &last.CreatedAt}
+			return res
+		}
+	}
+	if g.LastMoveUCI != nil && len(*g.LastMoveUCI) >= 4 {
+		uci := *g.LastMoveUCI
+		res.LastMove = &LastMove{UCI: uci, From: uci[:2], To: uci[2:4], PlayedAt: g.LastMoveAt}
+	}
+	return res
+}
+
+// ErrActiveClaim is returned by GetNext in single-active-claim mode when the
+// client still holds an unmoved claim. Use errors.As with *ActiveClaimError to
+// recover the game the client should resume.
+var ErrActiveClaim = errors.New("client already holds an active claim")
+
+// ErrIPClaimLimit is returned by GetNext when the caller's IP has claimed
+// MaxClaimsPerIP games within the counter's window.
+var ErrIPClaimLimit = errors.New("too many games claimed from this IP")
+
+// ErrClaimsSaturated is returned by GetNext under ClaimOverflowReject when
+// MaxConcurrentClaims claims are already running.
+var ErrClaimsSaturated = errors.New("too many concurrent claims")
+
+// ErrClaimQueueTimeout is returned by GetNext under ClaimOverflowQueue when
+// no claim slot frees up within ClaimQueueTimeout.
+var ErrClaimQueueTimeout = errors.New("timed out waiting for a claim slot")
+
+// ClaimOverflowPolicy decides what GetNext does when MaxConcurrentClaims
+// claims are already running.
+type ClaimOverflowPolicy string
+
+const (
+	// ClaimOverflowQueue waits up to ClaimQueueTimeout for a slot.
+	ClaimOverflowQueue ClaimOverflowPolicy = "queue"
+	// ClaimOverflowReject fails at once with ErrClaimsSaturated.
+	ClaimOverflowReject ClaimOverflowPolicy = "reject"
+)
+
+// defaultClaimQueueTimeout is the ClaimOverflowQueue wait when
+// ClaimQueueTimeout is zero.
+const defaultClaimQueueTimeout = time.Second
+
+// ActiveClaimError carries the game the client must finish before claiming
+// another one.
+type ActiveClaimError struct {
+	GameID uuid.UUID
+}
+
+func (e *ActiveClaimError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrActiveClaim, e.GameID)
+}
+
+func (e *ActiveClaimError) Unwrap() error { return ErrActiveClaim }
+
+// NextGameOptions holds optional matchmaking behavior.
+type NextGameOptions struct {
+	// SingleActiveClaim refuses a new claim while the client has an unmoved
+	// claim on a waiting/ongoing game.
+	SingleActiveClaim bool
+
+	// Blocklist rejects claims from banned clients. Nil disables the check.
+	Blocklist *Blocklist
+
+	// MaxClaimsPerIP caps how many games one IP may claim per ClaimCounter
+	// window, whatever client IDs it presents. Zero or a nil ClaimCounter
+	// disables the cap.
+	MaxClaimsPerIP int
+	ClaimCounter   ports.ClaimCounter
+
+	// IPv4PrefixLen and IPv6PrefixLen key MaxClaimsPerIP on the caller's
+	// network rather than its address, so cycling through the addresses of
+	// one allocation does not reset the cap. Zero means 32 (the full IPv4
+	// address) and 64 (the usual IPv6 subnet).
+	IPv4PrefixLen int
+	IPv6PrefixLen int
+
+	// PoolMetrics, when set, counts inline batches and empty-pool claims.
+	PoolMetrics *PoolMetrics
+
+	// MaxConcurrentClaims bounds how many claims run against the store at
+	// once; zero means no bound. ClaimOverflow picks what happens to the
+	// claims beyond it, queueing by default, and ClaimQueueTimeout bounds
+	// the queueing, zero meaning one second.
+	MaxConcurrentClaims int
+	ClaimOverflow       ClaimOverflowPolicy
+	ClaimQueueTimeout   time.Duration
 }
 
 // NextGame handles matchmaking: find (or create) a game for an anonymous client.
@@ -21,36 +136,175 @@ type NextGame struct {
 	store     ports.GameStore
 	rl        ports.RateLimiter
 	batchSize int
+	opts      NextGameOptions
+	// slots holds one token per running claim; nil without
+	// MaxConcurrentClaims.
+	slots chan struct{}
 }
 
-func NewNextGame(store ports.GameStore, rl ports.RateLimiter, batchSize int) *NextGame {
-	return &NextGame{store: store, rl: rl, batchSize: batchSize}
+func NewNextGame(store ports.GameStore, rl ports.RateLimiter, batchSize int, opts NextGameOptions) *NextGame {
+	n := &NextGame{store: store, rl: rl, batchSize: batchSize, opts: opts}
+	if opts.MaxConcurrentClaims > 0 {
+		n.slots = make(chan struct{}, opts.MaxConcurrentClaims)
+	}
+	return n
 }
 
 // GetNext returns a game that clientID has not played before.
 // If no suitable game exists, a batch of waiting games is created and the
-// search is retried once. Returns ErrNoGamesAvailable if still nothing found.
+// search is retried once. Returns ErrNoGamesAvailable if still nothing found,
+// or an *ActiveClaimError in single-active-claim mode.
 func (n *NextGame) GetNext(ctx context.Context, ip, token string, clientID uuid.UUID) (NextGameResult, error) {
 	if !n.rl.Allow(ip, token) {
 		return NextGameResult{}, ErrRateLimited
 	}
+	if n.opts.Blocklist.IsBlocked(clientID) {
+		return NextGameResult{}, ErrClientBlocked
+	}
+
+	if !n.ipCapped() {
+		return n.getNext(ctx, clientID)
+	}
+	// The IP's claim is reserved up front and given back if nothing is
+	// claimed, so concurrent requests cannot overshoot the cap.
+	ipKey := hashIP(n.normalizeIPKey(ip))
+	if !n.opts.ClaimCounter.Reserve(ipKey, n.opts.MaxClaimsPerIP) {
+		return NextGameResult{}, ErrIPClaimLimit
+	}
+	res, err := n.getNext(ctx, clientID)
+	if err != nil {
+		n.opts.ClaimCounter.Release(ipKey)
+	}
+	return res, err
+}
+
+func (n *NextGame) getNext(ctx context.Context, clientID uuid.UUID) (NextGameResult, error) {
+	release, err := n.acquireSlot(ctx)
+	if err != nil {
+		return NextGameResult{}, err
+	}
+	defer release()
 
-	g, hist, err := n.store.ClaimNextGame(ctx, clientID)
+	g, hist, err := n.claim(ctx, clientID)
 	if err == nil {
-		return NextGameResult{Game: g, History: hist}, nil
+		return newNextGameResult(g, hist), nil
 	}
 	if !errors.Is(err, ports.ErrNoGamesAvailable) {
 		return NextGameResult{}, err
 	}
 
 	// No suitable game found — create a batch and retry once.
-	if createErr := n.store.CreateWaitingBatch(ctx, n.batchSize); createErr != nil {
+	n.opts.PoolMetrics.poolEmpty(false)
+	created, createErr := n.store.CreateWaitingBatch(ctx, n.batchSize)
+	if createErr != nil {
 		return NextGameResult{}, createErr
 	}
+	n.opts.PoolMetrics.batchCreated(created)
+	if created == 0 {
+		n.opts.PoolMetrics.poolEmpty(true)
+		return NextGameResult{}, ports.ErrNoGamesAvailable
+	}
 
-	g, hist, err = n.store.ClaimNextGame(ctx, clientID)
+	g, hist, err = n.claim(ctx, clientID)
 	if err != nil {
+		if errors.Is(err, ports.ErrNoGamesAvailable) {
+			n.opts.PoolMetrics.poolEmpty(true)
+		}
 		return NextGameResult{}, err
 	}
-	return NextGameResult{Game: g, History: hist}, nil
+	return newNextGameResult(g, hist), nil
+}
+
+// claim claims the next game for clientID. In single-active-claim mode the
+// active-claim check and the claim share a transaction holding the client's
+// lock, so concurrent requests from one client cannot both pass the check.
+func (n *NextGame) claim(ctx context.Context, clientID uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	if !n.opts.SingleActiveClaim {
+		return n.store.ClaimNextGame(ctx, clientID)
+	}
+	var (
+		g    *game.Game
+		hist []game.MoveHistoryItem
+	)
+	err := n.store.InTx(ctx, func(tx ports.GameStore) error {
+		if err := tx.LockClient(ctx, clientID); err != nil {
+			return err
+		}
+		gameID, found, err := tx.FindActiveClaim(ctx, clientID)
+		if err != nil {
+			return err
+		}
+		if found {
+			return &ActiveClaimError{GameID: gameID}
+		}
+		g, hist, err = tx.ClaimNextGame(ctx, clientID)
+		return err
+	})
+	return g, hist, err
+}
+
+// acquireSlot takes a claim slot, applying the overflow policy when none is
+// free, and returns the function that gives it back.
+func (n *NextGame) acquireSlot(ctx context.Context) (func(), error) {
+	if n.slots == nil {
+		return func() {}, nil
+	}
+	release := func() { <-n.slots }
+	select {
+	case n.slots <- struct{}{}:
+		return release, nil
+	default:
+	}
+	if n.opts.ClaimOverflow == ClaimOverflowReject {
+		return nil, ErrClaimsSaturated
+	}
+
+	timer := time.NewTimer(cmp.Or(n.opts.ClaimQueueTimeout, defaultClaimQueueTimeout))
+	defer timer.Stop()
+	select {
+	case n.slots <- struct{}{}:
+		return release, nil
+	case <-timer.C:
+		return nil, ErrClaimQueueTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (n *NextGame) ipCapped() bool {
+	return n.opts.MaxClaimsPerIP > 0 && n.opts.ClaimCounter != nil
+}
+
+// normalizeIPKey masks ip to the configured IPv4 or IPv6 prefix. IPv4-mapped
+// IPv6 addresses count as IPv4; anything unparsable is keyed as is.
+func (n *NextGame) normalizeIPKey(ip string) string {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ip
+	}
+	addr = addr.Unmap()
+	bits := cmp.Or(n.opts.IPv4PrefixLen, 32)
+	if addr.Is6() {
+		bits = cmp.Or(n.opts.IPv6PrefixLen, 64)
+	}
+	prefix, err := addr.Prefix(bits)
+	if err != nil {
+		return addr.String()
+	}
+	return prefix.String()
+}
+
+// hashIP keys per-IP counters so raw addresses are not held in memory.
+func hashIP(ip string) string {
+	sum := sha256.Sum256([]byte(ip))
+	return hex.EncodeToString(sum[:16])
+}
+
+// CountAvailable returns how many games clientID could still claim, without
+// claiming or creating any.
+func (n *NextGame) CountAvailable(ctx context.Context, ip, token string, clientID uuid.UUID) (int, error) {
+	if !n.rl.Allow(ip, token) {
+		return 0, ErrRateLimited
+	}
+	return n.store.CountAvailableForClient(ctx, clientID)
 }
@@ -0,0 +1,56 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+// TestGetNext_MaxClaimsPerIP_Concurrent: simultaneous claims from one IP
+// cannot overshoot the cap.
+func TestGetNext_MaxClaimsPerIP_Concurrent(t *testing.T) {
+	n := usecase.NewNextGame(memory.New(10), memory.AlwaysAllow{}, 10, usecase.NextGameOptions{
+		MaxClaimsPerIP: 2,
+		ClaimCounter:   memory.NewClaimCounter(time.Hour),
+	})
+	var (
+		wg      sync.WaitGroup
+		claimed atomic.Int32
+	)
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := n.GetNext(context.Background(), "192.0.2.1", "", uuid.New()); err == nil {
+				claimed.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+	if got := claimed.Load(); got != 2 {
+		t.Fatalf("%d claims succeeded, want 2", got)
+	}
+}
+
+// TestGetNext_MaxClaimsPerIP_ReleasesFailed: a claim that finds no game does
+// not count against the IP.
+func TestGetNext_MaxClaimsPerIP_ReleasesFailed(t *testing.T) {
+	ctx := context.Background()
+	n := usecase.NewNextGame(memory.New(0), memory.AlwaysAllow{}, 0, usecase.NextGameOptions{
+		MaxClaimsPerIP: 1,
+		ClaimCounter:   memory.NewClaimCounter(time.Hour),
+	})
+	for range 2 {
+		if _, err := n.GetNext(ctx, "192.0.2.1", "", uuid.New()); errors.Is(err, usecase.ErrIPClaimLimit) {
+			t.Fatal("failed claim was counted against the IP")
+		}
+	}
+}
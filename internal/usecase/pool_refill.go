@@ -0,0 +1,124 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// PoolMetrics instruments waiting-pool sizing: how often and how large
+// batches are created, and how often a claim finds the pool empty. A nil
+// *PoolMetrics records nothing.
+type PoolMetrics struct {
+	batches     *metrics.Counter
+	batchSize   *metrics.Histogram
+	emptyBefore *metrics.Counter
+	emptyAfter  *metrics.Counter
+}
+
+// NewPoolMetrics registers the pool metrics in reg.
+func NewPoolMetrics(reg *metrics.Registry) *PoolMetrics {
+	const emptyHelp = "Claims that found no game, before and after creating a batch."
+	return &PoolMetrics{
+		batches:     reg.Counter("game_batches_created_total", "CreateWaitingBatch calls, inline and by the refill worker."),
+		batchSize:   reg.Histogram("game_batch_size", "Games created per batch.", []int64{1, 5, 10, 25, 50, 100, 250, 500}),
+		emptyBefore: reg.Counter(`game_pool_empty_total{stage="before_batch"}`, emptyHelp),
+		emptyAfter:  reg.Counter(`game_pool_empty_total{stage="after_batch"}`, emptyHelp),
+	}
+}
+
+func (m *PoolMetrics) batchCreated(created int) {
+	if m == nil {
+		return
+	}
+	m.batches.Inc()
+	m.batchSize.Observe(int64(created))
+}
+
+// poolEmpty counts a claim that found no game; afterBatch is set once an
+// inline batch has been created and still left nothing to claim.
+func (m *PoolMetrics) poolEmpty(afterBatch bool) {
+	if m == nil {
+		return
+	}
+	if afterBatch {
+		m.emptyAfter.Inc()
+	} else {
+		m.emptyBefore.Inc()
+	}
+}
+
+// PoolRefiller keeps the waiting pool topped up to a target size, so GetNext
+// rarely has to create games inline.
+type PoolRefiller struct {
+	store   ports.GameStore
+	target  int
+	metrics *PoolMetrics
+}
+
+// NewPoolRefiller creates a PoolRefiller aiming for target waiting games.
+// A positive max caps the target. m may be nil.
+func NewPoolRefiller(store ports.GameStore, target, max int, m *PoolMetrics) *PoolRefiller {
+	if max > 0 && target > max {
+		target = max
+	}
+	return &PoolRefiller{store: store, target: target, metrics: m}
+}
+
+// Refill creates enough waiting games to reach the target and returns how
+// many were actually created, which may fall short on ID collisions; the
+// next tick makes up the difference.
+func (p *PoolRefiller) Refill(ctx context.Context) (int, error) {
+	waiting, err := p.store.CountWaiting(ctx)
+	if err != nil {
+		return 0, err
+	}
+	missing := p.target - waiting
+	if missing <= 0 {
+		return 0, nil
+	}
+	created, err := p.store.CreateWaitingBatch(ctx, missing)
+	if err != nil {
+		return created, err
+	}
+	p.metrics.batchCreated(created)
+	if created < missing {
+		log.Printf("pool refill: created %d of %d games", created, missing)
+	}
+	return created, nil
+}
+
+// SeedPool prepares the waiting pool at startup. With a positive minWaiting
+// it tops the pool up to minWaiting waiting games, however many exist already;
+// otherwise it creates batchSize games only when no game is active at all.
+// It returns how many games were created.
+func SeedPool(ctx context.Context, store ports.GameStore, minWaiting, batchSize int) (int, error) {
+	if minWaiting > 0 {
+		return NewPoolRefiller(store, minWaiting, 0, nil).Refill(ctx)
+	}
+	has, err := store.HasActiveGames(ctx)
+	if err != nil || has {
+		return 0, err
+	}
+	return store.CreateWaitingBatch(ctx, batchSize)
+}
+
+// Run refills the pool every interval until ctx is cancelled. Refill errors
+// are logged and retried on the next tick.
+func (p *PoolRefiller) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := p.Refill(ctx); err != nil {
+				log.Printf("pool refill failed: %v", err)
+			}
+		}
+	}
+}
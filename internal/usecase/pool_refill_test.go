@@ -0,0 +1,126 @@
+package usecase_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/metrics"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+func TestPoolRefiller_Refill(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(0)
+	if _, err := store.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+
+	p := usecase.NewPoolRefiller(store, 5, 0, nil)
+	created, err := p.Refill(ctx)
+	if err != nil {
+		t.Fatalf("Refill: %v", err)
+	}
+	if created != 3 {
+		t.Fatalf("created %d, want 3", created)
+	}
+	if created, _ := p.Refill(ctx); created != 0 {
+		t.Fatalf("full pool: created %d, want 0", created)
+	}
+}
+
+func TestPoolRefiller_RespectsMax(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(0)
+
+	if _, err := usecase.NewPoolRefiller(store, 10, 4, nil).Refill(ctx); err != nil {
+		t.Fatalf("Refill: %v", err)
+	}
+	n, err := store.CountWaiting(ctx)
+	if err != nil {
+		t.Fatalf("CountWaiting: %v", err)
+	}
+	if n != 4 {
+		t.Fatalf("waiting = %d, want 4", n)
+	}
+}
+
+// shortBatchStore creates one game fewer than asked, as if an ID collided.
+type shortBatchStore struct {
+	*memory.Store
+}
+
+func (s shortBatchStore) CreateWaitingBatch(ctx context.Context, count int) (int, error) {
+	return s.Store.CreateWaitingBatch(ctx, count-1)
+}
+
+func TestPoolRefiller_ReportsShortfall(t *testing.T) {
+	created, err := usecase.NewPoolRefiller(shortBatchStore{memory.New(0)}, 3, 0, nil).Refill(context.Background())
+	if err != nil {
+		t.Fatalf("Refill: %v", err)
+	}
+	if created != 2 {
+		t.Fatalf("created %d, want 2", created)
+	}
+}
+
+func TestPoolMetrics(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(0)
+	reg := metrics.NewRegistry()
+	pm := usecase.NewPoolMetrics(reg)
+
+	next := usecase.NewNextGame(store, memory.AlwaysAllow{}, 4, usecase.NextGameOptions{PoolMetrics: pm})
+	if _, err := next.GetNext(ctx, "192.0.2.1", "", uuid.New()); err != nil {
+		t.Fatalf("GetNext: %v", err)
+	}
+	if _, err := usecase.NewPoolRefiller(store, 10, 0, pm).Refill(ctx); err != nil {
+		t.Fatalf("Refill: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := reg.WriteText(&buf); err != nil {
+		t.Fatalf("WriteText: %v", err)
+	}
+	for _, want := range []string{
+		"game_batches_created_total 2\n",
+		`game_batch_size_bucket{le="5"} 1` + "\n",
+		`game_batch_size_bucket{le="10"} 2` + "\n",
+		"game_batch_size_sum 11\n",
+		`game_pool_empty_total{stage="before_batch"} 1` + "\n",
+		`game_pool_empty_total{stage="after_batch"} 0` + "\n",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("missing %q in:\n%s", want, buf.String())
+		}
+	}
+}
+
+func TestSeedPool(t *testing.T) {
+	ctx := context.Background()
+
+	// Two waiting games count as active, but fall short of the minimum.
+	store := memory.New(0)
+	if _, err := store.CreateWaitingBatch(ctx, 2); err != nil {
+		t.Fatalf("batch: %v", err)
+	}
+	created, err := usecase.SeedPool(ctx, store, 5, 10)
+	if err != nil {
+		t.Fatalf("SeedPool: %v", err)
+	}
+	if created != 3 {
+		t.Fatalf("created %d, want 3 to reach the minimum", created)
+	}
+
+	// Without a minimum, only an empty store is seeded.
+	if created, _ := usecase.SeedPool(ctx, store, 0, 10); created != 0 {
+		t.Fatalf("active store: created %d, want 0", created)
+	}
+	if created, _ := usecase.SeedPool(ctx, memory.New(0), 0, 10); created != 10 {
+		t.Fatalf("empty store: created %d, want a batch of 10", created)
+	}
+}
@@ -0,0 +1,50 @@
+package usecase
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+)
+
+// Purger deletes finished games once they are older than the retention
+// period.
+type Purger struct {
+	store     ports.GameStore
+	retention time.Duration
+	now       func() time.Time
+}
+
+// NewPurger creates a Purger keeping finished games for retention.
+func NewPurger(store ports.GameStore, retention time.Duration) *Purger {
+	return &Purger{store: store, retention: retention, now: time.Now}
+}
+
+// Purge deletes finished games last updated before now-retention and returns
+// how many were deleted.
+func (p *Purger) Purge(ctx context.Context) (int, error) {
+	return p.store.PurgeFinished(ctx, p.now().Add(-p.retention))
+}
+
+// Run purges every interval until ctx is cancelled. Errors are logged and
+// retried on the next tick.
+func (p *Purger) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := p.Purge(ctx)
+			if err != nil {
+				log.Printf("purge failed: %v", err)
+				continue
+			}
+			if n > 0 {
+				log.Printf("purged %d finished games", n)
+			}
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package usecase_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+func TestPurger_DeletesOnlyOldFinishedGames(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(3)
+	games := sampleGames(t, store, 3)
+	finish := func(g *game.Game, updatedAt time.Time) {
+		seedGame(t, store, g.ID, func(done *game.Game) {
+			done.Status = game.StatusDraw
+			done.UpdatedAt = updatedAt
+		})
+	}
+	old, recent, waiting := games[0], games[1], games[2]
+	finish(old, time.Now().Add(-48*time.Hour))
+	finish(recent, time.Now())
+
+	n, err := usecase.NewPurger(store, 24*time.Hour).Purge(ctx)
+	if err != nil {
+		t.Fatalf("Purge: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("want 1 purged game, got %d", n)
+	}
+	if _, err := store.GetByID(ctx, old.ID); err != ports.ErrNotFound {
+		t.Fatalf("old game: want ErrNotFound, got %v", err)
+	}
+	if _, err := store.GetByID(ctx, recent.ID); err != nil {
+		t.Fatalf("recent game: %v", err)
+	}
+	if _, err := store.GetByID(ctx, waiting.ID); err != nil {
+		t.Fatalf("waiting game: %v", err)
+	}
+}
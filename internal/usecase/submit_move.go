@@ -2,7 +2,13 @@ package usecase
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/google/uuid"
 
@@ -12,9 +18,16 @@ import (
 
 // SubmitMoveRequest is the input to SubmitMove.
 type SubmitMoveRequest struct {
-	UCI             string
-	ExpectedVersion int
+	UCI string
+	// ExpectedVersion is the state_version the client saw. It may only be
+	// omitted (nil) for a game nobody has moved in yet, where it counts as 0.
+	ExpectedVersion *int
 	ClientNonce     *string
+	// IncludeLegal requests the legal moves of the resulting position.
+	IncludeLegal bool
+	// UserAgent is the submitting client's User-Agent header. It is stored
+	// only when MoveSubmitterOptions.RecordUserAgent is set.
+	UserAgent string
 }
 
 // SubmitMoveResult is the output of a successful SubmitMove.
@@ -23,22 +36,111 @@ type SubmitMoveResult struct {
 	Game            *game.Game
 	History         []game.MoveHistoryItem
 	ShouldFetchNext bool
+	// WasFirstMove is true when this move opened the game (ply 0 -> 1).
+	WasFirstMove bool
+	// LegalMoves is set only when IncludeLegal was requested.
+	LegalMoves []string
 }
 
+// ErrMissingExpectedVersion is returned when a move on a game that already has
+// moves omits ExpectedVersion.
+var ErrMissingExpectedVersion = errors.New("expected version is required once a game has moves")
+
+// ErrCooldown is returned when a client submits a move before its cooldown
+// has elapsed. Use errors.As with *CooldownError to read the remaining wait.
+var ErrCooldown = errors.New("move cooldown active")
+
+// CooldownError reports how long the client must wait before moving again.
+type CooldownError struct {
+	RetryAfter time.Duration
+}
+
+func (e *CooldownError) Error() string {
+	return fmt.Sprintf("%v: retry after %s", ErrCooldown, e.RetryAfter)
+}
+
+func (e *CooldownError) Unwrap() error { return ErrCooldown }
+
+// VersionConflictError carries the game's current state with a version
+// conflict, so the client can re-render and resubmit without another fetch.
+// It unwraps to ports.ErrVersionConflict.
+type VersionConflictError struct {
+	Game    *game.Game
+	History []game.MoveHistoryItem
+}
+
+func (e *VersionConflictError) Error() string {
+	return fmt.Sprintf("%v: current state_version %d", ports.ErrVersionConflict, e.Game.StateVersion)
+}
+
+func (e *VersionConflictError) Unwrap() error { return ports.ErrVersionConflict }
+
+// MoveSubmitterOptions holds optional move-submission behavior.
+type MoveSubmitterOptions struct {
+	// Cooldown is the minimum time between two accepted moves of the same
+	// client across all games. Zero disables it.
+	Cooldown time.Duration
+
+	// Blocklist rejects moves from banned clients. Nil disables the check.
+	Blocklist *Blocklist
+
+	// DeadLetter additionally writes moves that fail to persist with an
+	// unexpected error to the store's failed-moves log. They are always
+	// logged.
+	DeadLetter bool
+
+	// RecordUserAgent stores the submitting User-Agent (truncated to
+	// MaxUserAgentLen bytes) with each move for analytics.
+	RecordUserAgent bool
+
+	// BlunderThresholdCP flags moves whose heuristic eval swing against the
+	// mover exceeds it, in centipawns (see game.IsBlunder). Zero disables
+	// the flag.
+	BlunderThresholdCP int
+
+	// RejectMovesOnWaiting fails moves on a game still in waiting status with
+	// game.ErrGameNotOngoing. By default such a move is applied and makes the
+	// game ongoing, as game.ApplyMove allows; claiming normally activates
+	// the game first, so a waiting game here means it was never claimed
+	// through GetNext.
+	RejectMovesOnWaiting bool
+
+	// Notifier is told about games finished by an accepted move. Optional.
+	Notifier ports.GameCompletionNotifier
+
+	// DraftTTL is how long a saved move draft is kept; zero selects
+	// DefaultDraftTTL.
+	DraftTTL time.Duration
+	// MaxDrafts caps how many drafts are held at once; zero selects
+	// DefaultMaxDrafts.
+	MaxDrafts int
+}
+
+// MaxUserAgentLen caps stored User-Agent strings, in bytes.
+const MaxUserAgentLen = 256
+
 // MoveSubmitter handles move submission.
 type MoveSubmitter struct {
-	store ports.GameStore
-	rl    ports.RateLimiter
+	store  ports.GameStore
+	rl     ports.RateLimiter
+	opts   MoveSubmitterOptions
+	drafts *moveDrafts
 }
 
-func NewMoveSubmitter(store ports.GameStore, rl ports.RateLimiter) *MoveSubmitter {
-	return &MoveSubmitter{store: store, rl: rl}
+func NewMoveSubmitter(store ports.GameStore, rl ports.RateLimiter, opts MoveSubmitterOptions) *MoveSubmitter {
+	return &MoveSubmitter{store: store, rl: rl, opts: opts, drafts: newMoveDrafts(opts.DraftTTL, opts.MaxDrafts)}
 }
 
 // SubmitMove validates and applies a move for clientID in gameID.
 // clientID must have been assigned to the game via GetNext and must not have
-// already moved. Returns ErrNotAssigned (403), ErrAlreadyMoved (409),
-// ErrVersionConflict (409), or domain errors on invalid/illegal moves (422).
+// already moved. Returns ErrClientBlocked (403), ErrNotAssigned (403),
+// ErrAlreadyMoved (409), ErrClaimHeld (409), *VersionConflictError (409),
+// *CooldownError (429), or domain errors on invalid/illegal moves (422).
+//
+// Retries are idempotent: if the client already moved in this game with the
+// same UCI, the recorded move is returned as a success instead of an error.
+// That needs the recorded move, so when the store does not persist history a
+// retry gets the original error (usually ErrAlreadyMoved) instead.
 func (m *MoveSubmitter) SubmitMove(
 	ctx context.Context,
 	ip, token string,
@@ -48,20 +150,55 @@ func (m *MoveSubmitter) SubmitMove(
 	if !m.rl.Allow(ip, token) {
 		return SubmitMoveResult{}, ErrRateLimited
 	}
+	if m.opts.Blocklist.IsBlocked(clientID) {
+		return SubmitMoveResult{}, ErrClientBlocked
+	}
+
+	res, err := m.submit(ctx, gameID, clientID, req)
+	if err != nil && mayBeRetry(err) {
+		return m.replayRecorded(ctx, gameID, clientID, req, err)
+	}
+	return res, err
+}
+
+// versionConflict reloads the game for a VersionConflictError, falling back to
+// the bare ErrVersionConflict if the reload fails.
+func (m *MoveSubmitter) versionConflict(ctx context.Context, gameID uuid.UUID) error {
+	g, history, err := m.store.GetGameWithHistory(ctx, gameID)
+	if err != nil {
+		return ports.ErrVersionConflict
+	}
+	return &VersionConflictError{Game: g, History: history}
+}
+
+func (m *MoveSubmitter) submit(ctx context.Context, gameID, clientID uuid.UUID, req SubmitMoveRequest) (SubmitMoveResult, error) {
+	if err := m.checkCooldown(ctx, clientID); err != nil {
+		return SubmitMoveResult{}, err
+	}
 
 	// Load current game state for domain validation.
 	g, err := m.store.GetByID(ctx, gameID)
 	if err != nil {
 		return SubmitMoveResult{}, err
 	}
+	if g.Status == game.StatusWaiting && m.opts.RejectMovesOnWaiting {
+		return SubmitMoveResult{}, game.ErrGameNotOngoing
+	}
 
-	// Client-side version check (early fast-fail before taking locks).
-	if g.StateVersion != req.ExpectedVersion {
-		return SubmitMoveResult{}, ports.ErrVersionConflict
+	// Client-side version check (early fast-fail before taking locks). A
+	// missing version could only match by accident once the game has moved.
+	expected := 0
+	switch {
+	case req.ExpectedVersion != nil:
+		expected = *req.ExpectedVersion
+	case g.PlyCount > 0:
+		return SubmitMoveResult{}, ErrMissingExpectedVersion
+	}
+	if g.StateVersion != expected {
+		return SubmitMoveResult{}, m.versionConflict(ctx, gameID)
 	}
 
-	// Apply domain move (pure, no side effects).
-	newGame, rec, err := g.ApplyMove(req.UCI, time.Now())
+	newGame, rec, err := m.applyMove(g, req.UCI, req.UserAgent)
 	if err != nil {
 		return SubmitMoveResult{}, err
 	}
@@ -72,13 +209,260 @@ func (m *MoveSubmitter) SubmitMove(
 	// Atomically persist: checks assignment, has_moved, CAS on version.
 	history, err := m.store.PersistMove(ctx, gameID, clientID, newGame, rec, ply)
 	if err != nil {
+		if !isExpectedPersistErr(err) {
+			m.deadLetter(ctx, gameID, clientID, rec, ply, err)
+		}
 		return SubmitMoveResult{}, err
 	}
 
-	return SubmitMoveResult{
+	m.drafts.clear(draftKey{gameID, clientID})
+	notifyIfFinished(m.opts.Notifier, newGame, history)
+
+	res := SubmitMoveResult{
 		Move:            rec,
 		Game:            newGame,
 		History:         history,
 		ShouldFetchNext: newGame.Status != game.StatusOngoing,
-	}, nil
+		WasFirstMove:    newGame.PlyCount == 1,
+	}
+	if req.IncludeLegal {
+		if res.LegalMoves, err = newGame.LegalMoves(); err != nil {
+			return SubmitMoveResult{}, err
+		}
+	}
+	return res, nil
+}
+
+// mayBeRetry reports whether err is what a client retrying a move that was
+// already accepted would get: the game has moved on, or the client is on
+// cooldown or already recorded as moved. Illegal moves and finished games are
+// not retries: the accepted move advanced state_version, so a retry fails the
+// version check before the move is validated.
+func mayBeRetry(err error) bool {
+	return errors.Is(err, ports.ErrAlreadyMoved) ||
+		errors.Is(err, ports.ErrVersionConflict) ||
+		errors.Is(err, ErrCooldown)
+}
+
+// replayRecorded returns the client's recorded move in gameID as a success
+// when it matches req.UCI. A different recorded move yields ErrAlreadyMoved;
+// no recorded move returns cause unchanged.
+func (m *MoveSubmitter) replayRecorded(ctx context.Context, gameID, clientID uuid.UUID, req SubmitMoveRequest, cause error) (SubmitMoveResult, error) {
+	g, history, ok := m.recordedHistory(ctx, gameID, clientID, cause)
+	if !ok {
+		return SubmitMoveResult{}, cause
+	}
+	idx := slices.IndexFunc(history, func(item game.MoveHistoryItem) bool { return item.ClientID == clientID })
+	if idx < 0 {
+		return SubmitMoveResult{}, cause
+	}
+	item := history[idx]
+	if item.UCI != req.UCI {
+		return SubmitMoveResult{}, ports.ErrAlreadyMoved
+	}
+	san, _ := game.SAN(item.FENBefore, item.UCI)
+
+	res := SubmitMoveResult{
+		Move: game.MoveRecord{
+			ID:          item.ID,
+			UCI:         item.UCI,
+			FENBefore:   item.FENBefore,
+			FENAfter:    item.FENAfter,
+			IsCapture:   item.IsCapture,
+			IsEnPassant: item.IsEnPassant,
+			IsCastle:    item.IsCastle,
+			IsBlunder:   item.IsBlunder,
+			GaveCheck:   strings.HasSuffix(san, "+") || strings.HasSuffix(san, "#"),
+			CreatedAt:   item.CreatedAt,
+		},
+		Game:            g,
+		History:         history,
+		ShouldFetchNext: g.Status != game.StatusOngoing,
+		WasFirstMove:    item.Ply == 0,
+	}
+	if req.IncludeLegal {
+		var err error
+		if res.LegalMoves, err = g.LegalMoves(); err != nil {
+			return SubmitMoveResult{}, err
+		}
+	}
+	return res, nil
+}
+
+// recordedHistory returns the game and history to look for the client's
+// recorded move in, and false when it cannot have one. A version conflict
+// already carries both; otherwise a lock-free has-moved check runs first, so
+// a client that never moved costs no history load.
+func (m *MoveSubmitter) recordedHistory(ctx context.Context, gameID, clientID uuid.UUID, cause error) (*game.Game, []game.MoveHistoryItem, bool) {
+	var conflict *VersionConflictError
+	if errors.As(cause, &conflict) {
+		return conflict.Game, conflict.History, true
+	}
+	if !errors.Is(cause, ports.ErrAlreadyMoved) {
+		if _, moved, err := m.store.IsAssigned(ctx, gameID, clientID); err != nil || !moved {
+			return nil, nil, false
+		}
+	}
+	g, history, err := m.store.GetGameWithHistory(ctx, gameID)
+	if err != nil {
+		return nil, nil, false
+	}
+	return g, history, true
+}
+
+// MoveChooser picks the UCI move to play in a freshly claimed game.
+type MoveChooser func(g *game.Game) (string, error)
+
+// ClaimAndMove claims the next game for clientID and plays the move returned
+// by choose in a single store transaction. If choose fails, the move is
+// illegal, or persisting it fails, the claim is rolled back as well.
+// userAgent is recorded as in SubmitMove.
+func (m *MoveSubmitter) ClaimAndMove(
+	ctx context.Context,
+	ip, token string,
+	clientID uuid.UUID,
+	userAgent string,
+	choose MoveChooser,
+) (SubmitMoveResult, error) {
+	if !m.rl.Allow(ip, token) {
+		return SubmitMoveResult{}, ErrRateLimited
+	}
+	if m.opts.Blocklist.IsBlocked(clientID) {
+		return SubmitMoveResult{}, ErrClientBlocked
+	}
+	if err := m.checkCooldown(ctx, clientID); err != nil {
+		return SubmitMoveResult{}, err
+	}
+
+	var result SubmitMoveResult
+	err := m.store.InTx(ctx, func(tx ports.GameStore) error {
+		g, _, err := tx.ClaimNextGame(ctx, clientID)
+		if err != nil {
+			return err
+		}
+		uci, err := choose(g)
+		if err != nil {
+			return err
+		}
+		newGame, rec, err := m.applyMove(g, uci, userAgent)
+		if err != nil {
+			return err
+		}
+		history, err := tx.PersistMove(ctx, g.ID, clientID, newGame, rec, newGame.PlyCount-1)
+		if err != nil {
+			return err
+		}
+		result = SubmitMoveResult{
+			Move:            rec,
+			Game:            newGame,
+			History:         history,
+			ShouldFetchNext: newGame.Status != game.StatusOngoing,
+			WasFirstMove:    newGame.PlyCount == 1,
+		}
+		return nil
+	})
+	if err != nil {
+		return SubmitMoveResult{}, err
+	}
+	m.drafts.clear(draftKey{result.Game.ID, clientID})
+	notifyIfFinished(m.opts.Notifier, result.Game, result.History)
+	return result, nil
+}
+
+// applyMove plays uci on g and fills in the parts of the move record that
+// depend on submitter options, so every entry point records moves alike.
+func (m *MoveSubmitter) applyMove(g *game.Game, uci, userAgent string) (*game.Game, game.MoveRecord, error) {
+	// Apply domain move (pure, no side effects).
+	newGame, rec, err := g.ApplyMove(uci, time.Now())
+	if err != nil {
+		return nil, game.MoveRecord{}, err
+	}
+	if m.opts.RecordUserAgent && userAgent != "" {
+		ua := truncateUTF8(userAgent, MaxUserAgentLen)
+		rec.UserAgent = &ua
+	}
+	rec.IsBlunder = game.IsBlunder(g, newGame, m.opts.BlunderThresholdCP)
+	return newGame, rec, nil
+}
+
+// notifyIfFinished tells n, if set, about g once its move has committed and
+// ended the game.
+func notifyIfFinished(n ports.GameCompletionNotifier, g *game.Game, history []game.MoveHistoryItem) {
+	if n == nil || g.Status == game.StatusOngoing || g.Status == game.StatusWaiting {
+		return
+	}
+	n.GameCompleted(g, history)
+}
+
+// checkCooldown returns a *CooldownError if clientID moved too recently.
+func (m *MoveSubmitter) checkCooldown(ctx context.Context, clientID uuid.UUID) error {
+	if m.opts.Cooldown <= 0 {
+		return nil
+	}
+	last, err := m.store.LastMoveAt(ctx, clientID)
+	if err != nil {
+		return err
+	}
+	if last == nil {
+		return nil
+	}
+	if wait := m.opts.Cooldown - time.Since(*last); wait > 0 {
+		return &CooldownError{RetryAfter: wait}
+	}
+	return nil
+}
+
+// truncateUTF8 shortens s to at most n bytes without splitting a rune.
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// isExpectedPersistErr reports whether err is one of the sentinel outcomes of
+// PersistMove rather than an infrastructure failure.
+func isExpectedPersistErr(err error) bool {
+	return errors.Is(err, ports.ErrNotAssigned) ||
+		errors.Is(err, ports.ErrAlreadyMoved) ||
+		errors.Is(err, ports.ErrClaimHeld) ||
+		errors.Is(err, ports.ErrVersionConflict) ||
+		errors.Is(err, ports.ErrNotFound)
+}
+
+// deadLetter retains a validated move that failed to persist, so transient
+// store failures leave evidence behind.
+func (m *MoveSubmitter) deadLetter(
+	ctx context.Context,
+	gameID, clientID uuid.UUID,
+	rec game.MoveRecord,
+	ply int,
+	cause error,
+) {
+	log.Printf("dead-letter move: game=%s client=%s ply=%d uci=%s fen_before=%q fen_after=%q: %v",
+		gameID, clientID, ply, rec.UCI, rec.FENBefore, rec.FENAfter, cause)
+	if !m.opts.DeadLetter {
+		return
+	}
+
+	// The request context may already be cancelled; the record must still land.
+	dlCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 5*time.Second)
+	defer cancel()
+	fm := ports.FailedMove{
+		ID:        rec.ID,
+		GameID:    gameID,
+		ClientID:  clientID,
+		Ply:       ply,
+		UCI:       rec.UCI,
+		FENBefore: rec.FENBefore,
+		FENAfter:  rec.FENAfter,
+		Error:     cause.Error(),
+		CreatedAt: rec.CreatedAt,
+	}
+	if err := m.store.RecordFailedMove(dlCtx, fm); err != nil {
+		log.Printf("dead-letter write failed: game=%s client=%s: %v", gameID, clientID, err)
+	}
 }
@@ -0,0 +1,285 @@
+package usecase_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/randomtoy/random-chess-backend/internal/adapters/memory"
+	"github.com/randomtoy/random-chess-backend/internal/domain/game"
+	"github.com/randomtoy/random-chess-backend/internal/ports"
+	"github.com/randomtoy/random-chess-backend/internal/usecase"
+)
+
+func TestClaimAndMove(t *testing.T) {
+	store := memory.New(1)
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{})
+	clientID := uuid.New()
+
+	res, err := m.ClaimAndMove(context.Background(), "", "", clientID, "", func(*game.Game) (string, error) {
+		return "e2e4", nil
+	})
+	if err != nil {
+		t.Fatalf("ClaimAndMove: %v", err)
+	}
+	if res.Game.PlyCount != 1 || len(res.History) != 1 || !res.WasFirstMove {
+		t.Fatalf("unexpected result: ply=%d history=%d first=%v", res.Game.PlyCount, len(res.History), res.WasFirstMove)
+	}
+	if _, active, _ := store.FindActiveClaim(context.Background(), clientID); active {
+		t.Fatal("client should have no open claim after moving")
+	}
+}
+
+// TestClaimAndMove_RollsBackClaim: a failed move leaves no claim behind, so
+// the game is still available to the same client.
+func TestClaimAndMove_RollsBackClaim(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{})
+	clientID := uuid.New()
+
+	_, err := m.ClaimAndMove(ctx, "", "", clientID, "", func(*game.Game) (string, error) {
+		return "e2e5", nil
+	})
+	if !errors.Is(err, game.ErrIllegalMove) {
+		t.Fatalf("want ErrIllegalMove, got %v", err)
+	}
+
+	n, err := store.CountAvailableForClient(ctx, clientID)
+	if err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("claim was not rolled back: %d games available, want 1", n)
+	}
+}
+
+// TestClaimAndMove_RecordsLikeSubmit: moves played through ClaimAndMove get
+// the same blunder flag and User-Agent as submitted ones.
+func TestClaimAndMove_RecordsLikeSubmit(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	// White is a queen up; Qf7 stalemates and throws the win away.
+	seedGame(t, store, sampleGames(t, store, 1)[0].ID, func(won *game.Game) {
+		won.FEN = "7k/4Q3/6K1/8/8/8/8/8 w - - 0 1"
+		won.SideToMove = "white"
+	})
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{
+		RecordUserAgent:    true,
+		BlunderThresholdCP: 300,
+	})
+
+	res, err := m.ClaimAndMove(ctx, "", "", uuid.New(), "bot/1.0", func(*game.Game) (string, error) {
+		return "e7f7", nil
+	})
+	if err != nil {
+		t.Fatalf("ClaimAndMove: %v", err)
+	}
+	if !res.Move.IsBlunder || len(res.History) != 1 || !res.History[0].IsBlunder {
+		t.Fatalf("blunder not flagged: move=%v history=%+v", res.Move.IsBlunder, res.History)
+	}
+	if res.Move.UserAgent == nil || *res.Move.UserAgent != "bot/1.0" {
+		t.Fatalf("user agent = %v, want bot/1.0", res.Move.UserAgent)
+	}
+}
+
+type recordingNotifier struct{ games []*game.Game }
+
+func (n *recordingNotifier) GameCompleted(g *game.Game, _ []game.MoveHistoryItem) {
+	n.games = append(n.games, g)
+}
+
+func TestSubmitMove_NotifiesOnCompletion(t *testing.T) {
+	notifier := &recordingNotifier{}
+	m := usecase.NewMoveSubmitter(memory.New(1), memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{Notifier: notifier})
+
+	// Fool's mate, one client per ply.
+	moves := []string{"f2f3", "e7e5", "g2g4", "d8h4"}
+	var res usecase.SubmitMoveResult
+	for range moves {
+		var err error
+		res, err = m.ClaimAndMove(context.Background(), "", "", uuid.New(), "", func(g *game.Game) (string, error) {
+			return moves[g.PlyCount], nil
+		})
+		if err != nil {
+			t.Fatalf("ClaimAndMove: %v", err)
+		}
+		if res.Game.Status == game.StatusCheckmate {
+			break
+		}
+	}
+	if res.Game.Status != game.StatusCheckmate {
+		t.Fatalf("game not finished: %s", res.Game.Status)
+	}
+	if len(notifier.games) != 1 || notifier.games[0].ID != res.Game.ID {
+		t.Fatalf("want one notification for %s, got %d", res.Game.ID, len(notifier.games))
+	}
+}
+
+func TestSubmitMove_WaitingGame(t *testing.T) {
+	ctx := context.Background()
+	// waitingClaim returns a store whose only game clientID has claimed but
+	// which is still waiting, as if it had never been activated.
+	waitingClaim := func(clientID uuid.UUID) (*memory.Store, uuid.UUID) {
+		store := memory.New(1)
+		g, _, err := store.ClaimNextGame(ctx, clientID)
+		if err != nil {
+			t.Fatalf("claim: %v", err)
+		}
+		seedGame(t, store, g.ID, func(waiting *game.Game) { waiting.Status = game.StatusWaiting })
+		return store, g.ID
+	}
+	clientID := uuid.New()
+
+	// By default the move is applied and activates the game.
+	store, gameID := waitingClaim(clientID)
+	ver := 1
+	res, err := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{}).
+		SubmitMove(ctx, "", "", gameID, clientID, usecase.SubmitMoveRequest{UCI: "e2e4", ExpectedVersion: &ver})
+	if err != nil {
+		t.Fatalf("default policy: %v", err)
+	}
+	if res.Game.Status != game.StatusOngoing {
+		t.Fatalf("default policy: status %s, want ongoing", res.Game.Status)
+	}
+
+	store, gameID = waitingClaim(clientID)
+	_, err = usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{RejectMovesOnWaiting: true}).
+		SubmitMove(ctx, "", "", gameID, clientID, usecase.SubmitMoveRequest{UCI: "e2e4", ExpectedVersion: &ver})
+	if !errors.Is(err, game.ErrGameNotOngoing) {
+		t.Fatalf("reject policy: want ErrGameNotOngoing, got %v", err)
+	}
+}
+
+func TestDraft_Expires(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	g, _, err := store.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{DraftTTL: 20 * time.Millisecond})
+	clientID := uuid.New()
+
+	if _, err := m.SaveDraft(ctx, "", "", g.ID, clientID, usecase.MoveDraft{From: "e2", To: "e4"}); err != nil {
+		t.Fatalf("SaveDraft: %v", err)
+	}
+	if d, err := m.Draft(ctx, "", "", g.ID, clientID); err != nil || d.To != "e4" {
+		t.Fatalf("Draft: got %+v, %v", d, err)
+	}
+	time.Sleep(30 * time.Millisecond)
+	if _, err := m.Draft(ctx, "", "", g.ID, clientID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("expired draft: got %v, want ErrNotFound", err)
+	}
+}
+
+// TestDraft_Capped: once MaxDrafts are held, saving another evicts the oldest.
+func TestDraft_Capped(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	g, _, err := store.ClaimNextGame(ctx, uuid.New())
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{MaxDrafts: 2})
+	clients := []uuid.UUID{uuid.New(), uuid.New(), uuid.New()}
+	for _, c := range clients {
+		if _, err := m.SaveDraft(ctx, "", "", g.ID, c, usecase.MoveDraft{From: "e2", To: "e4"}); err != nil {
+			t.Fatalf("SaveDraft: %v", err)
+		}
+		time.Sleep(time.Millisecond) // distinct SavedAt
+	}
+	if _, err := m.Draft(ctx, "", "", g.ID, clients[0]); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("oldest draft: got %v, want ErrNotFound", err)
+	}
+	for _, c := range clients[1:] {
+		if _, err := m.Draft(ctx, "", "", g.ID, c); err != nil {
+			t.Fatalf("newer draft evicted: %v", err)
+		}
+	}
+}
+
+// TestClaimAndMove_ClearsDraft: a move played through ClaimAndMove drops the
+// client's draft for that game, as SubmitMove does.
+func TestClaimAndMove_ClearsDraft(t *testing.T) {
+	ctx := context.Background()
+	store := memory.New(1)
+	games := sampleGames(t, store, 1)
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{})
+	clientID := uuid.New()
+	if _, err := m.SaveDraft(ctx, "", "", games[0].ID, clientID, usecase.MoveDraft{From: "e2", To: "e4"}); err != nil {
+		t.Fatalf("SaveDraft: %v", err)
+	}
+
+	res, err := m.ClaimAndMove(ctx, "", "", clientID, "", func(*game.Game) (string, error) {
+		return "e2e4", nil
+	})
+	if err != nil {
+		t.Fatalf("ClaimAndMove: %v", err)
+	}
+	if res.Game.ID != games[0].ID {
+		t.Fatalf("claimed %s, want %s", res.Game.ID, games[0].ID)
+	}
+	if _, err := m.Draft(ctx, "", "", games[0].ID, clientID); !errors.Is(err, ports.ErrNotFound) {
+		t.Fatalf("draft after move: got %v, want ErrNotFound", err)
+	}
+}
+
+// historyLoadStore counts full game-with-history loads.
+type historyLoadStore struct {
+	*memory.Store
+	loads int
+}
+
+func (s *historyLoadStore) GetGameWithHistory(ctx context.Context, id uuid.UUID) (*game.Game, []game.MoveHistoryItem, error) {
+	s.loads++
+	return s.Store.GetGameWithHistory(ctx, id)
+}
+
+// TestSubmitMove_ReplayLoadsHistoryOnce: a retry is answered from the history
+// the version conflict already loaded, and rejected moves by a client that
+// never moved load no history at all.
+func TestSubmitMove_ReplayLoadsHistoryOnce(t *testing.T) {
+	ctx := context.Background()
+	store := &historyLoadStore{Store: memory.New(1)}
+	m := usecase.NewMoveSubmitter(store, memory.AlwaysAllow{}, usecase.MoveSubmitterOptions{})
+	mover := uuid.New()
+	g, _, err := store.ClaimNextGame(ctx, mover)
+	if err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	ver := 0
+	first, err := m.SubmitMove(ctx, "", "", g.ID, mover, usecase.SubmitMoveRequest{UCI: "e2e4", ExpectedVersion: &ver})
+	if err != nil {
+		t.Fatalf("first submit: %v", err)
+	}
+
+	store.loads = 0
+	retry, err := m.SubmitMove(ctx, "", "", g.ID, mover, usecase.SubmitMoveRequest{UCI: "e2e4", ExpectedVersion: &ver})
+	if err != nil || retry.Move.ID != first.Move.ID {
+		t.Fatalf("retry: got move %v, err %v; want %v", retry.Move.ID, err, first.Move.ID)
+	}
+	if store.loads != 1 {
+		t.Fatalf("retry loaded history %d times, want 1", store.loads)
+	}
+
+	other := uuid.New()
+	if _, _, err := store.ClaimNextGame(ctx, other); err != nil {
+		t.Fatalf("claim: %v", err)
+	}
+	store.loads = 0
+	ver = first.Game.StateVersion
+	if _, err := m.SubmitMove(ctx, "", "", g.ID, other, usecase.SubmitMoveRequest{UCI: "e7e4", ExpectedVersion: &ver}); !errors.Is(err, game.ErrIllegalMove) {
+		t.Fatalf("illegal move: want ErrIllegalMove, got %v", err)
+	}
+	stale := 0
+	if _, err := m.SubmitMove(ctx, "", "", g.ID, other, usecase.SubmitMoveRequest{UCI: "e7e5", ExpectedVersion: &stale}); !errors.Is(err, ports.ErrVersionConflict) {
+		t.Fatalf("stale version: want ErrVersionConflict, got %v", err)
+	}
+	if store.loads != 1 {
+		t.Fatalf("rejected moves loaded history %d times, want 1 for the conflict", store.loads)
+	}
+}